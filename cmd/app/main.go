@@ -2,20 +2,107 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"os"
 	"ozon/internal/app"
 	"ozon/pkg/logger"
+	"slices"
 )
 
+const usage = `usage:
+  main [flags]                  run the service
+  main config print [flags]     print the effective config, secrets redacted
+  main migrate up [flags]       apply the pending migrations
+  main migrate down [flags]     roll back the last applied migration
+  main migrate status [flags]   list the migrations and when each was applied`
+
 func main() {
 
-	ctx := context.Background()
-	cfg, err := app.LoadConfig()
-	logger.InitLogger()
+	args := os.Args[1:]
+
+	if len(args) > 0 && args[0] == "config" {
+		os.Exit(configCommand(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "migrate" {
+		os.Exit(migrateCommand(args[1:]))
+	}
+
+	cfg, err := loadConfig(args)
 	if err != nil {
-		panic(err)
+		os.Exit(2)
+	}
+
+	if err := logger.InitLogger(cfg.Log); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to initialize logger:", err)
+		os.Exit(1)
 	}
 
+	ctx := context.Background()
 	a := app.New(ctx, cfg)
+
+	if err := app.WatchConfig(args, a.Reload); err != nil {
+		logger.GetLogger().Warn("config reload is disabled", zap.String("err", err.Error()))
+	}
+
 	app.Run(ctx, a)
 
 }
+
+func configCommand(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	cfg, err := loadConfig(args[1:])
+	if err != nil {
+		return 2
+	}
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func migrateCommand(args []string) int {
+	if len(args) == 0 || !slices.Contains([]string{"up", "down", "status"}, args[0]) {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	cfg, err := loadConfig(args[1:])
+	if err != nil {
+		return 2
+	}
+
+	if err := logger.InitLogger(cfg.Log); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to initialize logger:", err)
+		return 1
+	}
+
+	if err := app.Migrate(context.Background(), cfg, args[0], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// loadConfig reports errors itself, there is no logger before the config.
+func loadConfig(args []string) (app.Config, error) {
+	cfg, err := app.LoadConfig(args)
+	if errors.Is(err, app.ErrHelp) {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	return cfg, err
+}
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE post_tags (
+                           post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+                           tag VARCHAR(50) NOT NULL,
+                           created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                           PRIMARY KEY (post_id, tag)
+);
+
+CREATE INDEX post_tags_tag_idx ON post_tags (tag, post_id DESC);
+CREATE INDEX post_tags_created_at_idx ON post_tags (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS post_tags;
+-- +goose StatementEnd
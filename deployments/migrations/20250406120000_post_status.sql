@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'PUBLISHED'
+        CHECK (status IN ('DRAFT', 'SCHEDULED', 'PUBLISHED')),
+    ADD COLUMN publish_at TIMESTAMP;
+
+CREATE INDEX posts_scheduled_idx ON posts (publish_at) WHERE status = 'SCHEDULED';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS posts_scheduled_idx;
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS publish_at,
+    DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts ADD COLUMN pinned_at TIMESTAMP;
+ALTER TABLE comments ADD COLUMN pinned_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE comments DROP COLUMN IF EXISTS pinned_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS pinned_at;
+-- +goose StatementEnd
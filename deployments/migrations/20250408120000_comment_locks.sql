@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE comments ADD COLUMN is_locked BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE FUNCTION comment_thread_locked(comment_id INT) RETURNS BOOLEAN AS $$
+    WITH RECURSIVE ancestors AS (
+        SELECT id, parent_comment_id, is_locked FROM comments WHERE id = comment_id
+        UNION ALL
+        SELECT c.id, c.parent_comment_id, c.is_locked FROM comments c JOIN ancestors a ON c.id = a.parent_comment_id
+    )
+    SELECT COALESCE(bool_or(is_locked), FALSE) FROM ancestors;
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS comment_thread_locked(INT);
+ALTER TABLE comments DROP COLUMN IF EXISTS is_locked;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX comments_post_id_idx ON comments (post_id, created_at) WHERE parent_comment_id IS NULL;
+CREATE INDEX comments_parent_comment_id_idx ON comments (parent_comment_id, created_at);
+
+CREATE FUNCTION comment_thread_size(comment_id INT) RETURNS BIGINT AS $$
+    WITH RECURSIVE descendants AS (
+        SELECT id FROM comments WHERE parent_comment_id = comment_id
+        UNION ALL
+        SELECT c.id FROM comments c JOIN descendants d ON c.parent_comment_id = d.id
+    )
+    SELECT COUNT(*) FROM descendants;
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS comment_thread_size(INT);
+DROP INDEX IF EXISTS comments_parent_comment_id_idx;
+DROP INDEX IF EXISTS comments_post_id_idx;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts ADD COLUMN comment_count INT NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN reply_count INT NOT NULL DEFAULT 0;
+
+UPDATE posts SET comment_count = (SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id);
+UPDATE comments SET reply_count = (SELECT COUNT(*) FROM comments r WHERE r.parent_comment_id = comments.id);
+
+CREATE FUNCTION comments_count_insert() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE posts SET comment_count = comment_count + 1 WHERE id = NEW.post_id;
+    IF NEW.parent_comment_id IS NOT NULL THEN
+        UPDATE comments SET reply_count = reply_count + 1 WHERE id = NEW.parent_comment_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION comments_count_delete() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE posts SET comment_count = comment_count - 1 WHERE id = OLD.post_id;
+    IF OLD.parent_comment_id IS NOT NULL THEN
+        UPDATE comments SET reply_count = reply_count - 1 WHERE id = OLD.parent_comment_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER comments_count_insert AFTER INSERT ON comments
+    FOR EACH ROW EXECUTE FUNCTION comments_count_insert();
+CREATE TRIGGER comments_count_delete AFTER DELETE ON comments
+    FOR EACH ROW EXECUTE FUNCTION comments_count_delete();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS comments_count_delete ON comments;
+DROP TRIGGER IF EXISTS comments_count_insert ON comments;
+DROP FUNCTION IF EXISTS comments_count_delete();
+DROP FUNCTION IF EXISTS comments_count_insert();
+ALTER TABLE comments DROP COLUMN IF EXISTS reply_count;
+ALTER TABLE posts DROP COLUMN IF EXISTS comment_count;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at DROP DEFAULT,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING NULLIF(updated_at, '0001-01-01 00:00:00') AT TIME ZONE 'UTC',
+    ALTER COLUMN publish_at TYPE TIMESTAMPTZ USING publish_at AT TIME ZONE 'UTC',
+    ALTER COLUMN pinned_at TYPE TIMESTAMPTZ USING pinned_at AT TIME ZONE 'UTC';
+
+ALTER TABLE comments
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at DROP DEFAULT,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING NULLIF(updated_at, '0001-01-01 00:00:00') AT TIME ZONE 'UTC',
+    ALTER COLUMN pinned_at TYPE TIMESTAMPTZ USING pinned_at AT TIME ZONE 'UTC';
+
+ALTER TABLE post_tags
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE post_tags
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE comments
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMP USING COALESCE(updated_at AT TIME ZONE 'UTC', '0001-01-01 00:00:00'),
+    ALTER COLUMN updated_at SET DEFAULT '0001-01-01 00:00:00',
+    ALTER COLUMN pinned_at TYPE TIMESTAMP USING pinned_at AT TIME ZONE 'UTC';
+
+ALTER TABLE posts
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMP USING COALESCE(updated_at AT TIME ZONE 'UTC', '0001-01-01 00:00:00'),
+    ALTER COLUMN updated_at SET DEFAULT '0001-01-01 00:00:00',
+    ALTER COLUMN publish_at TYPE TIMESTAMP USING publish_at AT TIME ZONE 'UTC',
+    ALTER COLUMN pinned_at TYPE TIMESTAMP USING pinned_at AT TIME ZONE 'UTC';
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE idempotency_keys (
+                                  scope VARCHAR(64) NOT NULL,
+                                  key VARCHAR(255) NOT NULL,
+                                  result_id INT NOT NULL,
+                                  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+                                  expires_at TIMESTAMPTZ NOT NULL,
+                                  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE outbox (
+                        id BIGSERIAL PRIMARY KEY,
+                        event_type VARCHAR(32) NOT NULL,
+                        aggregate_id INT NOT NULL,
+                        payload JSONB NOT NULL,
+                        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE webhooks (
+                          id SERIAL PRIMARY KEY,
+                          url TEXT NOT NULL,
+                          secret TEXT NOT NULL,
+                          events TEXT[] NOT NULL,
+                          created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webhook_deliveries (
+                                    id BIGSERIAL PRIMARY KEY,
+                                    webhook_id INT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+                                    event VARCHAR(32) NOT NULL,
+                                    payload JSONB NOT NULL,
+                                    status VARCHAR(16) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED')),
+                                    attempts INT NOT NULL DEFAULT 0,
+                                    response_code INT,
+                                    last_error TEXT,
+                                    next_attempt_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+                                    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+                                    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migrations of the Postgres storage, the
+// binary applies them itself with migrate up.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -2,18 +2,77 @@ package Subscription
 
 import (
 	"context"
+	"encoding/json"
+	"ozon/internal/outbox"
 	"ozon/internal/transport/graph/model"
+	"slices"
 	"sync"
+	"sync/atomic"
 )
 
+// subscriberBuffer is how many events a subscriber may fall behind. A
+// subscriber with a full buffer is disconnected, so a client that stopped
+// reading never holds up the others. It is above the batch of the outbox
+// relay, so a burst of events does not cut off subscribers that keep up.
+const subscriberBuffer = 256
+
+// subscriber owns the channel of one subscription. Its lock orders sends
+// against closing the channel, so the hub lock is not held while sending.
+type subscriber[T any] struct {
+	ch     chan T
+	lock   sync.Mutex
+	closed bool
+}
+
+func newSubscriber[T any]() *subscriber[T] {
+	return &subscriber[T]{ch: make(chan T, subscriberBuffer)}
+}
+
+// send never blocks. It closes the channel and returns false if the buffer
+// is full.
+func (s *subscriber[T]) send(value T) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return true
+	}
+
+	select {
+	case s.ch <- value:
+		return true
+	default:
+		s.closed = true
+		close(s.ch)
+		return false
+	}
+}
+
+func (s *subscriber[T]) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
 type Subscription struct {
-	commentSubscriptions map[string][]chan *model.Comment
+	commentSubscriptions map[string][]*subscriber[*model.Comment]
+	postSubscriptions    []*subscriber[*model.Post]
+	closed               bool
 	lock                 sync.Mutex
+
+	// The counts are changed under lock and read without it, so that
+	// metrics scrapes never wait for the hub.
+	commentCounts sync.Map // post ID -> *atomic.Int64
+	postCount     atomic.Int64
 }
 
 func New() *Subscription {
 	return &Subscription{
-		commentSubscriptions: make(map[string][]chan *model.Comment),
+		commentSubscriptions: make(map[string][]*subscriber[*model.Comment]),
 		lock:                 sync.Mutex{},
 	}
 }
@@ -22,42 +81,193 @@ func (p *Subscription) Subscribe(ctx context.Context, postId string) chan *model
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	ch := make(chan *model.Comment, 1)
-	p.commentSubscriptions[postId] = append(p.commentSubscriptions[postId], ch)
+	sub := newSubscriber[*model.Comment]()
+	if p.closed {
+		sub.close()
+		return sub.ch
+	}
+	p.commentSubscriptions[postId] = append(p.commentSubscriptions[postId], sub)
+	p.countComments(postId, 1)
 
-	return ch
+	return sub.ch
 }
 
+// Publish passes the comment to the subscribers of its post before
+// returning. Subscribers that fell behind are disconnected.
 func (p *Subscription) Publish(ctx context.Context, comment *model.Comment) {
-	go func() {
-		p.lock.Lock()
-		defer p.lock.Unlock()
-
-		if subscribers, ok := p.commentSubscriptions[comment.PostID]; ok {
-			for _, ch := range subscribers {
-				ch <- comment
-			}
+	p.lock.Lock()
+	subs := slices.Clone(p.commentSubscriptions[comment.PostID])
+	p.lock.Unlock()
+
+	for _, sub := range subs {
+		if !sub.send(comment) {
+			p.removeComment(comment.PostID, sub.ch)
 		}
-	}()
+	}
 }
 
 func (p *Subscription) Check(postId string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
 	_, exists := p.commentSubscriptions[postId]
 	return exists
 }
 
 func (p *Subscription) Unsubscribe(ctx context.Context, postId string, ch chan *model.Comment) {
+	if sub := p.removeComment(postId, ch); sub != nil {
+		sub.close()
+	}
+}
+
+// removeComment drops the comment subscriber of ch and returns it, nil if
+// it was removed before.
+func (p *Subscription) removeComment(postId string, ch chan *model.Comment) *subscriber[*model.Comment] {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
 	subs := p.commentSubscriptions[postId]
-	newSubs := make([]chan *model.Comment, 0, len(subs))
+	i := slices.IndexFunc(subs, func(sub *subscriber[*model.Comment]) bool { return sub.ch == ch })
+	if i < 0 {
+		return nil
+	}
+
+	sub := subs[i]
+	subs = slices.Delete(slices.Clone(subs), i, i+1)
+	if len(subs) == 0 {
+		delete(p.commentSubscriptions, postId)
+	} else {
+		p.commentSubscriptions[postId] = subs
+	}
+	p.countComments(postId, -1)
+
+	return sub
+}
+
+// countComments changes the number of comment subscribers of the post. It
+// must be called with the lock held.
+func (p *Subscription) countComments(postId string, delta int64) {
+	value, _ := p.commentCounts.LoadOrStore(postId, new(atomic.Int64))
+	if value.(*atomic.Int64).Add(delta) == 0 {
+		p.commentCounts.Delete(postId)
+	}
+}
+
+func (p *Subscription) SubscribePosts(ctx context.Context) chan *model.Post {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	sub := newSubscriber[*model.Post]()
+	if p.closed {
+		sub.close()
+		return sub.ch
+	}
+	p.postSubscriptions = append(p.postSubscriptions, sub)
+	p.postCount.Add(1)
+
+	return sub.ch
+}
+
+// PublishPost passes the post to the subscribers of new posts before
+// returning. Subscribers that fell behind are disconnected.
+func (p *Subscription) PublishPost(ctx context.Context, post *model.Post) {
+	p.lock.Lock()
+	subs := slices.Clone(p.postSubscriptions)
+	p.lock.Unlock()
+
 	for _, sub := range subs {
-		if sub != ch {
-			newSubs = append(newSubs, sub)
+		if !sub.send(post) {
+			p.removePost(sub.ch)
+		}
+	}
+}
+
+func (p *Subscription) UnsubscribePosts(ctx context.Context, ch chan *model.Post) {
+	if sub := p.removePost(ch); sub != nil {
+		sub.close()
+	}
+}
+
+// removePost drops the post subscriber of ch and returns it, nil if it was
+// removed before.
+func (p *Subscription) removePost(ch chan *model.Post) *subscriber[*model.Post] {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	i := slices.IndexFunc(p.postSubscriptions, func(sub *subscriber[*model.Post]) bool { return sub.ch == ch })
+	if i < 0 {
+		return nil
+	}
+
+	sub := p.postSubscriptions[i]
+	p.postSubscriptions = slices.Delete(slices.Clone(p.postSubscriptions), i, i+1)
+	p.postCount.Add(-1)
+
+	return sub
+}
+
+// Deliver passes outbox events on to the GraphQL subscribers. It returns
+// once the event is queued to every subscriber, one event at a time, so
+// the subscribers see the events in the order of the outbox.
+func (p *Subscription) Deliver(ctx context.Context, event outbox.Event) error {
+	switch event.Type {
+	case outbox.CommentCreated:
+		var comment model.Comment
+		if err := json.Unmarshal(event.Payload, &comment); err != nil {
+			return err
+		}
+		p.Publish(ctx, &comment)
+	case outbox.PostPublished:
+		var post model.Post
+		if err := json.Unmarshal(event.Payload, &post); err != nil {
+			return err
 		}
+		p.PublishPost(ctx, &post)
 	}
-	p.commentSubscriptions[postId] = newSubs
 
-	close(ch)
+	return nil
+}
+
+// CommentSubscribers returns the number of comment subscribers per post. It
+// does not take the lock.
+func (p *Subscription) CommentSubscribers() map[string]int {
+	output := make(map[string]int)
+	p.commentCounts.Range(func(key, value any) bool {
+		if n := value.(*atomic.Int64).Load(); n > 0 {
+			output[key.(string)] = int(n)
+		}
+		return true
+	})
+
+	return output
+}
+
+// PostSubscribers returns the number of subscribers to new posts. It does
+// not take the lock.
+func (p *Subscription) PostSubscribers() int {
+	return int(p.postCount.Load())
+}
+
+// Close completes all active subscriptions, closing their channels ends the
+// GraphQL subscriptions and gRPC streams reading them. Later subscriptions
+// complete immediately.
+func (p *Subscription) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closed = true
+
+	for postId, subs := range p.commentSubscriptions {
+		for _, sub := range subs {
+			sub.close()
+		}
+		delete(p.commentSubscriptions, postId)
+		p.commentCounts.Delete(postId)
+	}
+
+	for _, sub := range p.postSubscriptions {
+		sub.close()
+	}
+	p.postSubscriptions = nil
+	p.postCount.Store(0)
 }
@@ -0,0 +1,104 @@
+package Subscription
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ozon/internal/outbox"
+	"ozon/internal/transport/graph/model"
+)
+
+func commentEvent(t *testing.T, id int) outbox.Event {
+	payload, err := json.Marshal(model.Comment{ID: strconv.Itoa(id), PostID: "1"})
+	require.NoError(t, err)
+
+	return outbox.Event{ID: int64(id), Type: outbox.CommentCreated, Payload: payload}
+}
+
+func TestSubscription_Deliver(t *testing.T) {
+	ctx := context.Background()
+	hub := New()
+
+	stalled := hub.Subscribe(ctx, "1")
+	reader := hub.Subscribe(ctx, "1")
+
+	var got []string
+	for i := 1; i <= 3*subscriberBuffer; i++ {
+		require.NoError(t, hub.Deliver(ctx, commentEvent(t, i)))
+
+		comment := <-reader
+		got = append(got, comment.ID)
+	}
+
+	for i, id := range got {
+		assert.Equal(t, strconv.Itoa(i+1), id, "the events are delivered in order")
+	}
+
+	queued := 0
+	for range stalled {
+		queued++
+	}
+	assert.Equal(t, subscriberBuffer, queued, "the stalled subscriber is disconnected once its buffer is full")
+	assert.Equal(t, map[string]int{"1": 1}, hub.CommentSubscribers())
+
+	hub.Unsubscribe(ctx, "1", stalled)
+	hub.Close()
+
+	_, ok := <-reader
+	assert.False(t, ok, "closing the hub completes the subscriptions")
+}
+
+func TestSubscription_StalledPostSubscriber(t *testing.T) {
+	ctx := context.Background()
+	hub := New()
+
+	stalled := hub.SubscribePosts(ctx)
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.PublishPost(ctx, &model.Post{ID: strconv.Itoa(i)})
+	}
+
+	assert.Zero(t, hub.PostSubscribers())
+
+	hub.UnsubscribePosts(ctx, stalled)
+	hub.Close()
+}
+
+func TestSubscription_Counts(t *testing.T) {
+	ctx := context.Background()
+	hub := New()
+
+	first := hub.Subscribe(ctx, "1")
+	hub.Subscribe(ctx, "1")
+	hub.Subscribe(ctx, "2")
+	posts := hub.SubscribePosts(ctx)
+
+	hub.Unsubscribe(ctx, "1", first)
+	hub.Unsubscribe(ctx, "1", first)
+
+	// The counts are read while the hub is locked, as by a stuck publisher.
+	hub.lock.Lock()
+	counts := make(chan map[string]int, 1)
+	go func() {
+		counts <- hub.CommentSubscribers()
+		counts <- map[string]int{"posts": hub.PostSubscribers()}
+	}()
+
+	select {
+	case comments := <-counts:
+		assert.Equal(t, map[string]int{"1": 1, "2": 1}, comments)
+		assert.Equal(t, map[string]int{"posts": 1}, <-counts)
+	case <-time.After(time.Second):
+		t.Fatal("counting waits for the hub lock")
+	}
+	hub.lock.Unlock()
+
+	hub.UnsubscribePosts(ctx, posts)
+	hub.Close()
+	assert.Empty(t, hub.CommentSubscribers())
+	assert.Zero(t, hub.PostSubscribers())
+}
@@ -2,73 +2,190 @@ package app
 
 import (
 	"context"
+	"errors"
 	"github.com/labstack/echo"
 	"go.uber.org/zap"
-	"os"
 	"os/signal"
+	"ozon/internal/Subscription"
+	"ozon/internal/health"
+	"ozon/internal/metrics"
+	"ozon/internal/outbox"
+	"ozon/internal/ratelimit"
+	"ozon/internal/scheduler"
 	"ozon/internal/server"
 	"ozon/internal/service"
+	"ozon/internal/settings"
+	"ozon/internal/tracing"
+	grpcapi "ozon/internal/transport/grpc"
 	"ozon/internal/transport/http"
+	"ozon/internal/webhook"
+	"sync"
 	"syscall"
+	"time"
 
 	"ozon/internal/repository"
-	"ozon/internal/transport/graph/model"
 	"ozon/pkg/logger"
 )
 
-type Repository interface {
-	CreatePost(ctx context.Context, input model.CreatePostInput) (*model.Post, error)
-	PostComment(ctx context.Context, input model.PostCommentInput) (*model.Comment, error)
-	PutPost(ctx context.Context, input model.PutPostInput) (*model.Post, error)
-	PutComment(ctx context.Context, input model.PutCommentInput) (*model.Comment, error)
-	DeletePost(ctx context.Context, id string) (bool, error)
-	DeleteComment(ctx context.Context, id string) (bool, error)
-	GetPost(ctx context.Context, first int32) ([]*model.Post, error)
-	GetPostByID(ctx context.Context, id string) (*model.Post, error)
-	GetCommentByPostID(ctx context.Context, postID string, first int32) ([]*model.Comment, error)
-	GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32) ([]*model.Comment, error)
-}
-
 type App struct {
 	service    *service.Service
-	repository Repository
+	repository repository.Repository
+	http       server.Config
+	grpcPort   string
+	adminPort  string
+	tracing    tracing.Config
+	accessLog  http.AccessLogConfig
+
+	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+
+	// mu guards config, the last config applied by Reload.
+	mu       sync.Mutex
+	config   Config
+	settings *settings.Store
 }
 
 func New(ctx context.Context, cfg Config) *App {
 
-	storage, err := LoadConfig()
 	log := logger.GetLogger()
-	if err != nil {
-		log.Fatal("No database has chosen")
-	}
 
 	a := &App{}
 
-	switch storage.Storage.DB {
-	case "postgres":
+	switch cfg.Storage.DB {
+	case StoragePostgres:
 		log.Info("postgresql storage")
-		a.repository = repository.NewPsql(ctx, parseDBConn(cfg.Postgres))
-	case "in_memory":
-		a.repository = repository.NewInMemoryRepo()
-		log.Info("in_memory storage")
+		repo := repository.NewPsql(ctx, parseDBConn(cfg.Postgres))
+		if err := checkSchema(ctx, repo.Pool, cfg.Postgres.AutoMigrate); err != nil {
+			log.Fatal("failed to check database schema", zap.String("err", err.Error()))
+		}
+		a.repository = repo
+	case StorageInMemory:
+		if cfg.InMemory.Dir == "" {
+			a.repository = repository.NewInMemoryRepo()
+			log.Info("in_memory storage")
+			break
+		}
+
+		repo, err := repository.NewPersistentInMemoryRepo(cfg.InMemory)
+		if err != nil {
+			log.Fatal("failed to recover in_memory storage", zap.String("err", err.Error()))
+		}
+		a.repository = repo
 	default:
 		log.Fatal("No database has chosen")
 	}
-	a.service = service.New(a.repository)
+	a.service = service.New(a.repository,
+		service.WithIdempotencyTTL(cfg.Idempotency.TTL),
+		service.WithLimits(cfg.Limits),
+	)
+	a.http = cfg.HTTP
+	a.grpcPort = cfg.GRPC.Port
+	a.adminPort = cfg.Admin.Port
+	a.tracing = cfg.Tracing
+	a.accessLog = cfg.AccessLog
+	a.shutdownTimeout = cfg.Shutdown.Timeout
+	a.drainDelay = cfg.Shutdown.DrainDelay
+
+	a.config = cfg
+	a.settings = settings.NewStore(cfg.Settings())
+	a.settings.OnChange(func(s settings.Settings) {
+		if err := logger.SetLevel(s.LogLevel); err != nil {
+			log.Error("failed to set log level", zap.String("err", err.Error()))
+		}
+		a.service.SetLimits(s.Limits)
+	})
 	return a
 }
 
+// Reload applies the runtime settings of cfg and logs every changed
+// setting. The others take effect after a restart.
+func (a *App) Reload(cfg Config) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	log := logger.GetLogger()
+
+	changes := diffConfig(a.config, cfg)
+	for _, c := range changes {
+		fields := []zap.Field{zap.String("key", c.key), zap.Any("old", c.old), zap.Any("new", c.new)}
+		if c.runtime {
+			log.Info("setting reloaded", fields...)
+		} else {
+			log.Warn("setting changed, restart to apply it", fields...)
+		}
+	}
+
+	if len(changes) == 0 {
+		return
+	}
+
+	a.config = cfg
+	a.settings.Update(cfg.Settings())
+}
+
 func Run(ctx context.Context, a *App) {
 
 	log := logger.Logger{Logger: logger.GetLogger()}
 
+	shutdownTracing, err := tracing.Setup(ctx, a.tracing)
+	if err != nil {
+		log.Fatal("failed to set up tracing", zap.String("err", err.Error()))
+	}
+
 	e := echo.New()
 
-	service := service.New(a.repository)
+	service := a.service
+	hub := Subscription.New()
+
+	limiter := ratelimit.New(a.settings.Load().RateLimit)
+	a.settings.OnChange(func(s settings.Settings) { limiter.Update(s.RateLimit) })
 
-	http.NewHandler(e, service, log)
+	http.NewHandler(e, service, log, hub,
+		http.WithRateLimiter(limiter),
+		http.WithFeatures(func() settings.Features { return a.settings.Load().Features }),
+		http.WithAccessLog(a.accessLog),
+	)
 
-	srv := server.New(e.Server.Handler)
+	probes := health.New()
+	probes.Add("storage", a.repository.HealthCheck)
+	http.NewHealthHandler(e, probes)
+
+	metrics.Registry.MustRegister(metrics.NewSubscriptionCollector(hub))
+	if pool, ok := a.repository.(metrics.Pool); ok {
+		metrics.Registry.MustRegister(metrics.NewPoolCollector(pool))
+	}
+
+	admin := echo.New()
+	http.NewAdminHandler(admin, metrics.Registry)
+	http.NewSettingsHandler(admin, a.settings)
+
+	// Workers outlive ctx, they are stopped once the servers have drained.
+	workersCtx, stopWorkers := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+	for _, run := range []func(context.Context){
+		scheduler.New(service, log).Run,
+		// The webhook sink goes first: if enqueueing fails, the event is relayed
+		// again and the subscribers must not see it twice.
+		outbox.NewRelay(a.repository, log, webhook.NewSink(a.repository), hub).Run,
+		webhook.NewWorker(a.repository, log).Run,
+	} {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
+	}
+
+	srv := server.New(a.http, e.Server.Handler)
+	grpcSrv := server.NewGRPC(a.grpcPort, grpcapi.New(service, log, hub, grpcapi.WithRateLimiter(limiter)))
+	adminSrv := server.NewAdmin(a.adminPort, admin.Server.Handler)
+
+	// GraphQL subscriptions run on hijacked connections that the HTTP
+	// server does not wait for, completing them in the hub ends them, as
+	// well as the gRPC comment streams.
+	srv.OnShutdown(hub.Close)
 
 	go func() {
 		if err := srv.Run(ctx); err != nil {
@@ -76,15 +193,70 @@ func Run(ctx context.Context, a *App) {
 		}
 	}()
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		if err := grpcSrv.Run(ctx); err != nil {
+			log.Fatal("failed to run gRPC server", zap.String("err", err.Error()))
+		}
+	}()
 
-	indication := <-quit
+	go func() {
+		if err := adminSrv.Run(ctx); err != nil {
+			log.Fatal("failed to run admin server", zap.String("err", err.Error()))
+		}
+	}()
 
-	log.Info("Gracefully stopping the server", zap.String("caught signal", indication.String()))
+	lifecycle := NewLifecycle(log, a.shutdownTimeout)
+	lifecycle.OnStop("readiness", func(context.Context) error {
+		probes.ShutDown()
+		return nil
+	})
+	lifecycle.OnStop("drain", drain(a.drainDelay))
+	lifecycle.OnStop("servers", func(ctx context.Context) error {
+		errs := make(chan error, 2)
+		go func() { errs <- srv.Stop(ctx) }()
+		go func() { errs <- grpcSrv.Stop(ctx) }()
 
-	if err := srv.Stop(); err != nil {
-		log.Error("failed to stop server", zap.String("err", err.Error()))
-	}
+		return errors.Join(<-errs, <-errs)
+	})
+	lifecycle.OnStop("workers", func(ctx context.Context) error {
+		stopWorkers()
+
+		done := make(chan struct{})
+		go func() {
+			workers.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	lifecycle.OnStop("admin server", adminSrv.Stop)
+	lifecycle.OnStop("tracing", shutdownTracing)
+	lifecycle.OnStop("storage", func(context.Context) error {
+		a.repository.Close()
+		return nil
+	})
+	lifecycle.OnStop("logger", func(context.Context) error {
+		// Sync of a terminal fails with EINVAL, there is nothing to flush.
+		if err := log.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTTY) {
+			return err
+		}
+		return nil
+	})
+
+	// A new variable, the server goroutines above still read ctx.
+	signalCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
+	<-signalCtx.Done()
+
+	log.Info("Gracefully stopping the server", zap.String("cause", context.Cause(signalCtx).Error()))
+
+	if err := lifecycle.Shutdown(signalCtx); err != nil {
+		log.Error("shutdown did not complete cleanly", zap.String("err", err.Error()))
+	}
 }
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/api/posts"
+	"ozon/pkg/logger"
+)
+
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer lis.Close()
+
+	return strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+}
+
+// TestRun_ShutdownWithStalledSubscriber runs the service with a gRPC comment
+// stream whose client never reads. The stream must not hold up the other
+// subscribers, and the shutdown must finish within its deadline.
+func TestRun_ShutdownWithStalledSubscriber(t *testing.T) {
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	grpcPort, adminPort := freePort(t), freePort(t)
+	cfg, err := LoadConfig([]string{
+		"--config", writeConfig(t, "DB_Type:\n    DB: \"in_memory\"\n"),
+		"--http-port", freePort(t),
+		"--grpc-port", grpcPort,
+		"--admin-port", adminPort,
+		"--shutdown-timeout", "2s",
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := New(ctx, cfg)
+	post, err := a.service.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "post", AreCommentsAllowed: true})
+	require.NoError(t, err)
+
+	stopped := make(chan struct{})
+	go func() {
+		Run(ctx, a)
+		close(stopped)
+	}()
+
+	// The streams outlive ctx, the service has to end them itself.
+	streamCtx, cancelStreams := context.WithCancel(context.Background())
+	defer cancelStreams()
+
+	watch := func(options ...grpc.DialOption) posts.Posts_WatchCommentsClient {
+		conn, err := grpc.NewClient("localhost:"+grpcPort, append(options, grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		stream, err := posts.NewPostsClient(conn).WatchComments(streamCtx, &posts.WatchCommentsRequest{PostId: post.ID}, grpc.WaitForReady(true))
+		require.NoError(t, err)
+
+		return stream
+	}
+
+	// A fixed window keeps the stalled stream from growing its buffers, so
+	// the server blocks on it after a few comments.
+	watch(grpc.WithInitialWindowSize(1 << 16))
+	reading := watch()
+
+	require.Eventually(t, func() bool {
+		resp, err := nethttp.Get("http://localhost:" + adminPort + "/metrics")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		return strings.Contains(string(body), fmt.Sprintf(`ozon_comment_subscriptions_active{post_id=%q} 2`, post.ID))
+	}, 5*time.Second, 20*time.Millisecond, "both streams subscribe")
+
+	const comments = 300
+	content := strings.Repeat("x", 2000)
+	for range comments {
+		_, err := a.service.PostComment(ctx, model.PostCommentInput{PostID: post.ID, AuthorID: "2", Content: content})
+		require.NoError(t, err)
+	}
+
+	for n := range comments {
+		_, err := reading.Recv()
+		require.NoError(t, err, "comment %d", n)
+	}
+
+	start := time.Now()
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(4 * time.Second):
+		t.Fatal("shutdown is stuck")
+	}
+	assert.Less(t, time.Since(start), 3*time.Second, "shutdown stays within its deadline")
+
+	_, err = reading.Recv()
+	assert.Error(t, err, "the stream of the reading subscriber is completed")
+}
@@ -3,54 +3,499 @@ package app
 import (
 	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+	"ozon/internal/ratelimit"
+	"ozon/internal/server"
+	"ozon/internal/service"
+	"ozon/internal/settings"
+	"ozon/internal/tracing"
+	"ozon/internal/transport/http"
+	"ozon/internal/wal"
+	"ozon/pkg/logger"
 )
 
 type PsqlConfig struct {
 	User     string `mapstructure:"user"`
-	Password string `mapstructure:"password"`
+	Password string `mapstructure:"password" secret:"true"`
 	Host     string `mapstructure:"host"`
 	Port     string `mapstructure:"port"`
 	Name     string `mapstructure:"name"`
+	// AutoMigrate applies the pending migrations on startup, without it
+	// the service refuses to start on a schema behind the binary.
+	AutoMigrate bool `mapstructure:"autoMigrate"`
 }
 
 type StorageType struct {
 	DB string `mapstructure:"DB"`
 }
+type IdempotencyConfig struct {
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+type GRPCConfig struct {
+	Port string `mapstructure:"port"`
+}
+
+type AdminConfig struct {
+	Port string `mapstructure:"port"`
+}
+
+type ShutdownConfig struct {
+	// Timeout bounds the whole graceful shutdown, requests still in flight
+	// after it are cut off.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// DrainDelay is how long /readyz reports 503 before the servers stop
+	// accepting connections, so that load balancers take the instance out of
+	// rotation first. It counts towards Timeout.
+	DrainDelay time.Duration `mapstructure:"drainDelay"`
+}
+
 type Config struct {
-	Postgres PsqlConfig  `mapstructure:"Postgres"`
-	Storage  StorageType `mapstructure:"DB_Type"`
+	HTTP        server.Config        `mapstructure:"HTTP"`
+	Postgres    PsqlConfig           `mapstructure:"Postgres"`
+	Storage     StorageType          `mapstructure:"DB_Type"`
+	InMemory    wal.Config           `mapstructure:"InMemory"`
+	Idempotency IdempotencyConfig    `mapstructure:"Idempotency"`
+	GRPC        GRPCConfig           `mapstructure:"GRPC"`
+	Admin       AdminConfig          `mapstructure:"Admin"`
+	Tracing     tracing.Config       `mapstructure:"Tracing"`
+	Shutdown    ShutdownConfig       `mapstructure:"Shutdown"`
+	Limits      service.Limits       `mapstructure:"Limits"`
+	Log         logger.Config        `mapstructure:"Log"`
+	RateLimit   ratelimit.Config     `mapstructure:"RateLimit"`
+	Features    settings.Features    `mapstructure:"Features"`
+	AccessLog   http.AccessLogConfig `mapstructure:"AccessLog"`
 }
 
 const (
 	fileName = "config"
 	fileType = "yaml"
 	filePath = "./config"
+
+	envPrefix = "OZON"
 )
 
-func LoadConfig() (Config, error) {
+// Storages supported in DB_Type.DB.
+const (
+	StoragePostgres = "postgres"
+	StorageInMemory = "in_memory"
+)
+
+// ErrHelp is returned by LoadConfig when the usage was requested with -h.
+var ErrHelp = pflag.ErrHelp
 
-	viper.SetConfigName(fileName)
-	viper.SetConfigType(fileType)
-	viper.AddConfigPath(filePath)
+// defaults also make every key known to viper, environment variables are
+// only looked up for known keys.
+var defaults = map[string]any{
+	"HTTP.port":                 "8080",
+	"HTTP.readHeaderTimeout":    "5s",
+	"HTTP.readTimeout":          "15s",
+	"HTTP.writeTimeout":         "30s",
+	"HTTP.idleTimeout":          "60s",
+	"Postgres.user":             "postgres",
+	"Postgres.password":         "",
+	"Postgres.host":             "localhost",
+	"Postgres.port":             "5432",
+	"Postgres.name":             "postgres",
+	"Postgres.autoMigrate":      false,
+	"DB_Type.DB":                StoragePostgres,
+	"InMemory.dir":              "",
+	"InMemory.fsync":            wal.FsyncInterval,
+	"InMemory.fsyncInterval":    "1s",
+	"InMemory.snapshotInterval": "5m",
+	"Idempotency.ttl":           "24h",
+	"GRPC.port":                 "9090",
+	"Admin.port":                "9100",
+	"Tracing.exporter":          tracing.ExporterNone,
+	"Tracing.endpoint":          "localhost:4317",
+	"Tracing.insecure":          false,
+	"Tracing.sampleRatio":       1.0,
+	"Shutdown.timeout":          "15s",
+	"Shutdown.drainDelay":       "0s",
+	"Limits.postLen":            service.DefaultLimits.PostLen,
+	"Limits.commentLen":         service.DefaultLimits.CommentLen,
+	"Log.level":                 "info",
+	"Log.encoding":              logger.EncodingJSON,
+	"Log.outputs":               []string{"stderr"},
+	"RateLimit.rps":             0,
+	"RateLimit.burst":           20,
+	"RateLimit.trustedProxies":  []string{},
+	"Features.introspection":    true,
+	"Features.playground":       true,
+	"AccessLog.enabled":         true,
+	"AccessLog.redactVariables": []string{"password", "secret", "token"},
+	"AccessLog.sampling":        map[string]float64{},
+	"AccessLog.slowThreshold":   "1s",
+}
 
-	if err := viper.ReadInConfig(); err != nil {
-		return Config{}, errors.New("error reading config file")
+var flags = []struct {
+	name, key, usage string
+}{
+	{"http-port", "HTTP.port", "port of the GraphQL and REST API"},
+	{"grpc-port", "GRPC.port", "port of the gRPC API"},
+	{"admin-port", "Admin.port", "port of the metrics endpoint"},
+	{"storage", "DB_Type.DB", "storage, postgres or in_memory"},
+	{"in-memory-dir", "InMemory.dir", "directory the in_memory storage is persisted to, empty keeps it in memory only"},
+	{"postgres-host", "Postgres.host", "PostgreSQL host"},
+	{"postgres-port", "Postgres.port", "PostgreSQL port"},
+	{"postgres-user", "Postgres.user", "PostgreSQL user"},
+	{"postgres-password", "Postgres.password", "PostgreSQL password"},
+	{"postgres-name", "Postgres.name", "PostgreSQL database"},
+	{"postgres-auto-migrate", "Postgres.autoMigrate", "apply the pending migrations on startup"},
+	{"read-timeout", "HTTP.readTimeout", "timeout for reading an HTTP request"},
+	{"write-timeout", "HTTP.writeTimeout", "timeout for writing an HTTP response"},
+	{"shutdown-timeout", "Shutdown.timeout", "deadline of the graceful shutdown"},
+	{"shutdown-drain-delay", "Shutdown.drainDelay", "delay between failing readiness and stopping the servers"},
+	{"max-post-len", "Limits.postLen", "maximum length of a post in bytes"},
+	{"max-comment-len", "Limits.commentLen", "maximum length of a comment in bytes"},
+	{"log-level", "Log.level", "log level, debug, info, warn or error"},
+	{"log-encoding", "Log.encoding", "log encoding, json or console"},
+}
+
+// LoadConfig reads the config file, then environment variables prefixed
+// with OZON_ (OZON_HTTP_PORT for HTTP.port, OZON_DB_TYPE_DB for DB_Type.DB)
+// and then the command line flags in args, each overriding the previous one.
+// The file is ./config/config.yaml unless --config or OZON_CONFIG is set,
+// and may be missing then.
+func LoadConfig(args []string) (Config, error) {
+	v, err := newViper(args)
+	if err != nil {
+		return Config{}, err
 	}
 
+	return decode(v)
+}
+
+// WatchConfig calls onChange with the config reloaded from all sources each
+// time the config file changes. A config that fails to load is logged and
+// skipped, the previous one stays in effect.
+func WatchConfig(args []string, onChange func(Config)) error {
+	v, err := newViper(args)
+	if err != nil {
+		return err
+	}
+
+	if v.ConfigFileUsed() == "" {
+		return errors.New("no config file to watch")
+	}
+
+	v.OnConfigChange(func(fsnotify.Event) {
+		cfg, err := LoadConfig(args)
+		if err != nil {
+			logger.GetLogger().Error("failed to reload config", zap.String("err", err.Error()))
+			return
+		}
+
+		onChange(cfg)
+	})
+	v.WatchConfig()
+
+	return nil
+}
+
+func newViper(args []string) (*viper.Viper, error) {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+
+	fs := pflag.NewFlagSet("main", pflag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv(envPrefix+"_CONFIG"), "path to the config file")
+	for _, f := range flags {
+		fs.String(f.name, fmt.Sprint(defaults[f.key]), f.usage)
+		if err := v.BindPFlag(f.key, fs.Lookup(f.name)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if *configFile != "" {
+		v.SetConfigFile(*configFile)
+	} else {
+		v.SetConfigName(fileName)
+		v.SetConfigType(fileType)
+		v.AddConfigPath(filePath)
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if *configFile != "" || !errors.As(err, &notFound) {
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+	}
+
+	return v, nil
+}
+
+func decode(v *viper.Viper) (Config, error) {
 	cfg := Config{}
 
-	if err := viper.Unmarshal(&cfg); err != nil {
-		return Config{}, errors.New("failed to read config")
+	if err := v.UnmarshalExact(&cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %w", err)
 	}
 
-	if cfg == (Config{}) {
-		return Config{}, errors.New("config is empty")
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
 	}
 
 	return cfg, nil
 }
 
+// Settings returns the part of the config that is reloaded while the server
+// is running.
+func (c Config) Settings() settings.Settings {
+	return settings.Settings{
+		LogLevel:  c.Log.Level,
+		Limits:    c.Limits,
+		RateLimit: c.RateLimit,
+		Features:  c.Features,
+	}
+}
+
+// Validate reports all invalid settings at once, by their key in the file.
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	used := map[string]string{}
+	for _, p := range []struct{ key, port string }{
+		{"HTTP.port", c.HTTP.Port},
+		{"GRPC.port", c.GRPC.Port},
+		{"Admin.port", c.Admin.Port},
+	} {
+		if !validPort(p.port) {
+			fail(p.key, "%q is not a port number", p.port)
+			continue
+		}
+		if other, ok := used[p.port]; ok {
+			fail(p.key, "port %s is already used by %s", p.port, other)
+		}
+		used[p.port] = p.key
+	}
+
+	for _, t := range []struct {
+		key     string
+		timeout time.Duration
+	}{
+		{"HTTP.readHeaderTimeout", c.HTTP.ReadHeaderTimeout},
+		{"HTTP.readTimeout", c.HTTP.ReadTimeout},
+		{"HTTP.writeTimeout", c.HTTP.WriteTimeout},
+		{"HTTP.idleTimeout", c.HTTP.IdleTimeout},
+	} {
+		if t.timeout < 0 {
+			fail(t.key, "must not be negative")
+		}
+	}
+
+	switch c.Storage.DB {
+	case StorageInMemory:
+	case StoragePostgres:
+		if c.Postgres.Host == "" {
+			fail("Postgres.host", "is required for the postgres storage")
+		}
+		if !validPort(c.Postgres.Port) {
+			fail("Postgres.port", "%q is not a port number", c.Postgres.Port)
+		}
+		if c.Postgres.User == "" {
+			fail("Postgres.user", "is required for the postgres storage")
+		}
+		if c.Postgres.Name == "" {
+			fail("Postgres.name", "is required for the postgres storage")
+		}
+	default:
+		fail("DB_Type.DB", "unknown storage %q, want %s or %s", c.Storage.DB, StoragePostgres, StorageInMemory)
+	}
+
+	switch c.InMemory.Fsync {
+	case wal.FsyncAlways, wal.FsyncNever:
+	case wal.FsyncInterval:
+		if c.InMemory.FsyncInterval <= 0 {
+			fail("InMemory.fsyncInterval", "must be positive for the interval policy")
+		}
+	default:
+		fail("InMemory.fsync", "unknown policy %q, want %s, %s or %s", c.InMemory.Fsync, wal.FsyncAlways, wal.FsyncInterval, wal.FsyncNever)
+	}
+	if c.InMemory.SnapshotInterval <= 0 {
+		fail("InMemory.snapshotInterval", "must be positive")
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		fail("Idempotency.ttl", "must be positive")
+	}
+	if c.Shutdown.Timeout <= 0 {
+		fail("Shutdown.timeout", "must be positive")
+	}
+	if c.Shutdown.DrainDelay < 0 {
+		fail("Shutdown.drainDelay", "must not be negative")
+	} else if c.Shutdown.Timeout > 0 && c.Shutdown.DrainDelay >= c.Shutdown.Timeout {
+		fail("Shutdown.drainDelay", "must be less than Shutdown.timeout")
+	}
+
+	if c.Limits.PostLen <= 0 {
+		fail("Limits.postLen", "must be positive")
+	}
+	if c.Limits.CommentLen <= 0 {
+		fail("Limits.commentLen", "must be positive")
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout:
+	case tracing.ExporterOTLP:
+		if c.Tracing.Endpoint == "" {
+			fail("Tracing.endpoint", "is required for the otlp exporter")
+		}
+	default:
+		fail("Tracing.exporter", "unknown exporter %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		fail("Tracing.sampleRatio", "must be between 0 and 1")
+	}
+
+	if c.RateLimit.RPS < 0 {
+		fail("RateLimit.rps", "must not be negative")
+	}
+	if c.RateLimit.RPS > 0 && c.RateLimit.Burst < 1 {
+		fail("RateLimit.burst", "must be at least 1 when the rate limit is enabled")
+	}
+	if _, err := ratelimit.ParseProxies(c.RateLimit.TrustedProxies); err != nil {
+		fail("RateLimit.trustedProxies", "%v", err)
+	}
+
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		fail("Log.level", "unknown level %q", c.Log.Level)
+	}
+	if c.Log.Encoding != logger.EncodingJSON && c.Log.Encoding != logger.EncodingConsole {
+		fail("Log.encoding", "unknown encoding %q, want %s or %s", c.Log.Encoding, logger.EncodingJSON, logger.EncodingConsole)
+	}
+	if len(c.Log.Outputs) == 0 {
+		fail("Log.outputs", "at least one output is required")
+	}
+
+	if c.AccessLog.SlowThreshold < 0 {
+		fail("AccessLog.slowThreshold", "must not be negative")
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.AccessLog.Sampling)) {
+		if ratio := c.AccessLog.Sampling[name]; ratio < 0 || ratio > 1 {
+			fail("AccessLog.sampling."+name, "must be between 0 and 1")
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+const redacted = "[redacted]"
+
+// Print writes the effective config as YAML in the layout of the config
+// file, fields tagged as secret are redacted.
+func (c Config) Print(w io.Writer) error {
+	out, err := yaml.Marshal(configMap(reflect.ValueOf(c)))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+func configMap(v reflect.Value) map[string]any {
+	out := make(map[string]any, v.NumField())
+
+	for i := range v.NumField() {
+		field, value := v.Type().Field(i), v.Field(i)
+		key := field.Tag.Get("mapstructure")
+
+		switch {
+		case field.Tag.Get("secret") == "true" && !value.IsZero():
+			out[key] = redacted
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			out[key] = value.Interface().(time.Duration).String()
+		case field.Type.Kind() == reflect.Struct:
+			out[key] = configMap(value)
+		default:
+			out[key] = value.Interface()
+		}
+	}
+
+	return out
+}
+
+// runtimeKeys are the keys, or prefixes of keys, of Settings.
+var runtimeKeys = []string{"Log.level", "Limits.", "RateLimit.", "Features."}
+
+type change struct {
+	key      string
+	old, new any
+	runtime  bool
+}
+
+// diffConfig lists the settings that differ between two configs, by their
+// key in the file. Secrets are compared redacted, so a changed password is
+// reported only when it is set or cleared.
+func diffConfig(prev, next Config) []change {
+	before, after := flatten("", configMap(reflect.ValueOf(prev))), flatten("", configMap(reflect.ValueOf(next)))
+
+	var changes []change
+	for key, value := range after {
+		if reflect.DeepEqual(before[key], value) {
+			continue
+		}
+
+		runtime := slices.ContainsFunc(runtimeKeys, func(prefix string) bool {
+			return key == prefix || (strings.HasSuffix(prefix, ".") && strings.HasPrefix(key, prefix))
+		})
+		changes = append(changes, change{key: key, old: before[key], new: value, runtime: runtime})
+	}
+
+	slices.SortFunc(changes, func(a, b change) int { return strings.Compare(a.key, b.key) })
+
+	return changes
+}
+
+func flatten(prefix string, m map[string]any) map[string]any {
+	out := make(map[string]any)
+	for key, value := range m {
+		if nested, ok := value.(map[string]any); ok {
+			maps.Copy(out, flatten(prefix+key+".", nested))
+			continue
+		}
+		out[prefix+key] = value
+	}
+
+	return out
+}
+
 func parseDBConn(cfg PsqlConfig) string {
 
 	return fmt.Sprintf("postgresql://%s:%s@%s:%s/%s",
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ozon/pkg/logger"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	path := writeConfig(t, `
+HTTP:
+    port: "8081"
+    readTimeout: "20s"
+DB_Type:
+    DB: "in_memory"
+Log:
+    level: "warn"
+`)
+
+	t.Setenv("OZON_HTTP_PORT", "8082")
+	t.Setenv("OZON_LOG_LEVEL", "error")
+
+	cfg, err := LoadConfig([]string{"--config", path, "--log-level", "debug"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "8082", cfg.HTTP.Port, "env overrides the file")
+	assert.Equal(t, "debug", cfg.Log.Level, "flags override env")
+	assert.Equal(t, 20*time.Second, cfg.HTTP.ReadTimeout, "file overrides defaults")
+	assert.Equal(t, StorageInMemory, cfg.Storage.DB)
+	assert.Equal(t, 15*time.Second, cfg.Shutdown.Timeout, "defaults fill the rest")
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		args    []string
+		wantErr []string
+	}{
+		{
+			name:    "unknown key",
+			config:  "HTTP:\n    prot: \"8081\"\n",
+			wantErr: []string{"invalid keys: prot"},
+		},
+		{
+			name:    "unknown storage",
+			args:    []string{"--storage", "mongo"},
+			wantErr: []string{`DB_Type.DB: unknown storage "mongo"`},
+		},
+		{
+			name: "several errors",
+			args: []string{"--http-port", "http", "--grpc-port", "9100", "--log-level", "loud", "--log-encoding", "xml", "--max-post-len", "0"},
+			wantErr: []string{
+				`HTTP.port: "http" is not a port number`,
+				"Admin.port: port 9100 is already used by GRPC.port",
+				"Limits.postLen: must be positive",
+				`Log.level: unknown level "loud"`,
+				`Log.encoding: unknown encoding "xml"`,
+			},
+		},
+		{
+			name:    "unknown fsync policy",
+			config:  "InMemory:\n    fsync: \"sometimes\"\n",
+			wantErr: []string{`InMemory.fsync: unknown policy "sometimes"`},
+		},
+		{
+			name:    "bad trusted proxy",
+			config:  "RateLimit:\n    trustedProxies: [\"gateway\"]\n",
+			wantErr: []string{`RateLimit.trustedProxies: "gateway" is neither an address nor a CIDR range`},
+		},
+		{
+			name:    "sampling out of range",
+			config:  "AccessLog:\n    sampling:\n        getPost: 2\n",
+			wantErr: []string{"AccessLog.sampling.getpost: must be between 0 and 1"},
+		},
+		{
+			name:    "drain delay past the shutdown deadline",
+			args:    []string{"--shutdown-timeout", "5s", "--shutdown-drain-delay", "5s"},
+			wantErr: []string{"Shutdown.drainDelay: must be less than Shutdown.timeout"},
+		},
+		{
+			name:    "missing file",
+			args:    []string{"--config", "/nonexistent/config.yaml"},
+			wantErr: []string{"error reading config file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.config != "" {
+				args = append([]string{"--config", writeConfig(t, tt.config)}, args...)
+			}
+
+			_, err := LoadConfig(args)
+			require.Error(t, err)
+
+			for _, want := range tt.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
+
+func TestConfig_PrintRedactsSecrets(t *testing.T) {
+	cfg, err := LoadConfig([]string{"--config", writeConfig(t, "DB_Type:\n    DB: \"postgres\"\n"), "--postgres-password", "s3cret"})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Print(&out))
+
+	assert.NotContains(t, out.String(), "s3cret")
+	assert.Contains(t, out.String(), "password: '[redacted]'")
+	assert.Contains(t, out.String(), "timeout: 15s")
+}
+
+func TestDiffConfig(t *testing.T) {
+	prev, err := LoadConfig([]string{"--config", writeConfig(t, "DB_Type:\n    DB: \"in_memory\"\n")})
+	require.NoError(t, err)
+
+	next := prev
+	next.Log.Level = "debug"
+	next.Features.Playground = false
+	next.HTTP.Port = "8081"
+	next.Postgres.Password = "s3cret"
+
+	assert.Equal(t, []change{
+		{key: "Features.playground", old: true, new: false, runtime: true},
+		{key: "HTTP.port", old: "8080", new: "8081"},
+		{key: "Log.level", old: "info", new: "debug", runtime: true},
+		{key: "Postgres.password", old: "", new: redacted},
+	}, diffConfig(prev, next))
+}
+
+func TestWatchConfig(t *testing.T) {
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	path := writeConfig(t, "DB_Type:\n    DB: \"in_memory\"\nLog:\n    level: \"info\"\n")
+
+	reloaded := make(chan Config, 10)
+	require.NoError(t, WatchConfig([]string{"--config", path}, func(cfg Config) { reloaded <- cfg }))
+
+	// An invalid file is skipped, the next valid one is applied.
+	require.NoError(t, os.WriteFile(path, []byte("DB_Type:\n    DB: \"in_memory\"\nLog:\n    level: \"loud\"\n"), 0o600))
+	require.NoError(t, os.WriteFile(path, []byte("DB_Type:\n    DB: \"in_memory\"\nLog:\n    level: \"debug\"\n"), 0o600))
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case cfg := <-reloaded:
+			require.NotEqual(t, "loud", cfg.Log.Level)
+			if cfg.Log.Level == "debug" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("config was not reloaded")
+		}
+	}
+}
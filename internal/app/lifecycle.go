@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"ozon/pkg/logger"
+)
+
+const defaultShutdownTimeout = 15 * time.Second
+
+type stopHook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Lifecycle stops the parts of the application in the order they were
+// registered. All steps share one deadline, a step that fails or runs out of
+// time is logged and the next ones still run, so the pool is closed and the
+// logs are flushed even when draining takes too long.
+type Lifecycle struct {
+	log     logger.Logger
+	timeout time.Duration
+	hooks   []stopHook
+}
+
+func NewLifecycle(log logger.Logger, timeout time.Duration) *Lifecycle {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	return &Lifecycle{log: log, timeout: timeout}
+}
+
+// OnStop appends a step to the shutdown sequence.
+func (l *Lifecycle) OnStop(name string, stop func(ctx context.Context) error) {
+	l.hooks = append(l.hooks, stopHook{name: name, stop: stop})
+}
+
+// drain returns a step that waits for delay, or until the shutdown deadline
+// if it comes first. Placed after the readiness step, it gives load
+// balancers time to notice the failing probe while requests are still served.
+func drain(delay time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Shutdown runs the steps in order. The deadline starts at the call and is
+// not affected by the cancellation of ctx, which has usually triggered it.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.timeout)
+	defer cancel()
+
+	var errs []error
+	for _, hook := range l.hooks {
+		start := time.Now()
+
+		if err := hook.stop(ctx); err != nil {
+			l.log.Error("failed to stop", zap.String("step", hook.name), zap.String("err", err.Error()))
+			errs = append(errs, fmt.Errorf("%s: %w", hook.name, err))
+			continue
+		}
+
+		l.log.Debug("stopped", zap.String("step", hook.name), zap.Duration("took", time.Since(start)))
+	}
+
+	return errors.Join(errs...)
+}
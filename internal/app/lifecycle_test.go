@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"ozon/pkg/logger"
+)
+
+func TestLifecycle_Shutdown(t *testing.T) {
+	var order []string
+	step := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return err
+		}
+	}
+
+	l := NewLifecycle(logger.Logger{Logger: zap.NewNop()}, time.Second)
+	l.OnStop("servers", step("servers", nil))
+	l.OnStop("workers", step("workers", errors.New("relay is stuck")))
+	l.OnStop("storage", step("storage", nil))
+
+	err := l.Shutdown(context.Background())
+
+	assert.EqualError(t, err, "workers: relay is stuck")
+	assert.Equal(t, []string{"servers", "workers", "storage"}, order)
+}
+
+func TestLifecycle_ShutdownDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var deadlines []time.Time
+	l := NewLifecycle(logger.Logger{Logger: zap.NewNop()}, 50*time.Millisecond)
+	l.OnStop("servers", func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		deadlines = append(deadlines, deadline)
+
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	l.OnStop("storage", func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		deadlines = append(deadlines, deadline)
+		return nil
+	})
+
+	start := time.Now()
+	err := l.Shutdown(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "cancelled ctx must not cut the shutdown short")
+	require.Len(t, deadlines, 2)
+	assert.Equal(t, deadlines[0], deadlines[1], "steps share one deadline")
+}
+
+func TestLifecycle_Drain(t *testing.T) {
+	var steps []string
+	l := NewLifecycle(logger.Logger{Logger: zap.NewNop()}, 50*time.Millisecond)
+	l.OnStop("readiness", func(context.Context) error {
+		steps = append(steps, "readiness")
+		return nil
+	})
+	l.OnStop("drain", drain(time.Hour))
+	l.OnStop("servers", func(context.Context) error {
+		steps = append(steps, "servers")
+		return nil
+	})
+
+	start := time.Now()
+	err := l.Shutdown(context.Background())
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second, "the delay is bounded by the shutdown deadline")
+	assert.Equal(t, []string{"readiness", "servers"}, steps)
+
+	start = time.Now()
+	require.NoError(t, drain(20*time.Millisecond)(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"io"
+	"ozon/deployments/migrations"
+	"ozon/internal/migrate"
+	"ozon/pkg/logger"
+	"ozon/pkg/postgresql"
+	"text/tabwriter"
+	"time"
+)
+
+// Migrate runs the migrate subcommand, up, down or status, against the
+// Postgres database of cfg whatever DB_Type is.
+func Migrate(ctx context.Context, cfg Config, command string, out io.Writer) error {
+	pool := postgresql.ConnectionDB(ctx, parseDBConn(cfg.Postgres))
+	defer pool.Close()
+
+	m, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %d %s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintf(out, "no pending migrations, version %d\n", m.Latest())
+		}
+		return err
+	case "down":
+		migration, err := m.Down(ctx)
+		if migration != nil {
+			fmt.Fprintf(out, "rolled back %d %s\n", migration.Version, migration.Name)
+		} else if err == nil {
+			fmt.Fprintln(out, "no applied migrations")
+		}
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tAPPLIED AT\tNAME")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, appliedAt, status.Name)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q, want up, down or status", command)
+	}
+}
+
+// checkSchema applies the pending migrations if autoMigrate is set and
+// otherwise fails if any is pending.
+func checkSchema(ctx context.Context, pool *pgxpool.Pool, autoMigrate bool) error {
+	m, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	if !autoMigrate {
+		return m.Check(ctx)
+	}
+
+	applied, err := m.Up(ctx)
+	for _, migration := range applied {
+		logger.GetLogger().Info("migration applied", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+	}
+
+	return err
+}
@@ -0,0 +1,45 @@
+package auth
+
+import "context"
+
+// Headers set by the API gateway after it has authenticated the caller.
+const (
+	UserIDHeader   = "X-User-ID"
+	UserRoleHeader = "X-User-Role"
+)
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+)
+
+type User struct {
+	ID   string
+	Role Role
+}
+
+type userKey struct{}
+
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// FromContext returns the caller of the request, if the gateway identified one.
+func FromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userKey{}).(User)
+	return user, ok && user.ID != ""
+}
+
+// IsAuthor reports whether the caller of the request is the given author.
+func IsAuthor(ctx context.Context, authorID string) bool {
+	user, ok := FromContext(ctx)
+	return ok && user.ID == authorID
+}
+
+// IsModerator reports whether the caller of the request may moderate content.
+func IsModerator(ctx context.Context) bool {
+	user, ok := FromContext(ctx)
+	return ok && user.Role == RoleModerator
+}
@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const checkTimeout = 2 * time.Second
+
+// Statuses of a component and of the whole service.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Check reports whether a component can serve requests.
+type Check func(ctx context.Context) error
+
+type Component struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// Health tracks the readiness of the service. It is not ready once
+// shutdown has begun or while any of its checks fails.
+type Health struct {
+	mu           sync.Mutex
+	checks       map[string]Check
+	shuttingDown atomic.Bool
+}
+
+func New() *Health {
+	return &Health{checks: make(map[string]Check)}
+}
+
+// Add registers the readiness check of a component.
+func (h *Health) Add(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks[name] = check
+}
+
+// ShutDown marks the service as not ready, so that the orchestrator stops
+// routing new requests to it.
+func (h *Health) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Ready runs all checks concurrently and reports the status of each.
+func (h *Health) Ready(ctx context.Context) Report {
+	h.mu.Lock()
+	checks := maps.Clone(h.checks)
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Components: make(map[string]Component, len(checks)+1)}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			component := Component{Status: StatusOK}
+			if err := check(ctx); err != nil {
+				component = Component{Status: StatusUnavailable, Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Components[name] = component
+			if component.Status != StatusOK {
+				report.Status = StatusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+
+	if h.shuttingDown.Load() {
+		report.Status = StatusUnavailable
+		report.Components["lifecycle"] = Component{Status: StatusUnavailable, Error: "shutting down"}
+	}
+
+	return report
+}
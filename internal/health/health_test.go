@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth_Ready(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+
+	tests := []struct {
+		name         string
+		checks       map[string]Check
+		shuttingDown bool
+		want         Report
+	}{
+		{
+			name:   "all components ready",
+			checks: map[string]Check{"storage": ok},
+			want: Report{Status: StatusOK, Components: map[string]Component{
+				"storage": {Status: StatusOK},
+			}},
+		},
+		{
+			name:   "failing component",
+			checks: map[string]Check{"storage": down, "cache": ok},
+			want: Report{Status: StatusUnavailable, Components: map[string]Component{
+				"storage": {Status: StatusUnavailable, Error: "connection refused"},
+				"cache":   {Status: StatusOK},
+			}},
+		},
+		{
+			name:         "shutting down",
+			checks:       map[string]Check{"storage": ok},
+			shuttingDown: true,
+			want: Report{Status: StatusUnavailable, Components: map[string]Component{
+				"storage":   {Status: StatusOK},
+				"lifecycle": {Status: StatusUnavailable, Error: "shutting down"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New()
+			for name, check := range tt.checks {
+				h.Add(name, check)
+			}
+			if tt.shuttingDown {
+				h.ShutDown()
+			}
+
+			assert.Equal(t, tt.want, h.Ready(context.Background()))
+		})
+	}
+}
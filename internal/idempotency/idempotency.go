@@ -0,0 +1,30 @@
+package idempotency
+
+import "context"
+
+// Header carries the client-generated key of a retryable mutation.
+const Header = "Idempotency-Key"
+
+type keyKey struct{}
+
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// FromContext returns the idempotency key sent with the request, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(keyKey{}).(string)
+	return key, ok && key != ""
+}
+
+// Scoped derives the key of one mutation of the request from its key, so
+// several mutations sent with one key are not deduplicated against each
+// other.
+func Scoped(ctx context.Context, mutation string) context.Context {
+	key, ok := FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	return WithKey(ctx, key+":"+mutation)
+}
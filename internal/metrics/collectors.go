@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Subscriptions reports the active subscribers of the subscription hub.
+type Subscriptions interface {
+	CommentSubscribers() map[string]int
+	PostSubscribers() int
+}
+
+type subscriptionCollector struct {
+	hub      Subscriptions
+	comments *prometheus.Desc
+	posts    *prometheus.Desc
+}
+
+// NewSubscriptionCollector exports the subscribers of the hub at scrape time.
+func NewSubscriptionCollector(hub Subscriptions) prometheus.Collector {
+	return &subscriptionCollector{
+		hub: hub,
+		comments: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "comment_subscriptions_active"),
+			"Active comment subscriptions per post.", []string{"post_id"}, nil),
+		posts: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "post_subscriptions_active"),
+			"Active subscriptions to new posts.", nil, nil),
+	}
+}
+
+func (c *subscriptionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.comments
+	ch <- c.posts
+}
+
+func (c *subscriptionCollector) Collect(ch chan<- prometheus.Metric) {
+	for postID, n := range c.hub.CommentSubscribers() {
+		ch <- prometheus.MustNewConstMetric(c.comments, prometheus.GaugeValue, float64(n), postID)
+	}
+	ch <- prometheus.MustNewConstMetric(c.posts, prometheus.GaugeValue, float64(c.hub.PostSubscribers()))
+}
+
+// Pool is implemented by the Postgres repository.
+type Pool interface {
+	Stat() *pgxpool.Stat
+}
+
+type poolCollector struct {
+	pool Pool
+
+	acquired, idle, constructing, total, max  *prometheus.Desc
+	acquires, emptyAcquires, canceledAcquires *prometheus.Desc
+	acquireDuration                           *prometheus.Desc
+}
+
+// NewPoolCollector exports the pgxpool statistics at scrape time.
+func NewPoolCollector(pool Pool) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		pool:             pool,
+		acquired:         desc("acquired_conns", "Connections currently acquired from the pool."),
+		idle:             desc("idle_conns", "Idle connections in the pool."),
+		constructing:     desc("constructing_conns", "Connections being established."),
+		total:            desc("total_conns", "All connections of the pool."),
+		max:              desc("max_conns", "Maximum size of the pool."),
+		acquires:         desc("acquires_total", "Successful acquires from the pool."),
+		emptyAcquires:    desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceledAcquires: desc("canceled_acquires_total", "Acquires canceled by their context."),
+		acquireDuration:  desc("acquire_duration_seconds_total", "Time spent acquiring connections."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{c.acquired, c.idle, c.constructing, c.total, c.max,
+		c.acquires, c.emptyAcquires, c.canceledAcquires, c.acquireDuration} {
+		ch <- desc
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.constructing, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "ozon"
+
+// Registry holds the metrics served on the admin port.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	GraphQLOperations = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "graphql_operations_total",
+		Help:      "GraphQL operations by name and type.",
+	}, []string{"operation", "type"})
+
+	GraphQLOperationDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "graphql_operation_duration_seconds",
+		Help:      "Duration of GraphQL operations, of every response for subscriptions.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "type"})
+
+	GraphQLResolvers = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "graphql_resolver_calls_total",
+		Help:      "Calls of GraphQL fields with a resolver by field and outcome.",
+	}, []string{"field", "status"})
+
+	GraphQLResolverDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "graphql_resolver_duration_seconds",
+		Help:      "Duration of GraphQL fields with a resolver.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"field"})
+
+	GraphQLErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "graphql_errors_total",
+		Help:      "GraphQL errors by the code extension of the error.",
+	}, []string{"code"})
+
+	WebsocketConnections = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_connections",
+		Help:      "Open GraphQL websocket connections.",
+	})
+)
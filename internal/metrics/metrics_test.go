@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type hub struct {
+	comments map[string]int
+	posts    int
+}
+
+func (h hub) CommentSubscribers() map[string]int { return h.comments }
+func (h hub) PostSubscribers() int               { return h.posts }
+
+func TestSubscriptionCollector(t *testing.T) {
+	collector := NewSubscriptionCollector(hub{comments: map[string]int{"1": 2, "7": 1}, posts: 3})
+
+	expected := `
+# HELP ozon_comment_subscriptions_active Active comment subscriptions per post.
+# TYPE ozon_comment_subscriptions_active gauge
+ozon_comment_subscriptions_active{post_id="1"} 2
+ozon_comment_subscriptions_active{post_id="7"} 1
+# HELP ozon_post_subscriptions_active Active subscriptions to new posts.
+# TYPE ozon_post_subscriptions_active gauge
+ozon_post_subscriptions_active 3
+`
+
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+}
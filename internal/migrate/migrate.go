@@ -0,0 +1,362 @@
+// Package migrate applies the SQL migrations of the Postgres storage. The
+// files keep the goose format and the applied versions are recorded in the
+// goose_db_version table, so databases migrated by goose carry on.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"io/fs"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrBehind is returned by Check when the database misses migrations the
+// binary expects.
+var ErrBehind = errors.New("database schema is behind")
+
+// lockID keys the advisory lock held while migrating, replicas started
+// together apply every migration once.
+const lockID = 7252_0329_2133
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration
+	// AppliedAt is nil for a pending migration.
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Load reads the <version>_<name>.sql files in the root of fsys, sorted by
+// version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]Migration, 0, len(paths))
+	for _, path := range paths {
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(path, ".sql"), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: want <version>_<name>.sql", path)
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, err := parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", path, err)
+		}
+		migration.Version, migration.Name = version, name
+
+		output = append(output, migration)
+	}
+
+	slices.SortFunc(output, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	for i := 1; i < len(output); i++ {
+		if output[i].Version == output[i-1].Version {
+			return nil, fmt.Errorf("migration %d: duplicate version", output[i].Version)
+		}
+	}
+
+	return output, nil
+}
+
+// parse splits a migration into its Up and Down sections. A section is run
+// as a single query, so StatementBegin and StatementEnd are accepted but
+// statements are never split.
+func parse(source string) (Migration, error) {
+	var up, down strings.Builder
+	var section *strings.Builder
+
+	for _, line := range strings.SplitAfter(source, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		directive, ok := strings.CutPrefix(trimmed, "-- +goose ")
+		if !ok {
+			if section != nil {
+				section.WriteString(line)
+			} else if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return Migration{}, errors.New("statement before -- +goose Up")
+			}
+			continue
+		}
+
+		switch strings.TrimSpace(directive) {
+		case "Up":
+			section = &up
+		case "Down":
+			section = &down
+		case "StatementBegin", "StatementEnd":
+		default:
+			return Migration{}, fmt.Errorf("unsupported directive %q", trimmed)
+		}
+	}
+
+	if strings.TrimSpace(up.String()) == "" {
+		return Migration{}, errors.New("no -- +goose Up section")
+	}
+
+	return Migration{Up: up.String(), Down: down.String()}, nil
+}
+
+// Latest returns the version of the newest embedded migration.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction, and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var output []Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		if err := createTable(ctx, conn); err != nil {
+			return err
+		}
+
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			err := run(ctx, conn, migration.Up, "INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, true)", migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+			}
+			output = append(output, migration)
+		}
+
+		return nil
+	})
+
+	return output, err
+}
+
+// Down rolls back the newest applied migration and returns it, nil if
+// none is applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var output *Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		if err := createTable(ctx, conn); err != nil {
+			return err
+		}
+
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return nil
+		}
+
+		version := slices.Max(slices.Collect(maps.Keys(applied)))
+		i := slices.IndexFunc(m.migrations, func(migration Migration) bool { return migration.Version == version })
+		if i < 0 {
+			return fmt.Errorf("migration %d is not known to this binary", version)
+		}
+		migration := m.migrations[i]
+
+		err = run(ctx, conn, migration.Down, "DELETE FROM goose_db_version WHERE version_id = $1", migration.Version)
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+		output = &migration
+
+		return nil
+	})
+
+	return output, err
+}
+
+// Status lists the embedded migrations with the time each was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := appliedVersions(ctx, m.pool)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		output = append(output, status)
+	}
+
+	return output, nil
+}
+
+// Check returns ErrBehind if an embedded migration is not applied. It only
+// reads the database.
+func (m *Migrator) Check(ctx context.Context) error {
+	applied, err := appliedVersions(ctx, m.pool)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, strconv.FormatInt(migration.Version, 10))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: migrations %s are not applied, run migrate up", ErrBehind, strings.Join(pending, ", "))
+	}
+
+	return nil
+}
+
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// locked runs fn on a connection holding the migration lock.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("migration lock: %w", err)
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockID)
+
+	return fn(conn)
+}
+
+// createTable creates goose_db_version as goose does, with the row of
+// version 0.
+func createTable(ctx context.Context, q querier) error {
+	exists, err := tableExists(ctx, q)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = q.Exec(ctx, `CREATE TABLE goose_db_version (
+    id serial NOT NULL,
+    version_id bigint NOT NULL,
+    is_applied boolean NOT NULL,
+    tstamp timestamp NULL DEFAULT now(),
+    PRIMARY KEY(id)
+)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(ctx, "INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, true)")
+	return err
+}
+
+func tableExists(ctx context.Context, q querier) (bool, error) {
+	var exists bool
+	err := q.QueryRow(ctx, "SELECT to_regclass('goose_db_version') IS NOT NULL").Scan(&exists)
+
+	return exists, err
+}
+
+// appliedVersions returns the applied versions with the time each was
+// applied. The last row of a version wins, older goose releases recorded a
+// rollback as a row with is_applied false.
+func appliedVersions(ctx context.Context, q querier) (map[int64]time.Time, error) {
+	output := make(map[int64]time.Time)
+
+	exists, err := tableExists(ctx, q)
+	if err != nil || !exists {
+		return output, err
+	}
+
+	rows, err := q.Query(ctx, `SELECT version_id, is_applied, COALESCE(tstamp, now()::timestamp) FROM (
+    SELECT DISTINCT ON (version_id) version_id, is_applied, tstamp FROM goose_db_version
+    WHERE version_id > 0 ORDER BY version_id, id DESC
+) AS versions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var applied bool
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &applied, &appliedAt); err != nil {
+			return nil, err
+		}
+		if applied {
+			output[version] = appliedAt
+		}
+	}
+
+	return output, rows.Err()
+}
+
+// run executes the section of a migration and records it in one
+// transaction. Without arguments the section goes over the simple protocol,
+// which accepts several statements.
+func run(ctx context.Context, conn *pgxpool.Conn, section, record string, version int64) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if strings.TrimSpace(section) != "" {
+		if _, err := tx.Exec(ctx, section); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, record, version); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
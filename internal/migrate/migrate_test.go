@@ -0,0 +1,265 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ozon/deployments/migrations"
+)
+
+// postgresDSNEnv names the variable with the DSN of a Postgres database to
+// run the migrator against. Every test migrates a schema of its own, which
+// is dropped afterwards.
+const postgresDSNEnv = "OZON_TEST_POSTGRES_DSN"
+
+// testPool returns a pool whose connections work in a new empty schema.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDSNEnv)
+	}
+
+	ctx := context.Background()
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+
+	admin, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+	t.Cleanup(admin.Close)
+
+	_, err = admin.Exec(ctx, "CREATE SCHEMA "+pgx.Identifier{schema}.Sanitize())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := admin.Exec(ctx, "DROP SCHEMA "+pgx.Identifier{schema}.Sanitize()+" CASCADE")
+		assert.NoError(t, err)
+	})
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	require.NoError(t, err)
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+// tables returns the tables of the schema of the pool.
+func tables(t *testing.T, pool *pgxpool.Pool) []string {
+	t.Helper()
+
+	rows, err := pool.Query(context.Background(), "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() ORDER BY table_name")
+	require.NoError(t, err)
+
+	output, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(t, err)
+
+	return output
+}
+
+func TestLoad(t *testing.T) {
+	file := func(source string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(source)} }
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name: "sorted by version",
+			fsys: fstest.MapFS{
+				"2_tags.sql": file("-- +goose Up\nCREATE TABLE tags ();\n-- +goose Down\nDROP TABLE tags;\n"),
+				"1_init.sql": file("-- comment\n-- +goose Up\n-- +goose StatementBegin\nCREATE TABLE posts ();\n-- +goose StatementEnd\n"),
+				"README.md":  file("not a migration"),
+			},
+			want: []Migration{
+				{Version: 1, Name: "init", Up: "CREATE TABLE posts ();\n"},
+				{Version: 2, Name: "tags", Up: "CREATE TABLE tags ();\n", Down: "DROP TABLE tags;\n"},
+			},
+		},
+		{
+			name:    "no version",
+			fsys:    fstest.MapFS{"init.sql": file("-- +goose Up\nSELECT 1;\n")},
+			wantErr: "migration init.sql: want <version>_<name>.sql",
+		},
+		{
+			name:    "no up section",
+			fsys:    fstest.MapFS{"1_init.sql": file("-- +goose Down\nSELECT 1;\n")},
+			wantErr: "migration 1_init.sql: no -- +goose Up section",
+		},
+		{
+			name:    "statement outside a section",
+			fsys:    fstest.MapFS{"1_init.sql": file("SELECT 1;\n-- +goose Up\nSELECT 1;\n")},
+			wantErr: "migration 1_init.sql: statement before -- +goose Up",
+		},
+		{
+			name:    "unsupported directive",
+			fsys:    fstest.MapFS{"1_init.sql": file("-- +goose NO TRANSACTION\n-- +goose Up\nSELECT 1;\n")},
+			wantErr: `migration 1_init.sql: unsupported directive "-- +goose NO TRANSACTION"`,
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"1_init.sql":  file("-- +goose Up\nSELECT 1;\n"),
+				"01_copy.sql": file("-- +goose Up\nSELECT 1;\n"),
+			},
+			wantErr: "migration 1: duplicate version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.fsys)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	got, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+
+	for _, migration := range got {
+		assert.NotEmpty(t, migration.Down, "%d %s has no down section", migration.Version, migration.Name)
+	}
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+
+	m, err := New(pool, migrations.FS)
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, m.migrations, applied)
+	require.NoError(t, m.Check(ctx))
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, len(m.migrations))
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, "%d %s is applied", status.Version, status.Name)
+	}
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "applied migrations are not run again")
+
+	for n := len(m.migrations) - 1; n >= 0; n-- {
+		rolledBack, err := m.Down(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, rolledBack)
+		assert.Equal(t, m.migrations[n].Version, rolledBack.Version, "the newest migration is rolled back first")
+	}
+
+	rolledBack, err := m.Down(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, rolledBack, "nothing is left to roll back")
+	assert.Equal(t, []string{"goose_db_version"}, tables(t, pool), "the down sections drop what the up sections create")
+
+	statuses, err = m.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.Nil(t, status.AppliedAt, "%d %s is pending", status.Version, status.Name)
+	}
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(m.migrations), "the schema is migrated up again after a full rollback")
+}
+
+func TestMigrator_Check(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+
+	file := func(source string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(source)} }
+	v1 := fstest.MapFS{"1_posts.sql": file("-- +goose Up\nCREATE TABLE posts (id int);\n-- +goose Down\nDROP TABLE posts;\n")}
+	v2 := fstest.MapFS{
+		"1_posts.sql": v1["1_posts.sql"],
+		"2_tags.sql":  file("-- +goose Up\nCREATE TABLE tags (id int);\n-- +goose Down\nDROP TABLE tags;\n"),
+	}
+
+	older, err := New(pool, v1)
+	require.NoError(t, err)
+	newer, err := New(pool, v2)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, older.Check(ctx), ErrBehind, "an empty database is behind")
+
+	_, err = older.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, older.Check(ctx))
+
+	err = newer.Check(ctx)
+	assert.ErrorIs(t, err, ErrBehind, "a binary with a newer migration refuses the schema")
+	assert.ErrorContains(t, err, "migrations 2 are not applied")
+
+	_, err = newer.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, newer.Check(ctx))
+	assert.NoError(t, older.Check(ctx), "an older binary accepts a newer schema")
+
+	_, err = newer.Down(ctx)
+	require.NoError(t, err)
+	assert.ErrorIs(t, newer.Check(ctx), ErrBehind, "the schema is behind after a rollback")
+}
+
+func TestMigrator_Lock(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+
+	// Run twice at once, the migration would fail: the table exists.
+	fsys := fstest.MapFS{
+		"1_slow.sql": &fstest.MapFile{Data: []byte("-- +goose Up\nSELECT pg_sleep(0.5);\nCREATE TABLE slow (id int);\n-- +goose Down\nDROP TABLE slow;\n")},
+	}
+
+	const migrators = 2
+	applied := make([][]Migration, migrators)
+	errs := make([]error, migrators)
+
+	var start, done sync.WaitGroup
+	start.Add(1)
+	for n := range migrators {
+		m, err := New(pool, fsys)
+		require.NoError(t, err)
+
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			start.Wait()
+			applied[n], errs[n] = m.Up(ctx)
+		}()
+	}
+	start.Done()
+	done.Wait()
+
+	for n := range migrators {
+		require.NoError(t, errs[n], "migrator %d", n)
+	}
+	assert.Equal(t, 1, len(applied[0])+len(applied[1]), "the migration is applied by one of the migrators")
+
+	var rows int
+	require.NoError(t, pool.QueryRow(ctx, "SELECT count(*) FROM goose_db_version WHERE version_id = 1").Scan(&rows))
+	assert.Equal(t, 1, rows, "the migration is recorded once")
+}
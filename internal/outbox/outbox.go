@@ -0,0 +1,35 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	PostCreated    EventType = "post.created"
+	PostPublished  EventType = "post.published"
+	PostUpdated    EventType = "post.updated"
+	PostDeleted    EventType = "post.deleted"
+	CommentCreated EventType = "comment.created"
+	CommentUpdated EventType = "comment.updated"
+	CommentDeleted EventType = "comment.deleted"
+)
+
+// Event is a domain event recorded by the repository together with the
+// write that caused it. Payload is the JSON of the post or comment as it
+// was after the write; deletions only carry the identifiers.
+type Event struct {
+	ID          int64
+	Type        EventType
+	AggregateID string
+	Payload     json.RawMessage
+	CreatedAt   time.Time
+}
+
+// Deleted is the payload of post.deleted and comment.deleted events.
+type Deleted struct {
+	ID     string `json:"id"`
+	PostID string `json:"postId,omitempty"`
+}
@@ -0,0 +1,80 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"ozon/pkg/logger"
+)
+
+const (
+	pollInterval = 200 * time.Millisecond
+	batchSize    = 100
+)
+
+// Store is the storage the events are recorded in.
+type Store interface {
+	FetchOutbox(ctx context.Context, limit int32) ([]Event, error)
+	AckOutbox(ctx context.Context, id int64) error
+}
+
+// Sink receives the recorded events in the order they were written.
+type Sink interface {
+	Deliver(ctx context.Context, event Event) error
+}
+
+// Relay moves events from the outbox to the sinks. An event is removed from
+// the outbox only after every sink has accepted it, so a sink may see the
+// same event again if another sink failed.
+type Relay struct {
+	store Store
+	sinks []Sink
+	log   logger.Logger
+}
+
+func NewRelay(store Store, log logger.Logger, sinks ...Sink) *Relay {
+	return &Relay{
+		store: store,
+		sinks: sinks,
+		log:   log,
+	}
+}
+
+// Run blocks until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.relay(ctx)
+		}
+	}
+}
+
+func (r *Relay) relay(ctx context.Context) {
+	events, err := r.store.FetchOutbox(ctx, batchSize)
+	if err != nil {
+		r.log.Error("failed to fetch outbox", zap.String("err", err.Error()))
+		return
+	}
+
+	for _, event := range events {
+		for _, sink := range r.sinks {
+			if err = sink.Deliver(ctx, event); err != nil {
+				// Stop here so that later events are not delivered ahead of this one.
+				r.log.Error("failed to deliver event", zap.Int64("id", event.ID), zap.String("err", err.Error()))
+				return
+			}
+		}
+
+		if err = r.store.AckOutbox(ctx, event.ID); err != nil {
+			r.log.Error("failed to ack event", zap.Int64("id", event.ID), zap.String("err", err.Error()))
+			return
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"ozon/pkg/logger"
+)
+
+type memoryStore struct {
+	events []Event
+}
+
+func (m *memoryStore) FetchOutbox(ctx context.Context, limit int32) ([]Event, error) {
+	return append([]Event{}, m.events...), nil
+}
+
+func (m *memoryStore) AckOutbox(ctx context.Context, id int64) error {
+	for n, event := range m.events {
+		if event.ID == id {
+			m.events = append(m.events[:n], m.events[n+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+type recordingSink struct {
+	delivered []int64
+	failOn    int64
+}
+
+func (r *recordingSink) Deliver(ctx context.Context, event Event) error {
+	if event.ID == r.failOn {
+		return errors.New("sink is down")
+	}
+	r.delivered = append(r.delivered, event.ID)
+	return nil
+}
+
+func TestRelay_relay(t *testing.T) {
+	tests := []struct {
+		name          string
+		failOn        int64
+		wantDelivered []int64
+		wantLeft      []int64
+	}{
+		{
+			name:          "delivers events in order",
+			wantDelivered: []int64{1, 2, 3},
+		},
+		{
+			name:          "stops at a failed event",
+			failOn:        2,
+			wantDelivered: []int64{1},
+			wantLeft:      []int64{2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryStore{events: []Event{{ID: 1}, {ID: 2}, {ID: 3}}}
+			sink := &recordingSink{failOn: tt.failOn}
+
+			NewRelay(store, logger.Logger{Logger: zap.NewNop()}, sink).relay(context.Background())
+
+			assert.Equal(t, tt.wantDelivered, sink.delivered)
+
+			var left []int64
+			for _, event := range store.events {
+				left = append(left, event.ID)
+			}
+			assert.Equal(t, tt.wantLeft, left)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package ratelimit
+
+import (
+	"container/list"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleTTL is how long the bucket of a client that stopped sending requests
+// is kept, a client coming back after that starts with a full bucket.
+const idleTTL = 10 * time.Minute
+
+// maxClients bounds the number of buckets kept. When it is reached, the
+// bucket of the client seen least recently is dropped.
+const maxClients = 100_000
+
+type Config struct {
+	// RPS is the sustained number of requests per second of one client,
+	// zero disables the limit.
+	RPS float64 `mapstructure:"rps" json:"rps"`
+	// Burst is the number of requests a client may send at once.
+	Burst int `mapstructure:"burst" json:"burst"`
+	// TrustedProxies are the addresses or CIDR ranges of the proxies in front
+	// of the service. X-Forwarded-For is only read from them, otherwise the
+	// client is the peer of the connection.
+	TrustedProxies []string `mapstructure:"trustedProxies" json:"trustedProxies,omitempty"`
+}
+
+// ParseProxies parses Config.TrustedProxies.
+func ParseProxies(proxies []string) ([]netip.Prefix, error) {
+	output := make([]netip.Prefix, 0, len(proxies))
+
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, err
+			}
+			output = append(output, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%q is neither an address nor a CIDR range", proxy)
+		}
+		addr = addr.Unmap()
+		output = append(output, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return output, nil
+}
+
+type client struct {
+	key     string
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+// Limiter keeps a token bucket per client. Its config may be changed while
+// it is in use.
+type Limiter struct {
+	mu      sync.Mutex
+	cfg     Config
+	proxies []netip.Prefix
+	// clients index the elements of recent, which is ordered from the
+	// client seen most recently to the one seen least recently.
+	clients  map[string]*list.Element
+	recent   *list.List
+	capacity int
+	now      func() time.Time
+}
+
+func New(cfg Config) *Limiter {
+	return &Limiter{
+		cfg:      cfg,
+		proxies:  parseValid(cfg.TrustedProxies),
+		clients:  make(map[string]*list.Element),
+		recent:   list.New(),
+		capacity: maxClients,
+		now:      time.Now,
+	}
+}
+
+// parseValid parses the proxies, they have been validated with the config.
+func parseValid(proxies []string) []netip.Prefix {
+	output, _ := ParseProxies(proxies)
+	return output
+}
+
+// Allow reports whether the client identified by key may send a request now.
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cfg.RPS <= 0 {
+		return true
+	}
+
+	now := l.now()
+
+	var c *client
+	if element, ok := l.clients[key]; ok {
+		c = element.Value.(*client)
+		l.recent.MoveToFront(element)
+	} else {
+		c = &client{key: key, limiter: rate.NewLimiter(rate.Limit(l.cfg.RPS), l.cfg.Burst)}
+		l.clients[key] = l.recent.PushFront(c)
+	}
+	c.seen = now
+
+	l.evict(now)
+
+	return c.limiter.AllowN(now, 1)
+}
+
+// evict drops the buckets of idle clients and, past the capacity, of the
+// clients seen least recently.
+func (l *Limiter) evict(now time.Time) {
+	for element := l.recent.Back(); element != nil; element = l.recent.Back() {
+		c := element.Value.(*client)
+		if len(l.clients) <= l.capacity && now.Sub(c.seen) < idleTTL {
+			return
+		}
+
+		l.recent.Remove(element)
+		delete(l.clients, c.key)
+	}
+}
+
+// Update applies cfg to new and existing clients.
+func (l *Limiter) Update(cfg Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cfg, l.proxies = cfg, parseValid(cfg.TrustedProxies)
+
+	now := l.now()
+	for _, element := range l.clients {
+		c := element.Value.(*client)
+		c.limiter.SetLimitAt(now, rate.Limit(cfg.RPS))
+		c.limiter.SetBurstAt(now, cfg.Burst)
+	}
+}
+
+// ClientIP returns the address of the client that sent a request over a
+// connection from peer, which is host:port or a bare address. The
+// X-Forwarded-For values are read from right to left while they were added
+// by trusted proxies, so a client cannot pick its own address.
+func (l *Limiter) ClientIP(peer string, forwardedFor []string) string {
+	l.mu.Lock()
+	proxies := l.proxies
+	l.mu.Unlock()
+
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+
+	if !trusted(proxies, peer) {
+		return peer
+	}
+
+	var hops []string
+	for _, value := range forwardedFor {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		client = hops[i]
+		if !trusted(proxies, client) {
+			break
+		}
+	}
+
+	return client
+}
+
+func trusted(proxies []netip.Prefix, address string) bool {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(Config{RPS: 1, Burst: 2})
+	l.now = func() time.Time { return now }
+
+	assert.True(t, l.Allow("alice"))
+	assert.True(t, l.Allow("alice"))
+	assert.False(t, l.Allow("alice"), "burst is spent")
+	assert.True(t, l.Allow("bob"), "clients have their own buckets")
+
+	now = now.Add(time.Second)
+	assert.True(t, l.Allow("alice"), "a token is refilled every second")
+	assert.False(t, l.Allow("alice"))
+}
+
+func TestLimiter_Update(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(Config{RPS: 1, Burst: 1})
+	l.now = func() time.Time { return now }
+
+	assert.True(t, l.Allow("alice"))
+	assert.False(t, l.Allow("alice"))
+
+	l.Update(Config{})
+	assert.True(t, l.Allow("alice"), "zero RPS disables the limit")
+
+	l.Update(Config{RPS: 10, Burst: 10})
+	now = now.Add(time.Second)
+	for range 10 {
+		assert.True(t, l.Allow("alice"))
+	}
+	assert.False(t, l.Allow("alice"), "the new burst applies to existing clients")
+}
+
+func TestLimiter_ForgetsIdleClients(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(Config{RPS: 1, Burst: 1})
+	l.now = func() time.Time { return now }
+
+	l.Allow("alice")
+	now = now.Add(idleTTL)
+	l.Allow("bob")
+
+	assert.NotContains(t, l.clients, "alice")
+	assert.Contains(t, l.clients, "bob")
+}
+
+func TestLimiter_Capacity(t *testing.T) {
+	l := New(Config{RPS: 1, Burst: 1})
+	l.capacity = 2
+
+	l.Allow("alice")
+	l.Allow("bob")
+	l.Allow("alice")
+	l.Allow("carol")
+
+	assert.Len(t, l.clients, 2)
+	assert.NotContains(t, l.clients, "bob", "the client seen least recently is dropped")
+	assert.False(t, l.Allow("alice"), "alice keeps her bucket")
+}
+
+func TestLimiter_ClientIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		proxies      []string
+		peer         string
+		forwardedFor []string
+		want         string
+	}{
+		{
+			name:         "untrusted peer",
+			peer:         "203.0.113.7:51234",
+			forwardedFor: []string{"198.51.100.1"},
+			want:         "203.0.113.7",
+		},
+		{
+			name:         "behind a trusted proxy",
+			proxies:      []string{"10.0.0.0/8"},
+			peer:         "10.0.0.2:51234",
+			forwardedFor: []string{"198.51.100.1"},
+			want:         "198.51.100.1",
+		},
+		{
+			name:         "a forged hop is ignored",
+			proxies:      []string{"10.0.0.0/8"},
+			peer:         "10.0.0.2:51234",
+			forwardedFor: []string{"192.0.2.99, 198.51.100.1"},
+			want:         "198.51.100.1",
+		},
+		{
+			name:         "chain of proxies",
+			proxies:      []string{"10.0.0.0/8", "192.0.2.10"},
+			peer:         "10.0.0.2:51234",
+			forwardedFor: []string{"198.51.100.1, 192.0.2.10", "10.0.0.3"},
+			want:         "198.51.100.1",
+		},
+		{
+			name:    "trusted proxy without the header",
+			proxies: []string{"10.0.0.0/8"},
+			peer:    "10.0.0.2:51234",
+			want:    "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(Config{TrustedProxies: tt.proxies})
+			assert.Equal(t, tt.want, l.ClientIP(tt.peer, tt.forwardedFor))
+		})
+	}
+}
+
+func TestParseProxies(t *testing.T) {
+	_, err := ParseProxies([]string{"10.0.0.0/8", "::1", "192.0.2.10"})
+	assert.NoError(t, err)
+
+	_, err = ParseProxies([]string{"gateway"})
+	assert.Error(t, err)
+}
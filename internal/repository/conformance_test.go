@@ -0,0 +1,50 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"ozon/deployments/migrations"
+	"ozon/internal/migrate"
+	"ozon/internal/repository"
+	"ozon/internal/repository/repositorytest"
+	"ozon/pkg/logger"
+)
+
+// postgresDSNEnv names the variable with the DSN of a Postgres database to
+// run the conformance suite against. It is migrated up and its tables are
+// truncated.
+const postgresDSNEnv = "OZON_TEST_POSTGRES_DSN"
+
+func TestInMemoryRepo_Conformance(t *testing.T) {
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	repositorytest.Run(t, func(t *testing.T) repository.Repository {
+		return repository.NewInMemoryRepo()
+	})
+}
+
+func TestPsqlPool_Conformance(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDSNEnv)
+	}
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	repo := repository.NewPsql(context.Background(), dsn)
+	t.Cleanup(repo.Close)
+
+	m, err := migrate.New(repo.Pool, migrations.FS)
+	require.NoError(t, err)
+	_, err = m.Up(context.Background())
+	require.NoError(t, err)
+
+	repositorytest.Run(t, func(t *testing.T) repository.Repository {
+		_, err := repo.Exec(context.Background(), "TRUNCATE posts, comments, post_tags, idempotency_keys, outbox, webhooks, webhook_deliveries RESTART IDENTITY CASCADE")
+		require.NoError(t, err)
+
+		return repo
+	})
+}
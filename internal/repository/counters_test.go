@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ozon/deployments/migrations"
+	"ozon/internal/migrate"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/logger"
+)
+
+// testCommentCounters checks the counters of a post and of a comment. drift
+// sets them to wrong values, as a bug or a manual fix of the data would.
+func testCommentCounters(t *testing.T, repo Repository, drift func(postID, commentID string)) {
+	ctx := context.Background()
+
+	post, err := repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "post", AreCommentsAllowed: true})
+	require.NoError(t, err)
+
+	comment := func(parent *model.Comment) *model.Comment {
+		input := model.PostCommentInput{PostID: post.ID, AuthorID: "2", Content: "comment"}
+		if parent != nil {
+			input.ParentCommentID = &parent.ID
+		}
+		output, err := repo.PostComment(ctx, input)
+		require.NoError(t, err)
+		return output
+	}
+	first := comment(nil)
+	reply := comment(first)
+	comment(reply)
+	comment(nil)
+
+	assertCounts := func(msg string, commentCount, firstReplies, replyReplies int32) {
+		t.Helper()
+
+		got, err := repo.GetPostByID(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Equal(t, commentCount, got.CommentCount, "comments of the post %s", msg)
+
+		root, err := repo.GetCommentByID(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, firstReplies, root.ReplyCount, "replies to the comment %s", msg)
+
+		if replyReplies >= 0 {
+			nested, err := repo.GetCommentByID(ctx, reply.ID)
+			require.NoError(t, err)
+			assert.Equal(t, replyReplies, nested.ReplyCount, "replies to the reply %s", msg)
+		}
+	}
+	assertCounts("after posting", 4, 1, 1)
+
+	deleted, err := repo.DeleteComment(ctx, reply.ID)
+	require.NoError(t, err)
+	require.True(t, deleted)
+	assertCounts("after deleting a reply with its replies", 2, 0, -1)
+
+	fixed, err := repo.RecountComments(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, fixed, "the counters are kept right")
+
+	drift(post.ID, first.ID)
+
+	fixed, err = repo.RecountComments(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, fixed, "the post and the comment are repaired")
+	assertCounts("after the recount", 2, 0, -1)
+}
+
+func TestInMemoryRepo_CommentCounters(t *testing.T) {
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	repo := NewInMemoryRepo()
+	testCommentCounters(t, repo, func(postID, commentID string) {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+
+		repo.posts[postID].post.CommentCount = 10
+		repo.comments[commentID].comment.ReplyCount = 5
+	})
+}
+
+// TestPsqlPool_CommentCounters checks the triggers that keep the counters,
+// it runs against the database in OZON_TEST_POSTGRES_DSN.
+func TestPsqlPool_CommentCounters(t *testing.T) {
+	dsn := os.Getenv("OZON_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("OZON_TEST_POSTGRES_DSN is not set")
+	}
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	ctx := context.Background()
+	repo := NewPsql(ctx, dsn)
+	t.Cleanup(repo.Close)
+
+	m, err := migrate.New(repo.Pool, migrations.FS)
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	_, err = repo.Exec(ctx, "TRUNCATE posts, comments, post_tags, idempotency_keys, outbox, webhooks, webhook_deliveries RESTART IDENTITY CASCADE")
+	require.NoError(t, err)
+
+	testCommentCounters(t, repo, func(postID, commentID string) {
+		_, err := repo.Exec(ctx, "UPDATE posts SET comment_count = 10 WHERE id = $1", postID)
+		require.NoError(t, err)
+		_, err = repo.Exec(ctx, "UPDATE comments SET reply_count = 5 WHERE id = $1", commentID)
+		require.NoError(t, err)
+	})
+}
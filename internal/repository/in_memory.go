@@ -1,133 +1,459 @@
 package repository
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"ozon/internal/outbox"
+	"ozon/internal/service"
 	"ozon/internal/transport/graph/model"
+	"ozon/internal/webhook"
 	"ozon/pkg/logger"
+	"slices"
 	"sort"
 	"sync"
 	"time"
 )
 
+// pageSize is the number of posts and comments in a page, like in PsqlPool.
+const pageSize = 10
+
+// InMemoryRepo keeps posts and comments in maps by ID, linked to each other
+// by pointers. Every list a query pages through is kept in its order as a
+// slice, so that a page is read without scanning or sorting the storage.
 type InMemoryRepo struct {
-	memory map[string]model.Post
-	mu     *sync.Mutex
-	logger *zap.Logger
+	posts    map[string]*postEntry
+	comments map[string]*commentEntry
+	feed     *postFeed
+	tags     map[string][]taggedPost
+	trending *tagTimeline
+	keys     map[idempotencyKey]idempotencyEntry
+	events   *eventLog
+	hooks    map[string]*webhookEntry
+	sends    *deliveryLog
+	mu       *sync.RWMutex
+	logger   *zap.Logger
+
+	// persistence is nil unless the repository is persisted.
+	persistence *persistence
+}
+
+// postEntry is a stored post. The post never holds comments, they are
+// reachable through roots.
+type postEntry struct {
+	seq      uint64
+	post     model.Post
+	taggedAt map[string]time.Time
+	pinnedAt time.Time
+	// roots are the top-level comments in creation order.
+	roots []*commentEntry
+	// pinnedComment is the pinned comment of the post, if any.
+	pinnedComment *commentEntry
+}
+
+// commentEntry is a stored comment. The comment never holds replies, they
+// are reachable through replies.
+type commentEntry struct {
+	seq     uint64
+	comment model.Comment
+	post    *postEntry
+	parent  *commentEntry
+	// replies are the direct replies in creation order.
+	replies []*commentEntry
+	// locked is whether the comment is locked itself rather than by an ancestor.
+	locked   bool
+	pinnedAt time.Time
+	// threadSize is the number of replies at any depth.
+	threadSize int
+}
+
+// postFeed keeps the posts in the orders they are paged through.
+type postFeed struct {
+	// seq numbers posts and comments in creation order.
+	seq uint64
+	// published are the published posts that are not pinned, in creation order.
+	published []*postEntry
+	// pinned are the published posts that are pinned, most recently pinned first.
+	pinned []*postEntry
+	// drafts are the posts that are not published by author, in creation order.
+	drafts map[string][]*postEntry
+	// scheduled are the scheduled posts in the order they are due.
+	scheduled []*postEntry
+}
+
+type idempotencyKey struct {
+	scope string
+	key   string
+}
+
+type idempotencyEntry struct {
+	resultID  string
+	expiresAt time.Time
+}
+
+// eventLog is the in-memory outbox.
+type eventLog struct {
+	events []outbox.Event
+	nextID int64
+}
+
+type webhookEntry struct {
+	seq     uint64
+	webhook model.Webhook
+	secret  string
+	// deliveries are the deliveries of the webhook in creation order.
+	deliveries []*deliveryEntry
+}
+
+// deliveryLog keeps the webhooks and their deliveries in creation order.
+type deliveryLog struct {
+	seq        uint64
+	webhooks   []*webhookEntry
+	deliveries map[string]*deliveryEntry
+	// pending are the deliveries waiting to be sent.
+	pending []*deliveryEntry
+}
+
+type deliveryEntry struct {
+	seq      uint64
+	delivery model.WebhookDelivery
+	eventID  int64
+	payload  json.RawMessage
+}
+
+// taggedPost is an entry of the tag index, kept in tagging order.
+type taggedPost struct {
+	post     *postEntry
+	taggedAt time.Time
+}
+
+// tagTimeline keeps the taggings of all tags in tagging order, so that the
+// trending tags are counted from the taggings in their window only.
+type tagTimeline struct {
+	taggings []tagging
+}
+
+// tagging is an entry of the tag timeline.
+type tagging struct {
+	tag string
+	taggedPost
 }
 
 func NewInMemoryRepo() *InMemoryRepo {
 	log := logger.GetLogger()
-	var inMemoryStorage = make(map[string]model.Post)
-	return &InMemoryRepo{memory: inMemoryStorage, mu: &sync.Mutex{}, logger: log}
+	return &InMemoryRepo{
+		posts:    make(map[string]*postEntry),
+		comments: make(map[string]*commentEntry),
+		feed:     &postFeed{drafts: make(map[string][]*postEntry)},
+		tags:     make(map[string][]taggedPost),
+		trending: &tagTimeline{},
+		keys:     make(map[idempotencyKey]idempotencyEntry),
+		events:   &eventLog{},
+		hooks:    make(map[string]*webhookEntry),
+		sends:    &deliveryLog{deliveries: make(map[string]*deliveryEntry)},
+		mu:       &sync.RWMutex{},
+		logger:   log,
+	}
 }
 
 func (i InMemoryRepo) CreatePost(ctx context.Context, input model.CreatePostInput) (*model.Post, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	id := uuid.New().String()
+	t, err := i.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := i.createPost(t, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.commit(); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// CreatePostOnce creates the post unless an unexpired result is stored
+// under the key, and returns false with the stored post then, nil if it
+// was deleted. The key is checked and stored in the write of the post.
+func (i InMemoryRepo) CreatePostOnce(ctx context.Context, input model.CreatePostInput, scope, key string, expiresAt time.Time) (*model.Post, bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if id, ok := i.storedResult(scope, key); ok {
+		if entry, ok := i.posts[id]; ok {
+			return entry.output(), false, nil
+		}
+		return nil, false, nil
+	}
+
+	t, err := i.begin()
+	if err != nil {
+		return nil, false, err
+	}
+
+	output, err := i.createPost(t, input)
+	if err != nil {
+		return nil, false, err
+	}
+	i.storeResult(t, scope, key, output.ID, expiresAt)
+
+	if err := t.commit(); err != nil {
+		return nil, false, err
+	}
+
+	return output, true, nil
+}
+
+// createPost writes the post and its events. It must be called with the
+// mutex held.
+func (i InMemoryRepo) createPost(t *tx, input model.CreatePostInput) (*model.Post, error) {
+	i.feed.seq++
+	entry := &postEntry{
+		seq: i.feed.seq,
+		post: model.Post{
+			ID:                 uuid.New().String(),
+			AuthorID:           input.AuthorID,
+			Content:            input.Content,
+			AreCommentsAllowed: input.AreCommentsAllowed,
+			CreatedAt:          time.Now().UTC(),
+			Version:            1,
+			Status:             model.PostStatusPublished,
+			PublishAt:          input.PublishAt,
+		},
+	}
 
-	output := model.Post{
-		ID:                 id,
-		AuthorID:           input.AuthorID,
-		Content:            input.Content,
-		AreCommentsAllowed: input.AreCommentsAllowed,
-		CreatedAt:          time.Now().Format(time.DateTime),
-		UpdatedAt:          "",
+	if input.Status != nil {
+		entry.post.Status = *input.Status
 	}
 
-	i.memory[id] = output
+	i.posts[entry.post.ID] = entry
+	i.setTags(entry, input.Tags)
+	i.feed.add(entry)
+
+	output := entry.output()
+
+	if err := i.writeEvent(outbox.PostCreated, output.ID, output); err != nil {
+		return nil, err
+	}
+	if output.Status == model.PostStatusPublished {
+		if err := i.writeEvent(outbox.PostPublished, output.ID, output); err != nil {
+			return nil, err
+		}
+	}
 
-	return &output, nil
+	t.putPost(entry)
 
+	return output, nil
 }
 
 func (i InMemoryRepo) PostComment(ctx context.Context, input model.PostCommentInput) (*model.Comment, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	id := uuid.New().String()
+	t, err := i.begin()
+	if err != nil {
+		return nil, err
+	}
 
-	output := model.Comment{
-		ID:              id,
-		PostID:          input.PostID,
-		ParentCommentID: input.ParentCommentID,
-		AuthorID:        input.AuthorID,
-		Content:         input.Content,
-		CreatedAt:       time.Now().Format(time.DateTime),
-		UpdatedAt:       "",
+	output, err := i.postComment(t, input)
+	if err != nil {
+		return nil, err
 	}
 
-	post := i.memory[input.PostID]
+	if err := t.commit(); err != nil {
+		return nil, err
+	}
 
-	if input.ParentCommentID == nil {
-		post.Comments = append(post.Comments, &output)
-	} else {
-		parentComment := findCommentByID(post.Comments, *input.ParentCommentID)
-		if parentComment == nil {
-			return nil, errors.New("parent comment with ID not found")
+	return output, nil
+}
+
+// PostCommentOnce is CreatePostOnce for comments.
+func (i InMemoryRepo) PostCommentOnce(ctx context.Context, input model.PostCommentInput, scope, key string, expiresAt time.Time) (*model.Comment, bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if id, ok := i.storedResult(scope, key); ok {
+		if entry, ok := i.comments[id]; ok {
+			return entry.output(), false, nil
 		}
-		parentComment.Replies = append(parentComment.Replies, &output)
+		return nil, false, nil
+	}
+
+	t, err := i.begin()
+	if err != nil {
+		return nil, false, err
+	}
+
+	output, err := i.postComment(t, input)
+	if err != nil {
+		return nil, false, err
 	}
+	i.storeResult(t, scope, key, output.ID, expiresAt)
 
-	i.memory[post.ID] = post
+	if err := t.commit(); err != nil {
+		return nil, false, err
+	}
 
-	return &output, nil
+	return output, true, nil
 }
 
-func findCommentByID(comments []*model.Comment, id string) *model.Comment {
+// postComment writes the comment and its event. It must be called with the
+// mutex held.
+func (i InMemoryRepo) postComment(t *tx, input model.PostCommentInput) (*model.Comment, error) {
+	post, ok := i.posts[input.PostID]
+	if !ok {
+		return nil, errors.New("post does not exist")
+	}
 
-	for _, comment := range comments {
-		if comment.ID == id {
-			return comment
+	var parent *commentEntry
+	if input.ParentCommentID != nil {
+		if parent, ok = i.comments[*input.ParentCommentID]; !ok {
+			return nil, errors.New("parent comment with ID not found")
 		}
-
-		if found := findCommentByID(comment.Replies, id); found != nil {
-			return found
+		if parent.post != post {
+			return nil, errors.New("parent comment belongs to another post")
 		}
 	}
-	return nil
+
+	i.feed.seq++
+	entry := &commentEntry{
+		seq: i.feed.seq,
+		comment: model.Comment{
+			ID:              uuid.New().String(),
+			PostID:          input.PostID,
+			ParentCommentID: input.ParentCommentID,
+			AuthorID:        input.AuthorID,
+			Content:         input.Content,
+			CreatedAt:       time.Now().UTC(),
+			Version:         1,
+		},
+		post:   post,
+		parent: parent,
+	}
+
+	i.link(entry)
+	post.post.CommentCount++
+	if parent != nil {
+		parent.comment.ReplyCount++
+	}
+
+	output := entry.output()
+
+	if err := i.writeEvent(outbox.CommentCreated, output.ID, output); err != nil {
+		return nil, err
+	}
+
+	t.putComment(entry)
+	if parent != nil {
+		t.putComment(parent)
+	}
+	t.putPost(post)
+
+	return output, nil
 }
 
 func (i InMemoryRepo) PutPost(ctx context.Context, input model.PutPostInput) (*model.Post, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	output, ok := i.memory[input.ID]
+	t, err := i.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := i.posts[input.ID]
 	if !ok {
-		return nil, errors.New("post does not exist")
+		return nil, service.ErrPostNotFound
 	}
-	output.CreatedAt = time.Now().Format(time.DateTime)
+	if input.ExpectedVersion != nil && *input.ExpectedVersion != entry.post.Version {
+		return nil, &model.VersionConflictError{Current: entry.post.Version}
+	}
+
+	previous := entry.post.Status
+	i.feed.remove(entry)
+
+	now := time.Now().UTC()
+	entry.post.UpdatedAt = &now
+	entry.post.Version++
 
 	if input.Content != nil {
-		output.Content = *input.Content
+		entry.post.Content = *input.Content
 	}
 	if input.AreCommentsAllowed != nil {
-		output.AreCommentsAllowed = *input.AreCommentsAllowed
+		entry.post.AreCommentsAllowed = *input.AreCommentsAllowed
+	}
+	if input.Tags != nil {
+		i.setTags(entry, input.Tags)
+	}
+	if input.Status != nil {
+		entry.post.Status = *input.Status
+	}
+	if input.PublishAt != nil {
+		entry.post.PublishAt = input.PublishAt
+	}
+
+	i.feed.add(entry)
+
+	output := entry.output()
+
+	if err := i.writeEvent(outbox.PostUpdated, output.ID, output); err != nil {
+		return nil, err
+	}
+	if previous != model.PostStatusPublished && output.Status == model.PostStatusPublished {
+		if err := i.writeEvent(outbox.PostPublished, output.ID, output); err != nil {
+			return nil, err
+		}
 	}
 
-	return &output, nil
+	t.putPost(entry)
+	if err := t.commit(); err != nil {
+		return nil, err
+	}
+
+	return output, nil
 }
 
 func (i InMemoryRepo) PutComment(ctx context.Context, input model.PutCommentInput) (*model.Comment, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	post, ok := i.memory[input.ID]
+	t, err := i.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := i.comments[input.ID]
 	if !ok {
-		return nil, errors.New("post does not exist")
+		return nil, errors.New("there is no comment with this id")
 	}
+	if input.ExpectedVersion != nil && *input.ExpectedVersion != entry.comment.Version {
+		return nil, &model.VersionConflictError{Current: entry.comment.Version}
+	}
+
+	now := time.Now().UTC()
+	entry.comment.Content = input.Content
+	entry.comment.UpdatedAt = &now
+	entry.comment.Version++
 
-	var output = findCommentByID(post.Comments, input.ID)
-	if output == nil {
-		return output, errors.New("there is no comment with this id")
+	output := entry.output()
+
+	if err := i.writeEvent(outbox.CommentUpdated, output.ID, output); err != nil {
+		return nil, err
 	}
 
-	output.Content = input.Content
-	output.UpdatedAt = time.Now().Format(time.DateTime)
+	t.putComment(entry)
+	if err := t.commit(); err != nil {
+		return nil, err
+	}
 
 	return output, nil
 }
@@ -136,125 +462,1184 @@ func (i InMemoryRepo) DeletePost(ctx context.Context, id string) (bool, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	_, ok := i.memory[id]
+	t, err := i.begin()
+	if err != nil {
+		return false, err
+	}
+
+	entry, ok := i.posts[id]
 	if !ok {
-		return false, errors.New("post does not exist")
+		return false, nil
 	}
 
-	delete(i.memory, id)
+	i.removePost(entry)
+
+	if err := i.writeEvent(outbox.PostDeleted, id, outbox.Deleted{ID: id}); err != nil {
+		return false, err
+	}
+
+	t.deletePost(id)
+	if err := t.commit(); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
+// DeleteComment deletes a comment together with its replies.
 func (i InMemoryRepo) DeleteComment(ctx context.Context, id string) (bool, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	var deleteComment func(comments []*model.Comment) bool
-	deleteComment = func(comments []*model.Comment) bool {
-		for i := 0; i < len(comments); i++ {
-			if comments[i].ID == id {
-				comments = append(comments[:i], comments[i+1:]...)
-				return true
+	t, err := i.begin()
+	if err != nil {
+		return false, err
+	}
+
+	entry, ok := i.comments[id]
+	if !ok {
+		return false, nil
+	}
+
+	post, parent := entry.post, entry.parent
+
+	post.post.CommentCount -= int32(i.unlink(entry))
+	t.deleteComment(id)
+
+	if parent != nil {
+		parent.comment.ReplyCount--
+		t.putComment(parent)
+	}
+	t.putPost(post)
+
+	if err := i.writeEvent(outbox.CommentDeleted, id, outbox.Deleted{ID: id, PostID: post.post.ID}); err != nil {
+		return false, err
+	}
+
+	if err := t.commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetPost returns a page of the published posts, the pinned ones first.
+func (i InMemoryRepo) GetPost(ctx context.Context, first int32) ([]*model.Post, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if first < 0 {
+		return nil, errors.New("invalid 'first' value")
+	}
+
+	var output []*model.Post
+
+	skip := int(first)
+	for ; skip < len(i.feed.pinned) && len(output) < pageSize; skip++ {
+		output = append(output, i.feed.pinned[skip].output())
+	}
+	skip = max(skip-len(i.feed.pinned), 0)
+
+	published := i.feed.published
+	for n := len(published) - 1 - skip; n >= 0 && len(output) < pageSize; n-- {
+		output = append(output, published[n].output())
+	}
+
+	return output, nil
+}
+
+func (i InMemoryRepo) GetPostByID(ctx context.Context, id string) (*model.Post, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	entry, ok := i.posts[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return entry.output(), nil
+}
+
+// GetCommentByPostID returns a page of the top-level comments of a post,
+// the pinned one first.
+func (i InMemoryRepo) GetCommentByPostID(ctx context.Context, postID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	post, ok := i.posts[postID]
+	if !ok {
+		return commentsPage(nil, nil, first, orderBy)
+	}
+
+	pinned := post.pinnedComment
+	if pinned != nil && pinned.parent != nil {
+		pinned = nil
+	}
+
+	return commentsPage(post.roots, pinned, first, orderBy)
+}
+
+// GetCommentByParentCommentID returns a page of the direct replies to a comment.
+func (i InMemoryRepo) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var replies []*commentEntry
+	if parent, ok := i.comments[parentCommentID]; ok {
+		replies = parent.replies
+	}
+
+	return commentsPage(replies, nil, first, orderBy)
+}
+
+// commentsPage returns the page of comments at offset first among siblings
+// given in creation order, the same way PsqlPool orders them. The pinned
+// comment, if it is one of the siblings, comes before the others.
+//
+// Newest and oldest pages are read straight from siblings, the other orders
+// sort a copy of them.
+func commentsPage(siblings []*commentEntry, pinned *commentEntry, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
+	if first < 0 {
+		return nil, errors.New("invalid 'first' value")
+	}
+
+	ordered, reverse := siblings, true
+
+	switch orderBy {
+	case model.CommentOrderNewest:
+	case model.CommentOrderOldest:
+		reverse = false
+	case model.CommentOrderTop, model.CommentOrderMostReplies:
+		ordered = slices.Clone(siblings)
+		slices.SortFunc(ordered, func(a, b *commentEntry) int {
+			if orderBy == model.CommentOrderTop && a.threadSize != b.threadSize {
+				return cmp.Compare(b.threadSize, a.threadSize)
 			}
-			if len(comments[i].Replies) > 0 {
-				if deleted := deleteComment(comments[i].Replies); deleted {
-					return true
-				}
+			if orderBy == model.CommentOrderMostReplies && a.comment.ReplyCount != b.comment.ReplyCount {
+				return cmp.Compare(b.comment.ReplyCount, a.comment.ReplyCount)
 			}
+			return cmp.Compare(b.seq, a.seq)
+		})
+		reverse = false
+	default:
+		return nil, fmt.Errorf("unknown order %q", orderBy)
+	}
+
+	at := func(n int) *commentEntry {
+		if reverse {
+			return ordered[len(ordered)-1-n]
+		}
+		return ordered[n]
+	}
+
+	var output []*model.Comment
+
+	skip, position := int(first), len(ordered)
+	if pinned != nil {
+		if skip == 0 {
+			output = append(output, pinned.output())
+		} else {
+			skip--
+		}
+
+		if reverse {
+			n, _ := slices.BinarySearchFunc(ordered, pinned.seq, compareSeq[*commentEntry])
+			position = len(ordered) - 1 - n
+		} else {
+			position = slices.Index(ordered, pinned)
 		}
-		return false
 	}
 
-	for _, post := range i.memory {
-		if deleted := deleteComment(post.Comments); deleted {
-			return true, nil
+	for n := skip; len(output) < pageSize; n++ {
+		k := n
+		if k >= position {
+			// The pinned comment is listed first already.
+			k++
+		}
+		if k >= len(ordered) {
+			break
 		}
+		output = append(output, at(k).output())
 	}
 
-	return false, nil
+	return output, nil
 }
 
-func (i InMemoryRepo) GetPost(ctx context.Context, first int32) ([]*model.Post, error) {
-	i.mu.Lock()
-	defer i.mu.Unlock()
+// GetPostByTag returns the published posts tagged with tag, most recently
+// tagged first, that were tagged before the post after.
+func (i InMemoryRepo) GetPostByTag(ctx context.Context, tag string, first int32, after *string) ([]*model.Post, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 
 	if first < 0 {
 		return nil, errors.New("invalid 'first' value")
 	}
-	var posts []*model.Post
 
-	for _, post := range i.memory {
-		posts = append(posts, &post)
+	index := i.tags[tag]
+	end := len(index)
+
+	if after != nil {
+		cursor, ok := i.posts[*after]
+		if !ok {
+			return nil, nil
+		}
+		taggedAt, ok := cursor.taggedAt[tag]
+		if !ok {
+			return nil, nil
+		}
+		end, _ = slices.BinarySearchFunc(index, taggedPost{post: cursor, taggedAt: taggedAt}, compareTagged)
+	}
+
+	var output []*model.Post
+
+	for n := end - 1; n >= 0 && len(output) < int(first); n-- {
+		if post := index[n].post; post.post.Status == model.PostStatusPublished {
+			output = append(output, post.output())
+		}
+	}
+
+	return output, nil
+}
+
+func (i InMemoryRepo) GetTrendingTags(ctx context.Context, since time.Time, limit int32) ([]*model.TagCount, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if limit < 0 {
+		return nil, errors.New("invalid 'limit' value")
+	}
+
+	taggings := i.trending.taggings
+	start := sort.Search(len(taggings), func(n int) bool { return !taggings[n].taggedAt.Before(since) })
+
+	counts := make(map[string]int32)
+	for _, entry := range taggings[start:] {
+		if entry.post.post.Status == model.PostStatusPublished {
+			counts[entry.tag]++
+		}
+	}
+
+	output := make([]*model.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		output = append(output, &model.TagCount{Tag: tag, Count: count})
 	}
 
-	sort.Slice(posts, func(i, j int) bool {
-		return posts[i].ID < posts[j].ID
+	sort.Slice(output, func(i, j int) bool {
+		if output[i].Count != output[j].Count {
+			return output[i].Count > output[j].Count
+		}
+		return output[i].Tag < output[j].Tag
 	})
 
-	start := int(first)
-	end := start + 10
+	if int(limit) < len(output) {
+		output = output[:limit]
+	}
 
-	if start >= len(posts) {
-		return nil, nil
+	return output, nil
+}
+
+// GetDrafts returns the posts of an author that are not published, newest first.
+func (i InMemoryRepo) GetDrafts(ctx context.Context, authorID string, first int32) ([]*model.Post, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if first < 0 {
+		return nil, errors.New("invalid 'first' value")
 	}
 
-	if end > len(posts) {
-		end = len(posts)
+	var output []*model.Post
+
+	drafts := i.feed.drafts[authorID]
+	for n := len(drafts) - 1; n >= 0 && len(output) < int(first); n-- {
+		output = append(output, drafts[n].output())
 	}
 
-	return posts[start:end], nil
+	return output, nil
 }
 
-func (i InMemoryRepo) GetPostByID(ctx context.Context, id string) (*model.Post, error) {
+func (i InMemoryRepo) PublishScheduledPosts(ctx context.Context, now time.Time) ([]*model.Post, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	post, exists := i.memory[id]
-	if !exists {
-		return nil, errors.New("post with this ID not found")
+	t, err := i.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	queue := i.feed.scheduled
+	n := sort.Search(len(queue), func(n int) bool { return queue[n].post.PublishAt.After(now) })
+
+	due := slices.Clone(queue[:n])
+	slices.SortFunc(due, func(a, b *postEntry) int { return cmp.Compare(a.seq, b.seq) })
+
+	var output []*model.Post
+
+	for _, entry := range due {
+		i.feed.remove(entry)
+		entry.post.Status = model.PostStatusPublished
+		i.feed.add(entry)
+		t.putPost(entry)
+
+		post := entry.output()
+		if err := i.writeEvent(outbox.PostPublished, post.ID, post); err != nil {
+			return nil, err
+		}
+
+		output = append(output, post)
+	}
+
+	if err := t.commit(); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+func (i InMemoryRepo) GetCommentByID(ctx context.Context, id string) (*model.Comment, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	entry, ok := i.comments[id]
+	if !ok {
+		return nil, nil
 	}
 
-	return &post, nil
+	return entry.output(), nil
 }
 
-func (i InMemoryRepo) GetCommentByPostID(ctx context.Context, postID string, first int32) ([]*model.Comment, error) {
+func (i InMemoryRepo) SetPostPinned(ctx context.Context, id string, pinned bool) (*model.Post, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	post, exists := i.memory[postID]
-	if !exists {
-		return nil, errors.New("post with this ID not found")
+	t, err := i.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := i.posts[id]
+	if !ok {
+		return nil, errors.New("post does not exist")
+	}
+
+	i.feed.remove(entry)
+	entry.pinnedAt = pinnedAt(entry.pinnedAt, pinned)
+	i.feed.add(entry)
+
+	output := entry.output()
+
+	if err := i.writeEvent(outbox.PostUpdated, id, output); err != nil {
+		return nil, err
+	}
+
+	t.putPost(entry)
+	if err := t.commit(); err != nil {
+		return nil, err
 	}
 
-	return post.Comments, nil
+	return output, nil
 }
 
-func (i InMemoryRepo) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32) ([]*model.Comment, error) {
+// SetCommentPinned pins a comment, unpinning the comment previously pinned in the same post.
+func (i InMemoryRepo) SetCommentPinned(ctx context.Context, id string, pinned bool) (*model.Comment, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	var result []*model.Comment
+	t, err := i.begin()
+	if err != nil {
+		return nil, err
+	}
 
-	for _, post := range i.memory {
-		result = append(result, findCommentsByParentID(post.Comments, parentCommentID)...)
+	entry, ok := i.comments[id]
+	if !ok {
+		return nil, errors.New("there is no comment with this id")
 	}
 
-	if first > 0 && int(first) < len(result) {
-		result = result[:first]
+	post := entry.post
+	if previous := post.pinnedComment; pinned && previous != nil && previous != entry {
+		previous.pinnedAt = time.Time{}
+		t.putComment(previous)
 	}
 
-	return result, nil
-}
+	entry.pinnedAt = pinnedAt(entry.pinnedAt, pinned)
+	switch {
+	case pinned:
+		post.pinnedComment = entry
+	case post.pinnedComment == entry:
+		post.pinnedComment = nil
+	}
 
-func findCommentsByParentID(comments []*model.Comment, parentCommentID string) []*model.Comment {
-	var result []*model.Comment
+	output := entry.output()
 
-	for _, comment := range comments {
-		if comment.ParentCommentID != nil && *comment.ParentCommentID == parentCommentID {
-			result = append(result, comment)
-		}
-		result = append(result, findCommentsByParentID(comment.Replies, parentCommentID)...)
+	if err := i.writeEvent(outbox.CommentUpdated, id, output); err != nil {
+		return nil, err
+	}
+
+	t.putComment(entry)
+	if err := t.commit(); err != nil {
+		return nil, err
 	}
 
-	return result
+	return output, nil
+}
+
+// SetCommentLocked locks a comment. A comment is locked when it is locked
+// itself or when any of its ancestors is.
+func (i InMemoryRepo) SetCommentLocked(ctx context.Context, id string, locked bool) (*model.Comment, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	t, err := i.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := i.comments[id]
+	if !ok {
+		return nil, errors.New("there is no comment with this id")
+	}
+
+	entry.locked = locked
+
+	output := entry.output()
+
+	if err := i.writeEvent(outbox.CommentUpdated, id, output); err != nil {
+		return nil, err
+	}
+
+	t.putComment(entry)
+	if err := t.commit(); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// RecountComments recomputes the comment counters from the comment indexes.
+func (i InMemoryRepo) RecountComments(ctx context.Context) (int64, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	t, err := i.begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var fixed int64
+
+	for _, entry := range i.posts {
+		count := len(entry.roots)
+		for _, root := range entry.roots {
+			count += root.threadSize
+		}
+
+		if entry.post.CommentCount != int32(count) {
+			entry.post.CommentCount = int32(count)
+			t.putPost(entry)
+			fixed++
+		}
+	}
+
+	for _, entry := range i.comments {
+		if entry.comment.ReplyCount != int32(len(entry.replies)) {
+			entry.comment.ReplyCount = int32(len(entry.replies))
+			t.putComment(entry)
+			fixed++
+		}
+	}
+
+	if err := t.commit(); err != nil {
+		return 0, err
+	}
+
+	return fixed, nil
+}
+
+// pinnedAt returns the pinning time of an entry pinned at current after it
+// is pinned or unpinned. Pinning a pinned entry keeps its time.
+func pinnedAt(current time.Time, pinned bool) time.Time {
+	switch {
+	case !pinned:
+		return time.Time{}
+	case current.IsZero():
+		return time.Now().UTC()
+	default:
+		return current
+	}
+}
+
+// output returns a copy of the post, without comments like in PsqlPool.
+func (p *postEntry) output() *model.Post {
+	output := p.post
+	output.IsPinned = !p.pinnedAt.IsZero()
+	output.Tags = slices.Clone(p.post.Tags)
+
+	return &output
+}
+
+// output returns a copy of the comment, without replies like in PsqlPool.
+func (c *commentEntry) output() *model.Comment {
+	output := c.comment
+	output.IsPinned = !c.pinnedAt.IsZero()
+	output.IsLocked = c.isLocked()
+
+	return &output
+}
+
+// isLocked reports whether the comment or any of its ancestors is locked.
+func (c *commentEntry) isLocked() bool {
+	for ; c != nil; c = c.parent {
+		if c.locked {
+			return true
+		}
+	}
+
+	return false
+}
+
+// link adds a comment to the comment indexes.
+func (i InMemoryRepo) link(entry *commentEntry) {
+	i.comments[entry.comment.ID] = entry
+
+	if entry.parent == nil {
+		entry.post.roots = insertSeq(entry.post.roots, entry)
+	} else {
+		entry.parent.replies = insertSeq(entry.parent.replies, entry)
+	}
+
+	for parent := entry.parent; parent != nil; parent = parent.parent {
+		parent.threadSize++
+	}
+}
+
+// unlink removes a comment and its replies from the comment indexes and
+// returns the number of removed comments.
+func (i InMemoryRepo) unlink(entry *commentEntry) int {
+	if entry.parent == nil {
+		entry.post.roots = removeSeq(entry.post.roots, entry)
+	} else {
+		entry.parent.replies = removeSeq(entry.parent.replies, entry)
+	}
+
+	removed := 1 + entry.threadSize
+	for parent := entry.parent; parent != nil; parent = parent.parent {
+		parent.threadSize -= removed
+	}
+
+	i.forget(entry)
+
+	return removed
+}
+
+// forget drops a comment and its replies from the comment map.
+func (i InMemoryRepo) forget(entry *commentEntry) {
+	delete(i.comments, entry.comment.ID)
+	if entry.post.pinnedComment == entry {
+		entry.post.pinnedComment = nil
+	}
+
+	for _, reply := range entry.replies {
+		i.forget(reply)
+	}
+}
+
+// removePost removes a post with its comments and tags from the indexes.
+func (i InMemoryRepo) removePost(entry *postEntry) {
+	i.feed.remove(entry)
+	i.tag(entry, nil)
+
+	for _, root := range entry.roots {
+		i.forget(root)
+	}
+
+	delete(i.posts, entry.post.ID)
+}
+
+// add puts a post into the list it is paged through in.
+func (f *postFeed) add(entry *postEntry) {
+	if scheduled(entry) {
+		n, _ := slices.BinarySearchFunc(f.scheduled, entry, compareDue)
+		f.scheduled = slices.Insert(f.scheduled, n, entry)
+	}
+
+	switch {
+	case entry.post.Status != model.PostStatusPublished:
+		f.drafts[entry.post.AuthorID] = insertSeq(f.drafts[entry.post.AuthorID], entry)
+	case entry.pinnedAt.IsZero():
+		f.published = insertSeq(f.published, entry)
+	default:
+		n := sort.Search(len(f.pinned), func(n int) bool { return pinnedBefore(entry, f.pinned[n]) })
+		f.pinned = slices.Insert(f.pinned, n, entry)
+	}
+}
+
+// remove takes a post out of the list it is paged through in. It must be
+// called before the status, the publication time or the pinning of the post
+// changes.
+func (f *postFeed) remove(entry *postEntry) {
+	if scheduled(entry) {
+		if n, ok := slices.BinarySearchFunc(f.scheduled, entry, compareDue); ok {
+			f.scheduled = slices.Delete(f.scheduled, n, n+1)
+		}
+	}
+
+	switch {
+	case entry.post.Status != model.PostStatusPublished:
+		drafts := removeSeq(f.drafts[entry.post.AuthorID], entry)
+		if len(drafts) == 0 {
+			delete(f.drafts, entry.post.AuthorID)
+		} else {
+			f.drafts[entry.post.AuthorID] = drafts
+		}
+	case entry.pinnedAt.IsZero():
+		f.published = removeSeq(f.published, entry)
+	default:
+		if n := slices.Index(f.pinned, entry); n >= 0 {
+			f.pinned = slices.Delete(f.pinned, n, n+1)
+		}
+	}
+}
+
+// scheduled reports whether the post waits to be published by
+// PublishScheduledPosts.
+func scheduled(entry *postEntry) bool {
+	return entry.post.Status == model.PostStatusScheduled && entry.post.PublishAt != nil
+}
+
+// compareDue orders the scheduled posts by publication time, then by
+// creation.
+func compareDue(a, b *postEntry) int {
+	if c := a.post.PublishAt.Compare(*b.post.PublishAt); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.seq, b.seq)
+}
+
+// pinnedBefore reports whether post a comes before post b among the pinned
+// posts: the most recently pinned first, then the newest.
+func pinnedBefore(a, b *postEntry) bool {
+	if !a.pinnedAt.Equal(b.pinnedAt) {
+		return a.pinnedAt.After(b.pinnedAt)
+	}
+	return a.seq > b.seq
+}
+
+// sequenced is an entry numbered in creation order.
+type sequenced interface {
+	order() uint64
+}
+
+func (p *postEntry) order() uint64     { return p.seq }
+func (c *commentEntry) order() uint64  { return c.seq }
+func (w *webhookEntry) order() uint64  { return w.seq }
+func (d *deliveryEntry) order() uint64 { return d.seq }
+
+func compareSeq[T sequenced](entry T, seq uint64) int {
+	return cmp.Compare(entry.order(), seq)
+}
+
+// insertSeq inserts an entry into a slice kept in creation order.
+func insertSeq[T sequenced](entries []T, entry T) []T {
+	n, _ := slices.BinarySearchFunc(entries, entry.order(), compareSeq[T])
+	return slices.Insert(entries, n, entry)
+}
+
+// removeSeq removes an entry from a slice kept in creation order.
+func removeSeq[T sequenced](entries []T, entry T) []T {
+	n, ok := slices.BinarySearchFunc(entries, entry.order(), compareSeq[T])
+	if !ok {
+		return entries
+	}
+	return slices.Delete(entries, n, n+1)
+}
+
+// compareTagged orders the tag index by tagging time, then by post creation.
+func compareTagged(a, b taggedPost) int {
+	if c := a.taggedAt.Compare(b.taggedAt); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.post.seq, b.post.seq)
+}
+
+// compareTagging orders the timeline like the tag index, then by tag.
+func compareTagging(a, b tagging) int {
+	if c := compareTagged(a.taggedPost, b.taggedPost); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.tag, b.tag)
+}
+
+func (t *tagTimeline) add(entry tagging) {
+	n, _ := slices.BinarySearchFunc(t.taggings, entry, compareTagging)
+	t.taggings = slices.Insert(t.taggings, n, entry)
+}
+
+func (t *tagTimeline) remove(entry tagging) {
+	if n, ok := slices.BinarySearchFunc(t.taggings, entry, compareTagging); ok {
+		t.taggings = slices.Delete(t.taggings, n, n+1)
+	}
+}
+
+// setTags replaces the tags of a post. Tags that stay on the post keep
+// their original tagging time, like rows in post_tags.
+func (i InMemoryRepo) setTags(entry *postEntry, tags []string) {
+	now := time.Now().UTC()
+
+	taggedAt := make(map[string]time.Time, len(tags))
+	for _, tag := range tags {
+		if at, ok := entry.taggedAt[tag]; ok {
+			taggedAt[tag] = at
+		} else {
+			taggedAt[tag] = now
+		}
+	}
+
+	i.tag(entry, taggedAt)
+
+	entry.post.Tags = make([]string, 0, len(taggedAt))
+	for tag := range taggedAt {
+		entry.post.Tags = append(entry.post.Tags, tag)
+	}
+	sort.Strings(entry.post.Tags)
+}
+
+// tag moves a post in the tag index to the given tags and tagging times.
+func (i InMemoryRepo) tag(entry *postEntry, taggedAt map[string]time.Time) {
+	for tag, at := range entry.taggedAt {
+		if next, ok := taggedAt[tag]; ok && next.Equal(at) {
+			continue
+		}
+
+		index := i.tags[tag]
+		if n, ok := slices.BinarySearchFunc(index, taggedPost{post: entry, taggedAt: at}, compareTagged); ok {
+			index = slices.Delete(index, n, n+1)
+		}
+		i.trending.remove(tagging{tag: tag, taggedPost: taggedPost{post: entry, taggedAt: at}})
+
+		if len(index) == 0 {
+			delete(i.tags, tag)
+		} else {
+			i.tags[tag] = index
+		}
+	}
+
+	for tag, at := range taggedAt {
+		if previous, ok := entry.taggedAt[tag]; ok && previous.Equal(at) {
+			continue
+		}
+
+		tagged := taggedPost{post: entry, taggedAt: at}
+		n, _ := slices.BinarySearchFunc(i.tags[tag], tagged, compareTagged)
+		i.tags[tag] = slices.Insert(i.tags[tag], n, tagged)
+		i.trending.add(tagging{tag: tag, taggedPost: tagged})
+	}
+
+	entry.taggedAt = taggedAt
+}
+
+// GetIdempotencyKey returns the ID remembered under the key, or "" if the key is unknown or expired.
+func (i InMemoryRepo) GetIdempotencyKey(ctx context.Context, scope, key string) (string, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	id, _ := i.storedResult(scope, key)
+
+	return id, nil
+}
+
+// storedResult returns the ID stored under the key unless it expired. It
+// must be called with the mutex held.
+func (i InMemoryRepo) storedResult(scope, key string) (string, bool) {
+	entry, ok := i.keys[idempotencyKey{scope: scope, key: key}]
+	if !ok || !entry.expiresAt.After(time.Now()) {
+		return "", false
+	}
+
+	return entry.resultID, true
+}
+
+// storeResult stores resultID under the key. It must be called with the
+// mutex held.
+func (i InMemoryRepo) storeResult(t *tx, scope, key, resultID string, expiresAt time.Time) {
+	k := idempotencyKey{scope: scope, key: key}
+	i.keys[k] = idempotencyEntry{resultID: resultID, expiresAt: expiresAt}
+
+	t.putKey(k, i.keys[k])
+}
+
+func (i InMemoryRepo) PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	t, err := i.begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for k, entry := range i.keys {
+		if !entry.expiresAt.After(now) {
+			delete(i.keys, k)
+			t.deleteKey(k)
+			purged++
+		}
+	}
+
+	if err := t.commit(); err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// writeEvent records an event in the outbox. It must be called with the mutex held.
+func (i InMemoryRepo) writeEvent(eventType outbox.EventType, aggregateID string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	i.events.nextID++
+	i.events.events = append(i.events.events, outbox.Event{
+		ID:          i.events.nextID,
+		Type:        eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+		CreatedAt:   time.Now().UTC(),
+	})
+
+	return nil
+}
+
+// FetchOutbox returns the oldest undelivered events in the order they were written.
+func (i InMemoryRepo) FetchOutbox(ctx context.Context, limit int32) ([]outbox.Event, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if limit < 0 {
+		return nil, errors.New("invalid 'limit' value")
+	}
+
+	events := i.events.events
+	if int(limit) < len(events) {
+		events = events[:limit]
+	}
+
+	return append([]outbox.Event{}, events...), nil
+}
+
+func (i InMemoryRepo) AckOutbox(ctx context.Context, id int64) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	t, err := i.begin()
+	if err != nil {
+		return err
+	}
+
+	if n, ok := slices.BinarySearchFunc(i.events.events, id, compareEvent); ok {
+		i.events.events = slices.Delete(i.events.events, n, n+1)
+		t.ackEvent(id)
+	}
+
+	return t.commit()
+}
+
+// compareEvent orders the outbox by event ID, the order events are written in.
+func compareEvent(event outbox.Event, id int64) int {
+	return cmp.Compare(event.ID, id)
+}
+
+func (i InMemoryRepo) CreateWebhook(ctx context.Context, input model.CreateWebhookInput) (*model.Webhook, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	t, err := i.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	i.sends.seq++
+	entry := &webhookEntry{
+		seq: i.sends.seq,
+		webhook: model.Webhook{
+			ID:        uuid.New().String(),
+			URL:       input.URL,
+			Events:    append([]model.WebhookEvent{}, input.Events...),
+			CreatedAt: time.Now().UTC(),
+		},
+		secret: input.Secret,
+	}
+
+	i.hooks[entry.webhook.ID] = entry
+	i.sends.webhooks = append(i.sends.webhooks, entry)
+
+	t.putWebhook(entry)
+	if err := t.commit(); err != nil {
+		return nil, err
+	}
+
+	return entry.output(), nil
+}
+
+// DeleteWebhook deletes a webhook together with its deliveries.
+func (i InMemoryRepo) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	t, err := i.begin()
+	if err != nil {
+		return false, err
+	}
+
+	entry, ok := i.hooks[id]
+	if !ok {
+		return false, nil
+	}
+
+	i.removeWebhook(entry)
+
+	t.deleteWebhook(id)
+	if err := t.commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// removeWebhook removes a webhook with its deliveries from the indexes.
+func (i InMemoryRepo) removeWebhook(entry *webhookEntry) {
+	for _, delivery := range entry.deliveries {
+		delete(i.sends.deliveries, delivery.delivery.ID)
+		i.sends.pending = removeSeq(i.sends.pending, delivery)
+	}
+
+	i.sends.webhooks = removeSeq(i.sends.webhooks, entry)
+	delete(i.hooks, entry.webhook.ID)
+}
+
+func (i InMemoryRepo) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	output := make([]*model.Webhook, 0, len(i.sends.webhooks))
+	for _, entry := range i.sends.webhooks {
+		output = append(output, entry.output())
+	}
+
+	return output, nil
+}
+
+// GetWebhookDeliveries returns the latest deliveries of a webhook, newest first.
+func (i InMemoryRepo) GetWebhookDeliveries(ctx context.Context, webhookID string, first int32, status *model.DeliveryStatus) ([]*model.WebhookDelivery, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if first < 0 {
+		return nil, errors.New("invalid 'first' value")
+	}
+
+	entry, ok := i.hooks[webhookID]
+	if !ok {
+		return nil, nil
+	}
+
+	var output []*model.WebhookDelivery
+
+	for n := len(entry.deliveries) - 1; n >= 0 && len(output) < int(first); n-- {
+		delivery := entry.deliveries[n].delivery
+		if status != nil && delivery.Status != *status {
+			continue
+		}
+		if delivery.Status != model.DeliveryStatusPending {
+			delivery.NextAttemptAt = nil
+		}
+		output = append(output, &delivery)
+	}
+
+	return output, nil
+}
+
+// EnqueueWebhookDeliveries creates a delivery for every webhook subscribed to the event.
+func (i InMemoryRepo) EnqueueWebhookDeliveries(ctx context.Context, eventID int64, event model.WebhookEvent, payload json.RawMessage) (int64, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	t, err := i.begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var enqueued int64
+	now := time.Now().UTC()
+
+	for _, hook := range i.sends.webhooks {
+		if !slices.Contains(hook.webhook.Events, event) {
+			continue
+		}
+
+		i.sends.seq++
+		entry := &deliveryEntry{
+			seq: i.sends.seq,
+			delivery: model.WebhookDelivery{
+				ID:            uuid.New().String(),
+				WebhookID:     hook.webhook.ID,
+				Event:         event,
+				Status:        model.DeliveryStatusPending,
+				NextAttemptAt: &now,
+				CreatedAt:     now,
+			},
+			eventID: eventID,
+			payload: payload,
+		}
+		i.addDelivery(hook, entry)
+		t.putDelivery(entry)
+		enqueued++
+	}
+
+	if err := t.commit(); err != nil {
+		return 0, err
+	}
+
+	return enqueued, nil
+}
+
+// addDelivery adds a delivery of a webhook to the indexes.
+func (i InMemoryRepo) addDelivery(hook *webhookEntry, entry *deliveryEntry) {
+	hook.deliveries = insertSeq(hook.deliveries, entry)
+	i.sends.deliveries[entry.delivery.ID] = entry
+	if entry.delivery.Status == model.DeliveryStatusPending {
+		i.sends.pending = insertSeq(i.sends.pending, entry)
+	}
+}
+
+// FetchWebhookDeliveries leases the pending deliveries that are due, so that
+// concurrent workers do not send them twice. Like in PsqlPool, the lease
+// postpones the next attempt of a delivery.
+func (i InMemoryRepo) FetchWebhookDeliveries(ctx context.Context, now time.Time, limit int32) ([]webhook.Delivery, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	t, err := i.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	var output []webhook.Delivery
+
+	lease := now.Add(WebhookLease)
+
+	for _, entry := range i.sends.pending {
+		if len(output) >= int(limit) {
+			break
+		}
+
+		delivery := entry.delivery
+		if delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		entry.delivery.NextAttemptAt = &lease
+		t.putDelivery(entry)
+
+		hook := i.hooks[delivery.WebhookID]
+		output = append(output, webhook.Delivery{
+			ID:        delivery.ID,
+			EventID:   entry.eventID,
+			WebhookID: delivery.WebhookID,
+			URL:       hook.webhook.URL,
+			Secret:    hook.secret,
+			Event:     delivery.Event,
+			Payload:   entry.payload,
+			Attempts:  delivery.Attempts,
+			CreatedAt: delivery.CreatedAt,
+		})
+	}
+
+	if err := t.commit(); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// RecordWebhookAttempt stores the outcome of a delivery attempt. Like in
+// PsqlPool, an attempt of a delivery that no longer exists is ignored.
+func (i InMemoryRepo) RecordWebhookAttempt(ctx context.Context, attempt webhook.Attempt) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	t, err := i.begin()
+	if err != nil {
+		return err
+	}
+
+	entry, ok := i.sends.deliveries[attempt.DeliveryID]
+	if !ok {
+		return nil
+	}
+
+	next := entry.delivery
+	next.Status = attempt.Status
+	next.Attempts = attempt.Attempts
+	next.ResponseCode = attempt.ResponseCode
+	next.LastError = attempt.Error
+	if attempt.NextAttemptAt != nil {
+		next.NextAttemptAt = attempt.NextAttemptAt
+	}
+	next.DeliveredAt = nil
+	if attempt.Status == model.DeliveryStatusSucceeded {
+		now := time.Now().UTC()
+		next.DeliveredAt = &now
+	}
+
+	i.updateDelivery(entry, next)
+
+	t.putDelivery(entry)
+	return t.commit()
+}
+
+// updateDelivery replaces a delivery, keeping the pending index in sync.
+func (i InMemoryRepo) updateDelivery(entry *deliveryEntry, delivery model.WebhookDelivery) {
+	wasPending := entry.delivery.Status == model.DeliveryStatusPending
+	entry.delivery = delivery
+
+	switch isPending := delivery.Status == model.DeliveryStatusPending; {
+	case wasPending && !isPending:
+		i.sends.pending = removeSeq(i.sends.pending, entry)
+	case !wasPending && isPending:
+		i.sends.pending = insertSeq(i.sends.pending, entry)
+	}
+}
+
+// output returns a copy of the webhook.
+func (w *webhookEntry) output() *model.Webhook {
+	output := w.webhook
+	output.Events = slices.Clone(w.webhook.Events)
+
+	return &output
+}
+
+// HealthCheck fails once the log of a persisted storage has failed, writes
+// are refused then. Otherwise it succeeds, the storage lives in the process.
+func (i InMemoryRepo) HealthCheck(ctx context.Context) error {
+	if i.persistence != nil {
+		return i.persistence.log.Err()
+	}
+
+	return nil
+}
+
+// Close snapshots a persisted storage and closes its log, otherwise it does
+// nothing.
+func (i InMemoryRepo) Close() {
+	if i.persistence == nil {
+		return
+	}
+
+	close(i.persistence.stop)
+	<-i.persistence.done
+
+	if err := i.snapshot(); err != nil {
+		i.logger.Error("failed to snapshot in_memory storage", zap.String("err", err.Error()))
+	}
+	if err := i.persistence.log.Close(); err != nil {
+		i.logger.Error("failed to close in_memory storage log", zap.String("err", err.Error()))
+	}
 }
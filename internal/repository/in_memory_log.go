@@ -0,0 +1,569 @@
+package repository
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"ozon/internal/outbox"
+	"ozon/internal/transport/graph/model"
+	"ozon/internal/wal"
+	"ozon/pkg/logger"
+)
+
+// Operations of the changes written to the log.
+const (
+	opPutPost       = "putPost"
+	opDeletePost    = "deletePost"
+	opPutComment    = "putComment"
+	opDeleteComment = "deleteComment"
+	opPutKey        = "putKey"
+	opDeleteKey     = "deleteKey"
+	opPutEvent      = "putEvent"
+	opAckEvent      = "ackEvent"
+	opPutWebhook    = "putWebhook"
+	opDeleteWebhook = "deleteWebhook"
+	opPutDelivery   = "putDelivery"
+	opNextEventID   = "nextEventId"
+)
+
+// defaultSnapshotInterval is used when the config sets no interval.
+const defaultSnapshotInterval = 5 * time.Minute
+
+// persistence writes the changes of an InMemoryRepo to a write-ahead log
+// and snapshots its state periodically.
+type persistence struct {
+	log      *wal.Log
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// change is a change to one entity. Only the field of its operation is set.
+type change struct {
+	Op       string          `json:"op"`
+	ID       string          `json:"id,omitempty"`
+	EventID  int64           `json:"eventId,omitempty"`
+	Post     *postRecord     `json:"post,omitempty"`
+	Comment  *commentRecord  `json:"comment,omitempty"`
+	Key      *keyRecord      `json:"key,omitempty"`
+	Event    *outbox.Event   `json:"event,omitempty"`
+	Webhook  *webhookRecord  `json:"webhook,omitempty"`
+	Delivery *deliveryRecord `json:"delivery,omitempty"`
+}
+
+// postRecord is a post without its comments, together with the state the
+// repository keeps beside it.
+type postRecord struct {
+	Post     model.Post           `json:"post"`
+	TaggedAt map[string]time.Time `json:"taggedAt,omitempty"`
+	PinnedAt *time.Time           `json:"pinnedAt,omitempty"`
+}
+
+// commentRecord is a comment without its replies. Locked is whether the
+// comment is locked itself rather than by an ancestor.
+type commentRecord struct {
+	Comment  model.Comment `json:"comment"`
+	Locked   bool          `json:"locked,omitempty"`
+	PinnedAt *time.Time    `json:"pinnedAt,omitempty"`
+}
+
+type keyRecord struct {
+	Scope     string    `json:"scope"`
+	Key       string    `json:"key"`
+	ResultID  string    `json:"resultId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type webhookRecord struct {
+	Webhook model.Webhook `json:"webhook"`
+	Secret  string        `json:"secret"`
+}
+
+type deliveryRecord struct {
+	Delivery model.WebhookDelivery `json:"delivery"`
+	EventID  int64                 `json:"eventId"`
+	Payload  json.RawMessage       `json:"payload"`
+}
+
+// snapshot is the state of the repository. Comments are listed parents
+// first, replies in the order they were posted.
+type snapshot struct {
+	Posts       []postRecord     `json:"posts"`
+	Comments    []commentRecord  `json:"comments"`
+	Keys        []keyRecord      `json:"keys"`
+	Events      []outbox.Event   `json:"events"`
+	NextEventID int64            `json:"nextEventId"`
+	Webhooks    []webhookRecord  `json:"webhooks"`
+	Deliveries  []deliveryRecord `json:"deliveries"`
+}
+
+// NewPersistentInMemoryRepo returns an in-memory repository that survives
+// restarts: every write is appended to a log in cfg.Dir, which is replayed
+// on startup on top of the latest snapshot.
+func NewPersistentInMemoryRepo(cfg wal.Config) (*InMemoryRepo, error) {
+	i := NewInMemoryRepo()
+
+	log, err := wal.Open(cfg, logger.Logger{Logger: i.logger}, i.restore, i.replay)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := cfg.SnapshotInterval
+	if interval <= 0 {
+		interval = defaultSnapshotInterval
+	}
+
+	i.persistence = &persistence{log: log, interval: interval, stop: make(chan struct{}), done: make(chan struct{})}
+	go i.snapshotLoop()
+
+	i.logger.Info("in_memory storage recovered", zap.String("dir", cfg.Dir), zap.Int("posts", len(i.posts)))
+
+	return i, nil
+}
+
+// tx collects the changes of a write. It is nil when the repository is not
+// persisted, its methods do nothing then.
+type tx struct {
+	repo    InMemoryRepo
+	changes []change
+	// events is the last outbox event before the write, the events written
+	// by it are logged on commit.
+	events int64
+}
+
+// begin starts collecting the changes of a write. It fails once the log has
+// failed, as the write could not be persisted. It must be called with the
+// mutex held.
+func (i InMemoryRepo) begin() (*tx, error) {
+	if i.persistence == nil {
+		return nil, nil
+	}
+	if err := i.persistence.log.Err(); err != nil {
+		return nil, err
+	}
+
+	return &tx{repo: i, events: i.events.nextID}, nil
+}
+
+// commit appends the changes of a write to the log. If that fails, the
+// changes are rolled back, so that neither they nor their outbox events
+// are seen by anyone.
+func (t *tx) commit() error {
+	if t == nil {
+		return nil
+	}
+
+	events := t.repo.events.events
+	n := len(events)
+	for n > 0 && events[n-1].ID > t.events {
+		n--
+	}
+	for _, event := range events[n:] {
+		t.changes = append(t.changes, change{Op: opPutEvent, Event: &event})
+	}
+
+	if len(t.changes) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(t.changes)
+	if err == nil {
+		err = t.repo.persistence.log.Append(data)
+	}
+	if err != nil {
+		return errors.Join(err, t.repo.rollback())
+	}
+
+	return nil
+}
+
+// rollback drops the changes that are not in the log by loading the state
+// from the log again. It must be called with the mutex held.
+func (i InMemoryRepo) rollback() error {
+	clear(i.posts)
+	clear(i.comments)
+	*i.feed = postFeed{drafts: make(map[string][]*postEntry)}
+	clear(i.tags)
+	*i.trending = tagTimeline{}
+	clear(i.keys)
+	*i.events = eventLog{}
+	clear(i.hooks)
+	*i.sends = deliveryLog{deliveries: make(map[string]*deliveryEntry)}
+
+	if err := i.persistence.log.Reload(i.restore, i.replay); err != nil {
+		i.logger.Error("failed to roll back in_memory storage", zap.String("err", err.Error()))
+		return fmt.Errorf("roll back: %w", err)
+	}
+
+	return nil
+}
+
+func (t *tx) putPost(entry *postEntry) {
+	if t == nil {
+		return
+	}
+
+	record := entry.record()
+	t.changes = append(t.changes, change{Op: opPutPost, Post: &record})
+}
+
+func (t *tx) deletePost(id string) {
+	if t != nil {
+		t.changes = append(t.changes, change{Op: opDeletePost, ID: id})
+	}
+}
+
+func (t *tx) putComment(entry *commentEntry) {
+	if t == nil {
+		return
+	}
+
+	record := entry.record()
+	t.changes = append(t.changes, change{Op: opPutComment, Comment: &record})
+}
+
+func (t *tx) deleteComment(id string) {
+	if t != nil {
+		t.changes = append(t.changes, change{Op: opDeleteComment, ID: id})
+	}
+}
+
+func (t *tx) putKey(k idempotencyKey, entry idempotencyEntry) {
+	if t != nil {
+		t.changes = append(t.changes, change{Op: opPutKey, Key: &keyRecord{Scope: k.scope, Key: k.key, ResultID: entry.resultID, ExpiresAt: entry.expiresAt}})
+	}
+}
+
+func (t *tx) deleteKey(k idempotencyKey) {
+	if t != nil {
+		t.changes = append(t.changes, change{Op: opDeleteKey, Key: &keyRecord{Scope: k.scope, Key: k.key}})
+	}
+}
+
+func (t *tx) ackEvent(id int64) {
+	if t != nil {
+		t.changes = append(t.changes, change{Op: opAckEvent, EventID: id})
+	}
+}
+
+func (t *tx) putWebhook(entry *webhookEntry) {
+	if t != nil {
+		t.changes = append(t.changes, change{Op: opPutWebhook, Webhook: &webhookRecord{Webhook: entry.webhook, Secret: entry.secret}})
+	}
+}
+
+func (t *tx) deleteWebhook(id string) {
+	if t != nil {
+		t.changes = append(t.changes, change{Op: opDeleteWebhook, ID: id})
+	}
+}
+
+func (t *tx) putDelivery(entry *deliveryEntry) {
+	if t != nil {
+		t.changes = append(t.changes, change{Op: opPutDelivery, Delivery: &deliveryRecord{Delivery: entry.delivery, EventID: entry.eventID, Payload: entry.payload}})
+	}
+}
+
+// record returns the logged state of the post.
+func (p *postEntry) record() postRecord {
+	record := postRecord{Post: *p.output()}
+
+	if len(p.taggedAt) > 0 {
+		record.TaggedAt = maps.Clone(p.taggedAt)
+	}
+	if pinnedAt := p.pinnedAt; !pinnedAt.IsZero() {
+		record.PinnedAt = &pinnedAt
+	}
+
+	return record
+}
+
+// record returns the logged state of the comment.
+func (c *commentEntry) record() commentRecord {
+	record := commentRecord{Comment: *c.output(), Locked: c.locked}
+
+	if pinnedAt := c.pinnedAt; !pinnedAt.IsZero() {
+		record.PinnedAt = &pinnedAt
+	}
+
+	return record
+}
+
+// replay applies an entry of the log.
+func (i InMemoryRepo) replay(entry []byte) error {
+	var changes []change
+	if err := json.Unmarshal(entry, &changes); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		if err := i.apply(c); err != nil {
+			return fmt.Errorf("%s: %w", c.Op, err)
+		}
+	}
+
+	return nil
+}
+
+// restore loads a snapshot into the empty repository.
+func (i InMemoryRepo) restore(data []byte) error {
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	var changes []change
+	for _, post := range s.Posts {
+		changes = append(changes, change{Op: opPutPost, Post: &post})
+	}
+	for _, comment := range s.Comments {
+		changes = append(changes, change{Op: opPutComment, Comment: &comment})
+	}
+	for _, key := range s.Keys {
+		changes = append(changes, change{Op: opPutKey, Key: &key})
+	}
+	for _, event := range s.Events {
+		changes = append(changes, change{Op: opPutEvent, Event: &event})
+	}
+	changes = append(changes, change{Op: opNextEventID, EventID: s.NextEventID})
+	for _, hook := range s.Webhooks {
+		changes = append(changes, change{Op: opPutWebhook, Webhook: &hook})
+	}
+	for _, delivery := range s.Deliveries {
+		changes = append(changes, change{Op: opPutDelivery, Delivery: &delivery})
+	}
+
+	for _, c := range changes {
+		if err := i.apply(c); err != nil {
+			return fmt.Errorf("%s: %w", c.Op, err)
+		}
+	}
+
+	return nil
+}
+
+// apply makes a logged change again.
+func (i InMemoryRepo) apply(c change) error {
+	switch c.Op {
+	case opPutPost:
+		i.applyPost(*c.Post)
+	case opDeletePost:
+		entry, ok := i.posts[c.ID]
+		if !ok {
+			return errors.New("post does not exist")
+		}
+		i.removePost(entry)
+	case opPutComment:
+		return i.applyComment(*c.Comment)
+	case opDeleteComment:
+		entry, ok := i.comments[c.ID]
+		if !ok {
+			return errors.New("there is no comment with this id")
+		}
+		i.unlink(entry)
+	case opPutKey:
+		i.keys[idempotencyKey{scope: c.Key.Scope, key: c.Key.Key}] = idempotencyEntry{resultID: c.Key.ResultID, expiresAt: c.Key.ExpiresAt}
+	case opDeleteKey:
+		delete(i.keys, idempotencyKey{scope: c.Key.Scope, key: c.Key.Key})
+	case opPutEvent:
+		i.events.events = append(i.events.events, *c.Event)
+		i.events.nextID = max(i.events.nextID, c.Event.ID)
+	case opAckEvent:
+		i.events.events = slices.DeleteFunc(i.events.events, func(event outbox.Event) bool { return event.ID == c.EventID })
+	case opNextEventID:
+		i.events.nextID = max(i.events.nextID, c.EventID)
+	case opPutWebhook:
+		i.applyWebhook(*c.Webhook)
+	case opDeleteWebhook:
+		entry, ok := i.hooks[c.ID]
+		if !ok {
+			return errors.New("webhook does not exist")
+		}
+		i.removeWebhook(entry)
+	case opPutDelivery:
+		return i.applyDelivery(*c.Delivery)
+	default:
+		return errors.New("unknown operation")
+	}
+
+	return nil
+}
+
+func (i InMemoryRepo) applyPost(record postRecord) {
+	entry, ok := i.posts[record.Post.ID]
+	if ok {
+		i.feed.remove(entry)
+	} else {
+		i.feed.seq++
+		entry = &postEntry{seq: i.feed.seq}
+		i.posts[record.Post.ID] = entry
+	}
+
+	entry.post = record.Post
+	entry.post.Comments = nil
+	entry.pinnedAt = time.Time{}
+	if record.PinnedAt != nil {
+		entry.pinnedAt = *record.PinnedAt
+	}
+
+	taggedAt := make(map[string]time.Time, len(record.TaggedAt))
+	maps.Copy(taggedAt, record.TaggedAt)
+	i.tag(entry, taggedAt)
+
+	i.feed.add(entry)
+}
+
+func (i InMemoryRepo) applyComment(record commentRecord) error {
+	entry, ok := i.comments[record.Comment.ID]
+	if !ok {
+		post, ok := i.posts[record.Comment.PostID]
+		if !ok {
+			return errors.New("post does not exist")
+		}
+
+		var parent *commentEntry
+		if id := record.Comment.ParentCommentID; id != nil {
+			if parent, ok = i.comments[*id]; !ok {
+				return errors.New("parent comment with ID not found")
+			}
+		}
+
+		i.feed.seq++
+		entry = &commentEntry{seq: i.feed.seq, comment: record.Comment, post: post, parent: parent}
+		i.link(entry)
+	}
+
+	entry.comment = record.Comment
+	entry.comment.Replies = nil
+	entry.locked = record.Locked
+
+	post := entry.post
+	entry.pinnedAt = time.Time{}
+	switch {
+	case record.PinnedAt != nil:
+		entry.pinnedAt = *record.PinnedAt
+		post.pinnedComment = entry
+	case post.pinnedComment == entry:
+		post.pinnedComment = nil
+	}
+
+	return nil
+}
+
+func (i InMemoryRepo) applyWebhook(record webhookRecord) {
+	entry, ok := i.hooks[record.Webhook.ID]
+	if !ok {
+		i.sends.seq++
+		entry = &webhookEntry{seq: i.sends.seq}
+		i.hooks[record.Webhook.ID] = entry
+		i.sends.webhooks = insertSeq(i.sends.webhooks, entry)
+	}
+
+	entry.webhook, entry.secret = record.Webhook, record.Secret
+}
+
+func (i InMemoryRepo) applyDelivery(record deliveryRecord) error {
+	if entry, ok := i.sends.deliveries[record.Delivery.ID]; ok {
+		i.updateDelivery(entry, record.Delivery)
+		entry.eventID, entry.payload = record.EventID, record.Payload
+		return nil
+	}
+
+	hook, ok := i.hooks[record.Delivery.WebhookID]
+	if !ok {
+		return errors.New("webhook does not exist")
+	}
+
+	i.sends.seq++
+	i.addDelivery(hook, &deliveryEntry{seq: i.sends.seq, delivery: record.Delivery, eventID: record.EventID, payload: record.Payload})
+
+	return nil
+}
+
+// export returns the state of the repository. It must be called with the
+// mutex held.
+func (i InMemoryRepo) export() snapshot {
+	s := snapshot{NextEventID: i.events.nextID}
+
+	posts := slices.Collect(maps.Values(i.posts))
+	slices.SortFunc(posts, func(a, b *postEntry) int { return cmp.Compare(a.seq, b.seq) })
+
+	var walk func(comments []*commentEntry)
+	walk = func(comments []*commentEntry) {
+		for _, comment := range comments {
+			s.Comments = append(s.Comments, comment.record())
+			walk(comment.replies)
+		}
+	}
+
+	for _, post := range posts {
+		s.Posts = append(s.Posts, post.record())
+		walk(post.roots)
+	}
+
+	for k, entry := range i.keys {
+		s.Keys = append(s.Keys, keyRecord{Scope: k.scope, Key: k.key, ResultID: entry.resultID, ExpiresAt: entry.expiresAt})
+	}
+	slices.SortFunc(s.Keys, func(a, b keyRecord) int {
+		return cmp.Or(cmp.Compare(a.Scope, b.Scope), cmp.Compare(a.Key, b.Key))
+	})
+
+	s.Events = append(s.Events, i.events.events...)
+
+	var deliveries []*deliveryEntry
+	for _, entry := range i.sends.webhooks {
+		s.Webhooks = append(s.Webhooks, webhookRecord{Webhook: entry.webhook, Secret: entry.secret})
+		deliveries = append(deliveries, entry.deliveries...)
+	}
+
+	slices.SortFunc(deliveries, func(a, b *deliveryEntry) int { return cmp.Compare(a.seq, b.seq) })
+	for _, entry := range deliveries {
+		s.Deliveries = append(s.Deliveries, deliveryRecord{Delivery: entry.delivery, EventID: entry.eventID, Payload: entry.payload})
+	}
+
+	return s
+}
+
+// snapshot writes the state to a snapshot, replacing the log before it.
+func (i InMemoryRepo) snapshot() error {
+	i.mu.Lock()
+	seq, ok, err := i.persistence.log.Rotate()
+	if err != nil || !ok {
+		i.mu.Unlock()
+		return err
+	}
+	state := i.export()
+	i.mu.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return i.persistence.log.Compact(seq, data)
+}
+
+func (i InMemoryRepo) snapshotLoop() {
+	defer close(i.persistence.done)
+
+	ticker := time.NewTicker(i.persistence.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-i.persistence.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if err := i.snapshot(); err != nil {
+			i.logger.Error("failed to snapshot in_memory storage", zap.String("err", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ozon/internal/transport/graph/model"
+	"ozon/internal/wal"
+	"ozon/pkg/logger"
+)
+
+func TestPersistentInMemoryRepo(t *testing.T) {
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	ctx := context.Background()
+	cfg := wal.Config{Dir: t.TempDir(), Fsync: wal.FsyncAlways}
+
+	repo, err := NewPersistentInMemoryRepo(cfg)
+	require.NoError(t, err)
+
+	post, err := repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "post", AreCommentsAllowed: true, Tags: []string{"go", "db"}})
+	require.NoError(t, err)
+	comment, err := repo.PostComment(ctx, model.PostCommentInput{PostID: post.ID, AuthorID: "2", Content: "comment"})
+	require.NoError(t, err)
+	_, err = repo.PostComment(ctx, model.PostCommentInput{PostID: post.ID, ParentCommentID: &comment.ID, AuthorID: "3", Content: "reply"})
+	require.NoError(t, err)
+	_, err = repo.SetCommentLocked(ctx, comment.ID, true)
+	require.NoError(t, err)
+	_, err = repo.SetPostPinned(ctx, post.ID, true)
+	require.NoError(t, err)
+
+	deleted, err := repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "deleted", Tags: []string{"go"}})
+	require.NoError(t, err)
+	_, err = repo.DeletePost(ctx, deleted.ID)
+	require.NoError(t, err)
+
+	_, _, err = repo.CreatePostOnce(ctx, model.CreatePostInput{AuthorID: "1", Content: "retried"}, "post", "key", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	events, err := repo.FetchOutbox(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, repo.AckOutbox(ctx, events[0].ID))
+
+	state := func(r *InMemoryRepo) string { return exportState(t, r) }
+	want := state(repo)
+
+	_, err = NewPersistentInMemoryRepo(cfg)
+	require.ErrorIs(t, err, wal.ErrLocked, "the log is used by one repository at a time")
+
+	// Without a snapshot, as after a crash, the state is replayed from the log.
+	replayed, err := NewPersistentInMemoryRepo(wal.Config{Dir: copyLog(t, cfg.Dir), Fsync: wal.FsyncAlways})
+	require.NoError(t, err)
+	assert.JSONEq(t, want, state(replayed))
+
+	repo.Close()
+
+	restored, err := NewPersistentInMemoryRepo(cfg)
+	require.NoError(t, err)
+	assert.JSONEq(t, want, state(restored), "the state is restored from the snapshot written on close")
+
+	reply, err := restored.PostComment(ctx, model.PostCommentInput{PostID: post.ID, ParentCommentID: &comment.ID, AuthorID: "4", Content: "after restart"})
+	require.NoError(t, err)
+	assert.True(t, reply.IsLocked, "the lock of the thread is restored")
+
+	tagged, err := restored.GetPostByTag(ctx, "db", 10, nil)
+	require.NoError(t, err)
+	require.Len(t, tagged, 1)
+	assert.Equal(t, post.ID, tagged[0].ID)
+
+	restored.Close()
+	replayed.Close()
+}
+
+func TestPersistentInMemoryRepo_FailedAppend(t *testing.T) {
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	ctx := context.Background()
+	repo, err := NewPersistentInMemoryRepo(wal.Config{Dir: t.TempDir(), Fsync: wal.FsyncAlways})
+	require.NoError(t, err)
+
+	post, err := repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "post", AreCommentsAllowed: true})
+	require.NoError(t, err)
+	want := exportState(t, repo)
+
+	// Appending to the closed log fails.
+	require.NoError(t, repo.persistence.log.Close())
+
+	_, err = repo.PostComment(ctx, model.PostCommentInput{PostID: post.ID, AuthorID: "2", Content: "comment"})
+	require.Error(t, err)
+	assert.JSONEq(t, want, exportState(t, repo), "the comment and its event are rolled back")
+
+	_, err = repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "later"})
+	require.Error(t, err, "writes are refused once the log has failed")
+	assert.JSONEq(t, want, exportState(t, repo))
+
+	assert.Error(t, repo.HealthCheck(ctx))
+}
+
+// exportState returns the state of the repository as JSON.
+func exportState(t *testing.T, r *InMemoryRepo) string {
+	t.Helper()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.Marshal(r.export())
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+// copyLog copies the files of the log in dir as a crash would leave them,
+// without the lock.
+func copyLog(t *testing.T, dir string) string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	copied := t.TempDir()
+	for _, entry := range entries {
+		if entry.Name() == "LOCK" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(copied, entry.Name()), data, 0o644))
+	}
+
+	return copied
+}
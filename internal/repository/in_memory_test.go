@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/logger"
+)
+
+func postContents(posts []*model.Post) []string {
+	output := make([]string, 0, len(posts))
+	for _, post := range posts {
+		output = append(output, post.Content)
+	}
+
+	return output
+}
+
+func commentContents(comments []*model.Comment) []string {
+	output := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		output = append(output, comment.Content)
+	}
+
+	return output
+}
+
+func TestInMemoryRepo_GetPost(t *testing.T) {
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	ctx := context.Background()
+	repo := NewInMemoryRepo()
+
+	posts := make(map[string]*model.Post)
+	for _, content := range []string{"a", "b", "c", "d"} {
+		post, err := repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: content})
+		require.NoError(t, err)
+		posts[content] = post
+	}
+
+	draft := model.PostStatusDraft
+	_, err := repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "draft", Status: &draft})
+	require.NoError(t, err)
+
+	_, err = repo.SetPostPinned(ctx, posts["b"].ID, true)
+	require.NoError(t, err)
+
+	content := "edited"
+	_, err = repo.PutPost(ctx, model.PutPostInput{ID: posts["c"].ID, Content: &content})
+	require.NoError(t, err)
+
+	got, err := repo.GetPost(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "d", "edited", "a"}, postContents(got), "pinned first, then newest first")
+
+	got, err = repo.GetPost(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"edited", "a"}, postContents(got))
+
+	deleted, err := repo.DeletePost(ctx, posts["d"].ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = repo.DeletePost(ctx, posts["d"].ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	missing, err := repo.GetPostByID(ctx, posts["d"].ID)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestInMemoryRepo_CommentOrders(t *testing.T) {
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	ctx := context.Background()
+	repo := NewInMemoryRepo()
+
+	post, err := repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "post", AreCommentsAllowed: true})
+	require.NoError(t, err)
+
+	comment := func(content string, parent *model.Comment) *model.Comment {
+		input := model.PostCommentInput{PostID: post.ID, AuthorID: "2", Content: content}
+		if parent != nil {
+			input.ParentCommentID = &parent.ID
+		}
+
+		output, err := repo.PostComment(ctx, input)
+		require.NoError(t, err)
+		return output
+	}
+
+	// a has the largest thread, b the most direct replies, d and a tie on
+	// direct replies.
+	a := comment("a", nil)
+	b := comment("b", nil)
+	comment("c", nil)
+	d := comment("d", nil)
+	reply := comment("a1", a)
+	comment("a1.1", reply)
+	comment("a1.2", reply)
+	comment("b1", b)
+	comment("b2", b)
+	comment("d1", d)
+
+	tests := []struct {
+		order model.CommentOrder
+		want  []string
+	}{
+		{order: model.CommentOrderNewest, want: []string{"d", "c", "b", "a"}},
+		{order: model.CommentOrderOldest, want: []string{"a", "b", "c", "d"}},
+		{order: model.CommentOrderTop, want: []string{"a", "b", "d", "c"}},
+		{order: model.CommentOrderMostReplies, want: []string{"b", "d", "a", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			got, err := repo.GetCommentByPostID(ctx, post.ID, 0, tt.order)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, commentContents(got))
+		})
+	}
+
+	_, err = repo.GetCommentByPostID(ctx, post.ID, 0, "RANDOM")
+	assert.Error(t, err, "unknown orders are rejected")
+}
+
+func TestInMemoryRepo_ScheduledIndex(t *testing.T) {
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	ctx := context.Background()
+	repo := NewInMemoryRepo()
+
+	now := time.Now().UTC()
+	scheduled := model.PostStatusScheduled
+	schedule := func(content string, at time.Time) *model.Post {
+		post, err := repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: content, Status: &scheduled, PublishAt: &at})
+		require.NoError(t, err)
+		return post
+	}
+
+	later := schedule("later", now.Add(time.Hour))
+	schedule("second", now.Add(-time.Minute))
+	schedule("first", now.Add(-time.Hour))
+	deleted := schedule("deleted", now.Add(-time.Minute))
+
+	due := func() []string {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+
+		output := make([]string, 0, len(repo.feed.scheduled))
+		for _, entry := range repo.feed.scheduled {
+			output = append(output, entry.post.Content)
+		}
+		return output
+	}
+	assert.Equal(t, []string{"first", "second", "deleted", "later"}, due(), "ordered by publication time")
+
+	_, err := repo.DeletePost(ctx, deleted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "later"}, due())
+
+	published, err := repo.PublishScheduledPosts(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"second", "first"}, postContents(published), "published in creation order")
+	assert.Equal(t, []string{"later"}, due(), "only the due posts are taken")
+
+	sooner := now.Add(-time.Second)
+	_, err = repo.PutPost(ctx, model.PutPostInput{ID: later.ID, PublishAt: &sooner})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"later"}, due())
+
+	published, err = repo.PublishScheduledPosts(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"later"}, postContents(published), "a rescheduled post is due at its new time")
+	assert.Empty(t, due())
+}
+
+func TestInMemoryRepo_TagTimeline(t *testing.T) {
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	ctx := context.Background()
+	repo := NewInMemoryRepo()
+
+	taggings := func() []string {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+
+		output := make([]string, 0, len(repo.trending.taggings))
+		for _, entry := range repo.trending.taggings {
+			output = append(output, entry.post.post.Content+"#"+entry.tag)
+		}
+		return output
+	}
+
+	a, err := repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "a", Tags: []string{"go", "db"}})
+	require.NoError(t, err)
+	b, err := repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "b", Tags: []string{"go"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a#db", "a#go", "b#go"}, taggings())
+
+	_, err = repo.PutPost(ctx, model.PutPostInput{ID: a.ID, Tags: []string{"go", "sql"}})
+	require.NoError(t, err)
+	got := taggings()
+	assert.Equal(t, "a#go", got[0], "a kept tag keeps its tagging time")
+	assert.ElementsMatch(t, []string{"a#go", "b#go", "a#sql"}, got)
+
+	_, err = repo.DeletePost(ctx, b.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a#go", "a#sql"}, taggings())
+
+	trending, err := repo.GetTrendingTags(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*model.TagCount{{Tag: "go", Count: 1}, {Tag: "sql", Count: 1}}, trending)
+}
+
+func TestInMemoryRepo_Comments(t *testing.T) {
+	require.NoError(t, logger.InitLogger(logger.Config{Level: "fatal"}))
+
+	ctx := context.Background()
+	repo := NewInMemoryRepo()
+
+	post, err := repo.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "post", AreCommentsAllowed: true})
+	require.NoError(t, err)
+
+	comment := func(content string, parent *model.Comment) *model.Comment {
+		input := model.PostCommentInput{PostID: post.ID, AuthorID: "2", Content: content}
+		if parent != nil {
+			input.ParentCommentID = &parent.ID
+		}
+
+		output, err := repo.PostComment(ctx, input)
+		require.NoError(t, err)
+		return output
+	}
+
+	a := comment("a", nil)
+	b := comment("b", nil)
+	comment("c", nil)
+	reply := comment("reply", a)
+	nested := comment("nested", reply)
+	comment("other", b)
+
+	_, err = repo.SetCommentPinned(ctx, b.ID, true)
+	require.NoError(t, err)
+
+	tests := []struct {
+		order model.CommentOrder
+		first int32
+		want  []string
+	}{
+		{order: model.CommentOrderNewest, want: []string{"b", "c", "a"}},
+		{order: model.CommentOrderOldest, want: []string{"b", "a", "c"}},
+		{order: model.CommentOrderOldest, first: 1, want: []string{"a", "c"}},
+		{order: model.CommentOrderNewest, first: 2, want: []string{"a"}},
+		{order: model.CommentOrderTop, want: []string{"b", "a", "c"}},
+		{order: model.CommentOrderMostReplies, first: 1, want: []string{"a", "c"}},
+	}
+
+	for _, tt := range tests {
+		got, err := repo.GetCommentByPostID(ctx, post.ID, tt.first, tt.order)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, commentContents(got), "%s from %d", tt.order, tt.first)
+	}
+
+	edited, err := repo.PutComment(ctx, model.PutCommentInput{ID: nested.ID, Content: "edited"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, edited.Version)
+
+	replies, err := repo.GetCommentByParentCommentID(ctx, reply.ID, 0, model.CommentOrderNewest)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"edited"}, commentContents(replies))
+
+	_, err = repo.SetCommentLocked(ctx, a.ID, true)
+	require.NoError(t, err)
+
+	locked, err := repo.GetCommentByID(ctx, nested.ID)
+	require.NoError(t, err)
+	assert.True(t, locked.IsLocked, "the lock applies to the whole sub-thread")
+
+	deleted, err := repo.DeleteComment(ctx, reply.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	got, err := repo.GetCommentByID(ctx, nested.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got, "the replies are deleted with the comment")
+
+	parent, err := repo.GetCommentByID(ctx, a.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, parent.ReplyCount)
+
+	counted, err := repo.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, counted.CommentCount)
+
+	fixed, err := repo.RecountComments(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, fixed, "the counters are kept in sync")
+}
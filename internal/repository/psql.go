@@ -2,14 +2,19 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
+	"ozon/internal/outbox"
+	"ozon/internal/service"
 	"ozon/internal/transport/graph/model"
+	"ozon/internal/webhook"
 	"ozon/pkg/logger"
 	"ozon/pkg/postgresql"
+	"sort"
 	"time"
 )
 
@@ -26,40 +31,246 @@ type PsqlPool struct {
 	*zap.Logger
 }
 
-type times struct {
-	CreatedAt time.Time
-	UpdatedAt time.Time
+const postColumns = "id, author_id, content, are_comments_allowed, created_at, updated_at, status, publish_at, pinned_at IS NOT NULL, comment_count, version, " +
+	"ARRAY(SELECT tag FROM post_tags WHERE post_tags.post_id = posts.id ORDER BY tag)"
+
+func scanPost(row pgx.Row) (*model.Post, error) {
+	var output model.Post
+
+	err := row.Scan(&output.ID, &output.AuthorID, &output.Content, &output.AreCommentsAllowed, &output.CreatedAt, &output.UpdatedAt, &output.Status, &output.PublishAt, &output.IsPinned, &output.CommentCount, &output.Version, &output.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+const commentColumns = "id, post_id, parent_comment_id, author_id, content, created_at, updated_at, pinned_at IS NOT NULL, comment_thread_locked(id), reply_count, version"
+
+var commentOrders = map[model.CommentOrder]string{
+	model.CommentOrderNewest:      "created_at DESC, id DESC",
+	model.CommentOrderOldest:      "created_at ASC, id ASC",
+	model.CommentOrderTop:         "comment_thread_size(id) DESC, created_at DESC, id DESC",
+	model.CommentOrderMostReplies: "reply_count DESC, created_at DESC, id DESC",
+}
+
+func scanComment(row pgx.Row) (*model.Comment, error) {
+	var output model.Comment
+
+	err := row.Scan(&output.ID, &output.PostID, &output.ParentCommentID, &output.AuthorID, &output.Content, &output.CreatedAt, &output.UpdatedAt, &output.IsPinned, &output.IsLocked, &output.ReplyCount, &output.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+// setTags replaces the tags of a post, keeping the tagging time of the tags that stay.
+func setTags(ctx context.Context, tx pgx.Tx, postID string, tags []string) ([]string, error) {
+	query := "DELETE FROM post_tags WHERE post_id = $1 AND tag <> ALL($2)"
+
+	if _, err := tx.Exec(ctx, query, postID, tags); err != nil {
+		return nil, err
+	}
+
+	query = "INSERT INTO post_tags (post_id, tag) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING"
+
+	if _, err := tx.Exec(ctx, query, postID, tags); err != nil {
+		return nil, err
+	}
+
+	output := append([]string{}, tags...)
+	sort.Strings(output)
+
+	return output, nil
 }
 
-func (t times) parseTime() (string, string) {
-	return t.CreatedAt.Format(time.DateTime), t.UpdatedAt.Format(time.DateTime)
+// writeEvent records an event in the outbox as part of the transaction.
+func writeEvent(ctx context.Context, tx pgx.Tx, eventType outbox.EventType, aggregateID string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO outbox (event_type, aggregate_id, payload) VALUES ($1, $2, $3)"
+
+	_, err = tx.Exec(ctx, query, eventType, aggregateID, data)
+
+	return err
 }
 
 func (p PsqlPool) CreatePost(ctx context.Context, input model.CreatePostInput) (*model.Post, error) {
 
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool insert post: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	output, err := insertPost(ctx, tx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("PsqlPool insert post: %w", err)
+	}
+
+	return output, nil
+}
+
+// CreatePostOnce creates the post unless an unexpired result is stored
+// under the key, and returns false with the stored post then, nil if it
+// was deleted. The key is claimed in the transaction of the post, so of
+// concurrent retries only one writes a post and its events.
+func (p PsqlPool) CreatePostOnce(ctx context.Context, input model.CreatePostInput, scope, key string, expiresAt time.Time) (*model.Post, bool, error) {
+
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("PsqlPool insert post: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := claimIdempotencyKey(ctx, tx, scope, key, expiresAt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if id != "" {
+		output, err := scanPost(tx.QueryRow(ctx, "SELECT "+postColumns+" FROM posts WHERE id = $1", id))
+		switch {
+		case errors.Is(err, nil):
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, false, nil
+		default:
+			return nil, false, fmt.Errorf("PsqlPool select post %w", err)
+		}
+
+		return output, false, nil
+	}
+
+	output, err := insertPost(ctx, tx, input)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err = storeIdempotencyResult(ctx, tx, scope, key, output.ID); err != nil {
+		return nil, false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("PsqlPool insert post: %w", err)
+	}
+
+	return output, true, nil
+}
+
+// insertPost writes the post, its tags and its events in tx.
+func insertPost(ctx context.Context, tx pgx.Tx, input model.CreatePostInput) (*model.Post, error) {
+
 	var output = model.Post{
 		AuthorID:           input.AuthorID,
 		Content:            input.Content,
 		AreCommentsAllowed: input.AreCommentsAllowed,
+		Status:             model.PostStatusPublished,
+		PublishAt:          input.PublishAt,
 	}
 
-	t := times{}
+	if input.Status != nil {
+		output.Status = *input.Status
+	}
 
-	query := "INSERT INTO posts (author_id, content, are_comments_allowed) VALUES ($1, $2, $3) RETURNING id, created_at"
+	query := "INSERT INTO posts (author_id, content, are_comments_allowed, status, publish_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, version"
 
-	err := p.Pool.QueryRow(ctx, query, input.AuthorID, input.Content, input.AreCommentsAllowed).Scan(&output.ID, &t.CreatedAt)
+	err := tx.QueryRow(ctx, query, input.AuthorID, input.Content, input.AreCommentsAllowed, output.Status, input.PublishAt).Scan(&output.ID, &output.CreatedAt, &output.Version)
 
 	if err != nil {
 		return nil, fmt.Errorf("PsqlPool insert post: %w", err)
 	}
 
-	output.CreatedAt, output.UpdatedAt = t.parseTime()
-	return &output, nil
+	if output.Tags, err = setTags(ctx, tx, output.ID, input.Tags); err != nil {
+		return nil, fmt.Errorf("PsqlPool insert post tags: %w", err)
+	}
+
+	if err = writeEvent(ctx, tx, outbox.PostCreated, output.ID, output); err != nil {
+		return nil, fmt.Errorf("PsqlPool insert post: %w", err)
+	}
 
+	if output.Status == model.PostStatusPublished {
+		if err = writeEvent(ctx, tx, outbox.PostPublished, output.ID, output); err != nil {
+			return nil, fmt.Errorf("PsqlPool insert post: %w", err)
+		}
+	}
+
+	return &output, nil
 }
 
 func (p PsqlPool) PostComment(ctx context.Context, input model.PostCommentInput) (*model.Comment, error) {
 
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool insert comments: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	output, err := insertComment(ctx, tx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("PsqlPool insert comments: %w", err)
+	}
+
+	return output, nil
+}
+
+// PostCommentOnce is CreatePostOnce for comments.
+func (p PsqlPool) PostCommentOnce(ctx context.Context, input model.PostCommentInput, scope, key string, expiresAt time.Time) (*model.Comment, bool, error) {
+
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("PsqlPool insert comments: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := claimIdempotencyKey(ctx, tx, scope, key, expiresAt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if id != "" {
+		output, err := scanComment(tx.QueryRow(ctx, "SELECT "+commentColumns+" FROM comments WHERE id = $1", id))
+		switch {
+		case errors.Is(err, nil):
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, false, nil
+		default:
+			return nil, false, fmt.Errorf("PsqlPool select comment %w", err)
+		}
+
+		return output, false, nil
+	}
+
+	output, err := insertComment(ctx, tx, input)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err = storeIdempotencyResult(ctx, tx, scope, key, output.ID); err != nil {
+		return nil, false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("PsqlPool insert comments: %w", err)
+	}
+
+	return output, true, nil
+}
+
+// insertComment writes the comment and its event in tx.
+func insertComment(ctx context.Context, tx pgx.Tx, input model.PostCommentInput) (*model.Comment, error) {
+
 	var output = model.Comment{
 		PostID:          input.PostID,
 		ParentCommentID: input.ParentCommentID,
@@ -67,74 +278,143 @@ func (p PsqlPool) PostComment(ctx context.Context, input model.PostCommentInput)
 		Content:         input.Content,
 	}
 
-	t := times{}
-
-	query := ""
 	var err error
+	query := ""
 
 	if input.ParentCommentID != nil {
-		query = "INSERT INTO comments (post_id, parent_comment_id, author_id,content) VALUES ($1, $2, $3,$4) RETURNING id, created_at"
+		query = "INSERT INTO comments (post_id, parent_comment_id, author_id,content) VALUES ($1, $2, $3,$4) RETURNING id, created_at, version"
 
-		err = p.Pool.QueryRow(ctx, query, input.PostID, input.ParentCommentID, input.AuthorID, input.Content).Scan(&output.ID, &t.CreatedAt)
+		err = tx.QueryRow(ctx, query, input.PostID, input.ParentCommentID, input.AuthorID, input.Content).Scan(&output.ID, &output.CreatedAt, &output.Version)
 	} else {
-		query = "INSERT INTO comments (post_id, author_id,content) VALUES ($1, $2, $3) RETURNING id, created_at"
+		query = "INSERT INTO comments (post_id, author_id,content) VALUES ($1, $2, $3) RETURNING id, created_at, version"
 
-		err = p.Pool.QueryRow(ctx, query, input.PostID, input.AuthorID, input.Content).Scan(&output.ID, &t.CreatedAt)
+		err = tx.QueryRow(ctx, query, input.PostID, input.AuthorID, input.Content).Scan(&output.ID, &output.CreatedAt, &output.Version)
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("PsqlPool insert comments: %w", err)
 	}
-	output.CreatedAt, output.UpdatedAt = t.parseTime()
 
-	return &output, err
+	if err = writeEvent(ctx, tx, outbox.CommentCreated, output.ID, output); err != nil {
+		return nil, fmt.Errorf("PsqlPool insert comments: %w", err)
+	}
+
+	return &output, nil
 }
 
 func (p PsqlPool) PutPost(ctx context.Context, input model.PutPostInput) (*model.Post, error) {
 
-	var output = model.Post{}
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool update posts %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var previous model.PostStatus
+
+	query := "SELECT status FROM posts WHERE id = $1 FOR UPDATE"
+
+	err = tx.QueryRow(ctx, query, input.ID).Scan(&previous)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = service.ErrPostNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool update posts %w", err)
+	}
 
-	t := times{}
+	if input.Tags != nil {
+		if _, err = setTags(ctx, tx, input.ID, input.Tags); err != nil {
+			return nil, fmt.Errorf("PsqlPool update post tags %w", err)
+		}
+	}
 
-	query := "UPDATE posts SET content = COALESCE($1, content), are_comments_allowed = COALESCE($2, are_comments_allowed), updated_at = NOW() WHERE id = $3 RETURNING *"
+	query = "UPDATE posts SET content = COALESCE($1, content), are_comments_allowed = COALESCE($2, are_comments_allowed), " +
+		"status = COALESCE($4, status), publish_at = COALESCE($5, publish_at), updated_at = NOW(), version = version + 1 " +
+		"WHERE id = $3 AND ($6::int IS NULL OR version = $6) RETURNING " + postColumns
 
-	err := p.Pool.QueryRow(ctx, query, input.Content, input.AreCommentsAllowed, input.ID).Scan(&output.ID, &output.AuthorID, &output.Content, &output.AreCommentsAllowed, &t.CreatedAt, &t.UpdatedAt)
+	output, err := scanPost(tx.QueryRow(ctx, query, input.Content, input.AreCommentsAllowed, input.ID, input.Status, input.PublishAt, input.ExpectedVersion))
 
+	if errors.Is(err, pgx.ErrNoRows) && input.ExpectedVersion != nil {
+		err = p.versionConflict(ctx, "posts", input.ID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("PsqlPool update posts %w", err)
 	}
 
-	output.CreatedAt, output.UpdatedAt = t.parseTime()
+	if err = writeEvent(ctx, tx, outbox.PostUpdated, output.ID, output); err != nil {
+		return nil, fmt.Errorf("PsqlPool update posts %w", err)
+	}
+
+	if previous != model.PostStatusPublished && output.Status == model.PostStatusPublished {
+		if err = writeEvent(ctx, tx, outbox.PostPublished, output.ID, output); err != nil {
+			return nil, fmt.Errorf("PsqlPool update posts %w", err)
+		}
+	}
 
-	return &output, nil
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("PsqlPool update posts %w", err)
+	}
+
+	return output, nil
 }
 
 func (p PsqlPool) PutComment(ctx context.Context, input model.PutCommentInput) (*model.Comment, error) {
 
-	var output = model.Comment{
-		ID:      input.ID,
-		Content: input.Content,
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool update comments %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	t := times{}
-
-	query := "UPDATE comments SET content = $1, updated_at = NOW() WHERE id = $2 RETURNING post_id,parent_comment_id,author_id,created_at,updated_at"
+	query := "UPDATE comments SET content = $1, updated_at = NOW(), version = version + 1 " +
+		"WHERE id = $2 AND ($3::int IS NULL OR version = $3) RETURNING " + commentColumns
 
-	err := p.Pool.QueryRow(ctx, query, input.Content, input.ID).Scan(&output.PostID, &output.ParentCommentID, &output.AuthorID, &t.CreatedAt, &t.UpdatedAt)
+	output, err := scanComment(tx.QueryRow(ctx, query, input.Content, input.ID, input.ExpectedVersion))
 
+	if errors.Is(err, pgx.ErrNoRows) && input.ExpectedVersion != nil {
+		err = p.versionConflict(ctx, "comments", input.ID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("PsqlPool update comments %w", err)
 	}
-	output.CreatedAt, output.UpdatedAt = t.parseTime()
 
-	return &output, err
+	if err = writeEvent(ctx, tx, outbox.CommentUpdated, output.ID, output); err != nil {
+		return nil, fmt.Errorf("PsqlPool update comments %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("PsqlPool update comments %w", err)
+	}
+
+	return output, err
+}
+
+// versionConflict explains why a conditional update of the row matched nothing:
+// it reports the current version if the row exists and pgx.ErrNoRows otherwise.
+func (p PsqlPool) versionConflict(ctx context.Context, table string, id string) error {
+	var current int32
+
+	err := p.Pool.QueryRow(ctx, "SELECT version FROM "+table+" WHERE id = $1", id).Scan(&current)
+	if err != nil {
+		return err
+	}
+
+	return &model.VersionConflictError{Current: current}
 }
 
 func (p PsqlPool) DeletePost(ctx context.Context, id string) (bool, error) {
 
-	query := "DELETE FROM posts WHERE id = $1"
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("PsqlPool delete post %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	deleted := outbox.Deleted{}
+
+	query := "DELETE FROM posts WHERE id = $1 RETURNING id"
 
-	_, err := p.Pool.Exec(ctx, query, id)
+	err = tx.QueryRow(ctx, query, id).Scan(&deleted.ID)
 
 	switch {
 	case errors.Is(err, nil):
@@ -143,14 +423,30 @@ func (p PsqlPool) DeletePost(ctx context.Context, id string) (bool, error) {
 	default:
 		return false, fmt.Errorf("PsqlPool delete post %w", err)
 	}
+
+	if err = writeEvent(ctx, tx, outbox.PostDeleted, deleted.ID, deleted); err != nil {
+		return false, fmt.Errorf("PsqlPool delete post %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("PsqlPool delete post %w", err)
+	}
 	return true, err
 }
 
 func (p PsqlPool) DeleteComment(ctx context.Context, id string) (bool, error) {
 
-	query := "DELETE FROM comments WHERE id = $1"
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("PsqlPool delete comments %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	deleted := outbox.Deleted{}
 
-	_, err := p.Pool.Exec(ctx, query, id)
+	query := "DELETE FROM comments WHERE id = $1 RETURNING id, post_id"
+
+	err = tx.QueryRow(ctx, query, id).Scan(&deleted.ID, &deleted.PostID)
 
 	switch {
 	case errors.Is(err, nil):
@@ -159,47 +455,47 @@ func (p PsqlPool) DeleteComment(ctx context.Context, id string) (bool, error) {
 	default:
 		return false, fmt.Errorf("PsqlPool delete comments %w", err)
 	}
+
+	if err = writeEvent(ctx, tx, outbox.CommentDeleted, deleted.ID, deleted); err != nil {
+		return false, fmt.Errorf("PsqlPool delete comments %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("PsqlPool delete comments %w", err)
+	}
 	return true, err
 }
 
 func (p PsqlPool) GetPost(ctx context.Context, first int32) ([]*model.Post, error) {
-	t := times{}
 
 	var output []*model.Post
 
-	query := "SELECT * FROM posts ORDER BY created_at DESC LIMIT 10 OFFSET $1"
+	query := "SELECT " + postColumns + " FROM posts WHERE status = 'PUBLISHED' ORDER BY pinned_at DESC NULLS LAST, created_at DESC LIMIT 10 OFFSET $1"
 
 	rows, err := p.Pool.Query(ctx, query, first)
-	defer rows.Close()
-
 	if err != nil {
 		return nil, fmt.Errorf("PsqlPool select posts %w", err)
 	}
-
-	var row model.Post
+	defer rows.Close()
 
 	for rows.Next() {
 
-		err = rows.Scan(&row.ID, &row.AuthorID, &row.Content, &row.AreCommentsAllowed, &t.CreatedAt, &t.UpdatedAt)
+		row, err := scanPost(rows)
 		if err != nil {
 			return nil, fmt.Errorf("PsqlPool select posts %w", err)
 		}
-		row.CreatedAt, row.UpdatedAt = t.parseTime()
 
-		output = append(output, &row)
+		output = append(output, row)
 	}
 
 	return output, nil
 }
 
 func (p PsqlPool) GetPostByID(ctx context.Context, id string) (*model.Post, error) {
-	t := times{}
 
-	var output model.Post
-
-	query := "SELECT * FROM posts WHERE id = $1"
+	query := "SELECT " + postColumns + " FROM posts WHERE id = $1"
 
-	err := p.Pool.QueryRow(ctx, query, id).Scan(&output.ID, &output.AuthorID, &output.Content, &output.AreCommentsAllowed, &t.CreatedAt, &t.UpdatedAt)
+	output, err := scanPost(p.Pool.QueryRow(ctx, query, id))
 	switch {
 	case errors.Is(err, nil):
 	case errors.Is(err, pgx.ErrNoRows):
@@ -207,67 +503,613 @@ func (p PsqlPool) GetPostByID(ctx context.Context, id string) (*model.Post, erro
 	default:
 		return nil, fmt.Errorf("PsqlPool select post %w", err)
 	}
-	output.CreatedAt, output.UpdatedAt = t.parseTime()
 
-	return &output, nil
+	return output, nil
 }
 
-func (p PsqlPool) GetCommentByPostID(ctx context.Context, postID string, first int32) ([]*model.Comment, error) {
-	t := times{}
+func (p PsqlPool) GetCommentByPostID(ctx context.Context, postID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
 
 	var output []*model.Comment
 
-	query := "SELECT * FROM comments WHERE post_id = $1 ORDER BY created_at DESC LIMIT 10 OFFSET $2"
+	order, ok := commentOrders[orderBy]
+	if !ok {
+		return nil, fmt.Errorf("PsqlPool select comment by postID: unknown order %q", orderBy)
+	}
 
-	rows, err := p.Pool.Query(ctx, query, postID, first)
-	defer rows.Close()
+	query := "SELECT " + commentColumns + " FROM comments WHERE post_id = $1 AND parent_comment_id IS NULL " +
+		"ORDER BY pinned_at DESC NULLS LAST, " + order + " LIMIT 10 OFFSET $2"
 
+	rows, err := p.Pool.Query(ctx, query, postID, first)
 	if err != nil {
 		return nil, fmt.Errorf("PsqlPool select comment by postID %w", err)
 	}
-
-	var row model.Comment
+	defer rows.Close()
 
 	for rows.Next() {
 
-		err = rows.Scan(&row.ID, &row.PostID, &row.ParentCommentID, &row.AuthorID, &row.Content, &t.CreatedAt, &t.UpdatedAt)
+		row, err := scanComment(rows)
 		if err != nil {
 			return nil, fmt.Errorf("PsqlPool select comment by postID %w", err)
 		}
-		row.CreatedAt, row.UpdatedAt = t.parseTime()
 
-		output = append(output, &row)
+		output = append(output, row)
 	}
 
 	return output, nil
 }
 
-func (p PsqlPool) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32) ([]*model.Comment, error) {
-	t := times{}
+func (p PsqlPool) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
 
 	var output []*model.Comment
 
-	query := "SELECT * FROM comments WHERE parent_comment_id = $1 ORDER BY created_at DESC LIMIT 10 OFFSET $2"
+	order, ok := commentOrders[orderBy]
+	if !ok {
+		return nil, fmt.Errorf("PsqlPool select comment by parentID: unknown order %q", orderBy)
+	}
+
+	query := "SELECT " + commentColumns + " FROM comments WHERE parent_comment_id = $1 ORDER BY " + order + " LIMIT 10 OFFSET $2"
 
 	rows, err := p.Pool.Query(ctx, query, parentCommentID, first)
-	defer rows.Close()
-
 	if err != nil {
 		return nil, fmt.Errorf("PsqlPool select comment by parentID %w", err)
 	}
-
-	var row model.Comment
+	defer rows.Close()
 
 	for rows.Next() {
 
-		err = rows.Scan(&row.ID, &row.PostID, &row.ParentCommentID, &row.AuthorID, &row.Content, &t.CreatedAt, &t.UpdatedAt)
+		row, err := scanComment(rows)
 		if err != nil {
 			return nil, fmt.Errorf("PsqlPool select comment by parentID %w", err)
 		}
-		row.CreatedAt, row.UpdatedAt = t.parseTime()
+
+		output = append(output, row)
+	}
+
+	return output, nil
+}
+
+func (p PsqlPool) GetPostByTag(ctx context.Context, tag string, first int32, after *string) ([]*model.Post, error) {
+
+	var output []*model.Post
+
+	query := "SELECT " + postColumns + " FROM posts JOIN post_tags t ON t.post_id = posts.id " +
+		"WHERE t.tag = $1 AND posts.status = 'PUBLISHED' AND ($3::int IS NULL OR (t.created_at, t.post_id) < " +
+		"(SELECT created_at, post_id FROM post_tags WHERE tag = $1 AND post_id = $3)) " +
+		"ORDER BY t.created_at DESC, t.post_id DESC LIMIT $2"
+
+	rows, err := p.Pool.Query(ctx, query, tag, first, after)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool select posts by tag %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		row, err := scanPost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("PsqlPool select posts by tag %w", err)
+		}
+
+		output = append(output, row)
+	}
+
+	return output, nil
+}
+
+func (p PsqlPool) GetTrendingTags(ctx context.Context, since time.Time, limit int32) ([]*model.TagCount, error) {
+
+	var output []*model.TagCount
+
+	query := "SELECT t.tag, COUNT(*) FROM post_tags t JOIN posts ON posts.id = t.post_id " +
+		"WHERE t.created_at >= $1 AND posts.status = 'PUBLISHED' GROUP BY t.tag ORDER BY COUNT(*) DESC, t.tag LIMIT $2"
+
+	rows, err := p.Pool.Query(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool select trending tags %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var row model.TagCount
+
+		if err = rows.Scan(&row.Tag, &row.Count); err != nil {
+			return nil, fmt.Errorf("PsqlPool select trending tags %w", err)
+		}
 
 		output = append(output, &row)
 	}
 
 	return output, nil
 }
+
+func (p PsqlPool) GetDrafts(ctx context.Context, authorID string, first int32) ([]*model.Post, error) {
+
+	var output []*model.Post
+
+	query := "SELECT " + postColumns + " FROM posts WHERE author_id = $1 AND status <> 'PUBLISHED' ORDER BY created_at DESC LIMIT $2"
+
+	rows, err := p.Pool.Query(ctx, query, authorID, first)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool select drafts %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		row, err := scanPost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("PsqlPool select drafts %w", err)
+		}
+
+		output = append(output, row)
+	}
+
+	return output, nil
+}
+
+func (p PsqlPool) PublishScheduledPosts(ctx context.Context, now time.Time) ([]*model.Post, error) {
+
+	var output []*model.Post
+
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool publish scheduled posts %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := "UPDATE posts SET status = 'PUBLISHED' WHERE status = 'SCHEDULED' AND publish_at <= $1 RETURNING " + postColumns
+
+	rows, err := tx.Query(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool publish scheduled posts %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		row, err := scanPost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("PsqlPool publish scheduled posts %w", err)
+		}
+
+		output = append(output, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("PsqlPool publish scheduled posts %w", err)
+	}
+
+	for _, post := range output {
+		if err = writeEvent(ctx, tx, outbox.PostPublished, post.ID, post); err != nil {
+			return nil, fmt.Errorf("PsqlPool publish scheduled posts %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("PsqlPool publish scheduled posts %w", err)
+	}
+
+	return output, nil
+}
+
+func (p PsqlPool) GetCommentByID(ctx context.Context, id string) (*model.Comment, error) {
+
+	query := "SELECT " + commentColumns + " FROM comments WHERE id = $1"
+
+	output, err := scanComment(p.Pool.QueryRow(ctx, query, id))
+	switch {
+	case errors.Is(err, nil):
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("PsqlPool select comment %w", err)
+	}
+
+	return output, nil
+}
+
+func (p PsqlPool) SetPostPinned(ctx context.Context, id string, pinned bool) (*model.Post, error) {
+
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool pin post %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := "UPDATE posts SET pinned_at = CASE WHEN $2 THEN COALESCE(pinned_at, NOW()) END WHERE id = $1 RETURNING " + postColumns
+
+	output, err := scanPost(tx.QueryRow(ctx, query, id, pinned))
+
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool pin post %w", err)
+	}
+
+	if err = writeEvent(ctx, tx, outbox.PostUpdated, output.ID, output); err != nil {
+		return nil, fmt.Errorf("PsqlPool pin post %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("PsqlPool pin post %w", err)
+	}
+
+	return output, nil
+}
+
+// SetCommentPinned pins a comment, unpinning the comment previously pinned in the same post.
+func (p PsqlPool) SetCommentPinned(ctx context.Context, id string, pinned bool) (*model.Comment, error) {
+
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool pin comment %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if pinned {
+		query := "UPDATE comments SET pinned_at = NULL WHERE post_id = (SELECT post_id FROM comments WHERE id = $1) AND id <> $1 AND pinned_at IS NOT NULL"
+
+		if _, err = tx.Exec(ctx, query, id); err != nil {
+			return nil, fmt.Errorf("PsqlPool pin comment %w", err)
+		}
+	}
+
+	query := "UPDATE comments SET pinned_at = CASE WHEN $2 THEN COALESCE(pinned_at, NOW()) END WHERE id = $1 RETURNING " + commentColumns
+
+	output, err := scanComment(tx.QueryRow(ctx, query, id, pinned))
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool pin comment %w", err)
+	}
+
+	if err = writeEvent(ctx, tx, outbox.CommentUpdated, output.ID, output); err != nil {
+		return nil, fmt.Errorf("PsqlPool pin comment %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("PsqlPool pin comment %w", err)
+	}
+
+	return output, nil
+}
+
+func (p PsqlPool) SetCommentLocked(ctx context.Context, id string, locked bool) (*model.Comment, error) {
+
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool lock comment %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := "UPDATE comments SET is_locked = $2 WHERE id = $1"
+
+	tag, err := tx.Exec(ctx, query, id, locked)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool lock comment %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("PsqlPool lock comment %w", pgx.ErrNoRows)
+	}
+
+	// Selected separately: RETURNING would see the lock state before the update.
+	query = "SELECT " + commentColumns + " FROM comments WHERE id = $1"
+
+	output, err := scanComment(tx.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool lock comment %w", err)
+	}
+
+	if err = writeEvent(ctx, tx, outbox.CommentUpdated, output.ID, output); err != nil {
+		return nil, fmt.Errorf("PsqlPool lock comment %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("PsqlPool lock comment %w", err)
+	}
+
+	return output, nil
+}
+
+// RecountComments recomputes the counters maintained by the comments triggers.
+func (p PsqlPool) RecountComments(ctx context.Context) (int64, error) {
+
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("PsqlPool recount comments %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := "UPDATE posts SET comment_count = c.count FROM " +
+		"(SELECT posts.id, COUNT(comments.id) AS count FROM posts LEFT JOIN comments ON comments.post_id = posts.id GROUP BY posts.id) c " +
+		"WHERE posts.id = c.id AND posts.comment_count <> c.count"
+
+	posts, err := tx.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("PsqlPool recount comments %w", err)
+	}
+
+	query = "UPDATE comments SET reply_count = c.count FROM " +
+		"(SELECT parent.id, COUNT(reply.id) AS count FROM comments parent LEFT JOIN comments reply ON reply.parent_comment_id = parent.id GROUP BY parent.id) c " +
+		"WHERE comments.id = c.id AND comments.reply_count <> c.count"
+
+	comments, err := tx.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("PsqlPool recount comments %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("PsqlPool recount comments %w", err)
+	}
+
+	return posts.RowsAffected() + comments.RowsAffected(), nil
+}
+
+// GetIdempotencyKey returns the ID remembered under the key, or "" if the key is unknown or expired.
+func (p PsqlPool) GetIdempotencyKey(ctx context.Context, scope, key string) (string, error) {
+
+	var id string
+
+	query := "SELECT result_id FROM idempotency_keys WHERE scope = $1 AND key = $2 AND expires_at > NOW()"
+
+	err := p.Pool.QueryRow(ctx, query, scope, key).Scan(&id)
+
+	switch {
+	case errors.Is(err, nil):
+	case errors.Is(err, pgx.ErrNoRows):
+		return "", nil
+	default:
+		return "", fmt.Errorf("PsqlPool select idempotency key %w", err)
+	}
+
+	return id, nil
+}
+
+// claimIdempotencyKey returns the ID stored under the key in tx, or ""
+// after claiming the key for the result of tx. A concurrent claim of the
+// key waits for tx to end.
+func claimIdempotencyKey(ctx context.Context, tx pgx.Tx, scope, key string, expiresAt time.Time) (string, error) {
+
+	var id string
+
+	query := "INSERT INTO idempotency_keys (scope, key, result_id, expires_at) VALUES ($1, $2, 0, $3) " +
+		"ON CONFLICT (scope, key) DO UPDATE SET result_id = 0, created_at = NOW(), expires_at = EXCLUDED.expires_at " +
+		"WHERE idempotency_keys.expires_at <= NOW() RETURNING result_id"
+
+	err := tx.QueryRow(ctx, query, scope, key, expiresAt).Scan(&id)
+	switch {
+	case errors.Is(err, nil):
+		return "", nil
+	case errors.Is(err, pgx.ErrNoRows):
+	default:
+		return "", fmt.Errorf("PsqlPool insert idempotency key %w", err)
+	}
+
+	query = "SELECT result_id FROM idempotency_keys WHERE scope = $1 AND key = $2"
+
+	if err = tx.QueryRow(ctx, query, scope, key).Scan(&id); err != nil {
+		return "", fmt.Errorf("PsqlPool select idempotency key %w", err)
+	}
+
+	return id, nil
+}
+
+// storeIdempotencyResult stores the result under a key claimed in tx.
+func storeIdempotencyResult(ctx context.Context, tx pgx.Tx, scope, key, resultID string) error {
+
+	query := "UPDATE idempotency_keys SET result_id = $3 WHERE scope = $1 AND key = $2"
+
+	if _, err := tx.Exec(ctx, query, scope, key, resultID); err != nil {
+		return fmt.Errorf("PsqlPool update idempotency key %w", err)
+	}
+
+	return nil
+}
+
+func (p PsqlPool) PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+
+	query := "DELETE FROM idempotency_keys WHERE expires_at <= $1"
+
+	tag, err := p.Pool.Exec(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("PsqlPool delete idempotency keys %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// FetchOutbox returns the oldest undelivered events in the order they were written.
+func (p PsqlPool) FetchOutbox(ctx context.Context, limit int32) ([]outbox.Event, error) {
+
+	var output []outbox.Event
+
+	query := "SELECT id, event_type, aggregate_id, payload, created_at FROM outbox ORDER BY id LIMIT $1"
+
+	rows, err := p.Pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool select outbox %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var event outbox.Event
+
+		if err = rows.Scan(&event.ID, &event.Type, &event.AggregateID, &event.Payload, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("PsqlPool select outbox %w", err)
+		}
+
+		output = append(output, event)
+	}
+
+	return output, rows.Err()
+}
+
+func (p PsqlPool) AckOutbox(ctx context.Context, id int64) error {
+
+	query := "DELETE FROM outbox WHERE id = $1"
+
+	if _, err := p.Pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("PsqlPool delete outbox %w", err)
+	}
+
+	return nil
+}
+
+func scanWebhook(row pgx.Row) (*model.Webhook, error) {
+	var output model.Webhook
+	var events []string
+
+	if err := row.Scan(&output.ID, &output.URL, &events, &output.CreatedAt); err != nil {
+		return nil, err
+	}
+	output.Events = webhookEventsFromStrings(events)
+
+	return &output, nil
+}
+
+func (p PsqlPool) CreateWebhook(ctx context.Context, input model.CreateWebhookInput) (*model.Webhook, error) {
+
+	query := "INSERT INTO webhooks (url, secret, events) VALUES ($1, $2, $3) RETURNING id, url, events, created_at"
+
+	output, err := scanWebhook(p.Pool.QueryRow(ctx, query, input.URL, input.Secret, webhookEventsToStrings(input.Events)))
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool insert webhook %w", err)
+	}
+
+	return output, nil
+}
+
+func (p PsqlPool) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+
+	query := "DELETE FROM webhooks WHERE id = $1"
+
+	tag, err := p.Pool.Exec(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("PsqlPool delete webhook %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (p PsqlPool) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+
+	var output []*model.Webhook
+
+	query := "SELECT id, url, events, created_at FROM webhooks ORDER BY id"
+
+	rows, err := p.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool select webhooks %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		row, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("PsqlPool select webhooks %w", err)
+		}
+
+		output = append(output, row)
+	}
+
+	return output, rows.Err()
+}
+
+// GetWebhookDeliveries returns the latest deliveries of a webhook, newest first.
+func (p PsqlPool) GetWebhookDeliveries(ctx context.Context, webhookID string, first int32, status *model.DeliveryStatus) ([]*model.WebhookDelivery, error) {
+
+	var output []*model.WebhookDelivery
+
+	query := "SELECT id, webhook_id, event, status, attempts, response_code, last_error, " +
+		"CASE WHEN status = 'PENDING' THEN next_attempt_at END, created_at, delivered_at " +
+		"FROM webhook_deliveries WHERE webhook_id = $1 AND ($2::text IS NULL OR status = $2) ORDER BY id DESC LIMIT $3"
+
+	rows, err := p.Pool.Query(ctx, query, webhookID, status, first)
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool select webhook deliveries %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var row model.WebhookDelivery
+
+		err = rows.Scan(&row.ID, &row.WebhookID, &row.Event, &row.Status, &row.Attempts, &row.ResponseCode, &row.LastError, &row.NextAttemptAt, &row.CreatedAt, &row.DeliveredAt)
+		if err != nil {
+			return nil, fmt.Errorf("PsqlPool select webhook deliveries %w", err)
+		}
+
+		output = append(output, &row)
+	}
+
+	return output, rows.Err()
+}
+
+// EnqueueWebhookDeliveries creates a delivery for every webhook subscribed to the event.
+func (p PsqlPool) EnqueueWebhookDeliveries(ctx context.Context, eventID int64, event model.WebhookEvent, payload json.RawMessage) (int64, error) {
+
+	query := "INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload) SELECT id, $1, $2, $3 FROM webhooks WHERE $2 = ANY(events)"
+
+	tag, err := p.Pool.Exec(ctx, query, eventID, string(event), payload)
+	if err != nil {
+		return 0, fmt.Errorf("PsqlPool insert webhook deliveries %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// FetchWebhookDeliveries leases the pending deliveries that are due, so that
+// concurrent workers do not send them twice.
+func (p PsqlPool) FetchWebhookDeliveries(ctx context.Context, now time.Time, limit int32) ([]webhook.Delivery, error) {
+
+	var output []webhook.Delivery
+
+	query := "UPDATE webhook_deliveries d SET next_attempt_at = $3 FROM webhooks w WHERE d.webhook_id = w.id AND d.id IN " +
+		"(SELECT id FROM webhook_deliveries WHERE status = 'PENDING' AND next_attempt_at <= $1 ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED) " +
+		"RETURNING d.id, d.event_id, d.webhook_id, w.url, w.secret, d.event, d.payload, d.attempts, d.created_at"
+
+	rows, err := p.Pool.Query(ctx, query, now, limit, now.Add(WebhookLease))
+	if err != nil {
+		return nil, fmt.Errorf("PsqlPool lease webhook deliveries %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var row webhook.Delivery
+
+		err = rows.Scan(&row.ID, &row.EventID, &row.WebhookID, &row.URL, &row.Secret, &row.Event, &row.Payload, &row.Attempts, &row.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("PsqlPool lease webhook deliveries %w", err)
+		}
+
+		output = append(output, row)
+	}
+
+	return output, rows.Err()
+}
+
+func (p PsqlPool) RecordWebhookAttempt(ctx context.Context, attempt webhook.Attempt) error {
+
+	query := "UPDATE webhook_deliveries SET status = $2, attempts = $3, response_code = $4, last_error = $5, " +
+		"next_attempt_at = COALESCE($6, next_attempt_at), delivered_at = CASE WHEN $2 = 'SUCCEEDED' THEN NOW() END WHERE id = $1"
+
+	_, err := p.Pool.Exec(ctx, query, attempt.DeliveryID, string(attempt.Status), attempt.Attempts, attempt.ResponseCode, attempt.Error, attempt.NextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("PsqlPool update webhook delivery %w", err)
+	}
+
+	return nil
+}
+
+// HealthCheck reports whether the database can be reached.
+func (p PsqlPool) HealthCheck(ctx context.Context) error {
+	if err := p.Pool.Ping(ctx); err != nil {
+		return fmt.Errorf("PsqlPool HealthCheck %w", err)
+	}
+
+	return nil
+}
+
+// Close waits for the acquired connections to be released and closes the pool.
+func (p PsqlPool) Close() {
+	p.Pool.Close()
+}
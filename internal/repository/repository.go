@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"ozon/internal/outbox"
+	"ozon/internal/transport/graph/model"
+	"ozon/internal/webhook"
+)
+
+// Repository is the storage the app runs on. Its implementations must pass
+// the conformance suite in repositorytest.
+type Repository interface {
+	CreatePost(ctx context.Context, input model.CreatePostInput) (*model.Post, error)
+	PostComment(ctx context.Context, input model.PostCommentInput) (*model.Comment, error)
+	PutPost(ctx context.Context, input model.PutPostInput) (*model.Post, error)
+	PutComment(ctx context.Context, input model.PutCommentInput) (*model.Comment, error)
+	DeletePost(ctx context.Context, id string) (bool, error)
+	DeleteComment(ctx context.Context, id string) (bool, error)
+	GetPost(ctx context.Context, first int32) ([]*model.Post, error)
+	GetPostByID(ctx context.Context, id string) (*model.Post, error)
+	GetCommentByPostID(ctx context.Context, postID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error)
+	GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error)
+	GetPostByTag(ctx context.Context, tag string, first int32, after *string) ([]*model.Post, error)
+	GetTrendingTags(ctx context.Context, since time.Time, limit int32) ([]*model.TagCount, error)
+	GetDrafts(ctx context.Context, authorID string, first int32) ([]*model.Post, error)
+	PublishScheduledPosts(ctx context.Context, now time.Time) ([]*model.Post, error)
+	GetCommentByID(ctx context.Context, id string) (*model.Comment, error)
+	SetPostPinned(ctx context.Context, id string, pinned bool) (*model.Post, error)
+	SetCommentPinned(ctx context.Context, id string, pinned bool) (*model.Comment, error)
+	SetCommentLocked(ctx context.Context, id string, locked bool) (*model.Comment, error)
+	RecountComments(ctx context.Context) (int64, error)
+	GetIdempotencyKey(ctx context.Context, scope, key string) (string, error)
+	CreatePostOnce(ctx context.Context, input model.CreatePostInput, scope, key string, expiresAt time.Time) (*model.Post, bool, error)
+	PostCommentOnce(ctx context.Context, input model.PostCommentInput, scope, key string, expiresAt time.Time) (*model.Comment, bool, error)
+	PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+	FetchOutbox(ctx context.Context, limit int32) ([]outbox.Event, error)
+	AckOutbox(ctx context.Context, id int64) error
+	CreateWebhook(ctx context.Context, input model.CreateWebhookInput) (*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) (bool, error)
+	GetWebhooks(ctx context.Context) ([]*model.Webhook, error)
+	GetWebhookDeliveries(ctx context.Context, webhookID string, first int32, status *model.DeliveryStatus) ([]*model.WebhookDelivery, error)
+	EnqueueWebhookDeliveries(ctx context.Context, eventID int64, event model.WebhookEvent, payload json.RawMessage) (int64, error)
+	FetchWebhookDeliveries(ctx context.Context, now time.Time, limit int32) ([]webhook.Delivery, error)
+	RecordWebhookAttempt(ctx context.Context, attempt webhook.Attempt) error
+	HealthCheck(ctx context.Context) error
+	Close()
+}
+
+var (
+	_ Repository = InMemoryRepo{}
+	_ Repository = PsqlPool{}
+)
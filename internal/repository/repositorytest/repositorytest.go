@@ -0,0 +1,769 @@
+// Package repositorytest is the conformance suite of repository.Repository.
+// Every implementation must pass it, so that the app behaves the same on
+// any storage: ordering, pagination, missing entities and cascades.
+package repositorytest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"ozon/internal/outbox"
+	"ozon/internal/repository"
+	"ozon/internal/service"
+	"ozon/internal/transport/graph/model"
+	"ozon/internal/webhook"
+)
+
+// missingID is an ID no test creates. It is numeric, PsqlPool IDs are.
+const missingID = "2147483647"
+
+func postContents(posts []*model.Post) []string {
+	output := make([]string, 0, len(posts))
+	for _, post := range posts {
+		output = append(output, post.Content)
+	}
+
+	return output
+}
+
+func commentContents(comments []*model.Comment) []string {
+	output := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		output = append(output, comment.Content)
+	}
+
+	return output
+}
+
+// Run runs the conformance suite against the repositories newRepo returns.
+// Every test gets an empty repository.
+func Run(t *testing.T, newRepo func(t *testing.T) repository.Repository) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo repository.Repository)
+	}{
+		{name: "posts", run: testPosts},
+		{name: "post pages", run: testPostPages},
+		{name: "pinned posts", run: testPinnedPosts},
+		{name: "drafts", run: testDrafts},
+		{name: "comments", run: testComments},
+		{name: "comment orders", run: testCommentOrders},
+		{name: "comment threads", run: testCommentThreads},
+		{name: "tags", run: testTags},
+		{name: "idempotency keys", run: testIdempotencyKeys},
+		{name: "outbox", run: testOutbox},
+		{name: "webhooks", run: testWebhooks},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+func createPost(t *testing.T, repo repository.Repository, input model.CreatePostInput) *model.Post {
+	t.Helper()
+
+	if input.AuthorID == "" {
+		input.AuthorID = "1"
+	}
+	input.AreCommentsAllowed = true
+
+	post, err := repo.CreatePost(context.Background(), input)
+	require.NoError(t, err)
+
+	return post
+}
+
+func postComment(t *testing.T, repo repository.Repository, post *model.Post, parent *model.Comment, content string) *model.Comment {
+	t.Helper()
+
+	input := model.PostCommentInput{PostID: post.ID, AuthorID: "2", Content: content}
+	if parent != nil {
+		input.ParentCommentID = &parent.ID
+	}
+
+	comment, err := repo.PostComment(context.Background(), input)
+	require.NoError(t, err)
+
+	return comment
+}
+
+func getPost(t *testing.T, repo repository.Repository, id string) *model.Post {
+	t.Helper()
+
+	post, err := repo.GetPostByID(context.Background(), id)
+	require.NoError(t, err)
+	require.NotNil(t, post)
+
+	return post
+}
+
+func getComment(t *testing.T, repo repository.Repository, id string) *model.Comment {
+	t.Helper()
+
+	comment, err := repo.GetCommentByID(context.Background(), id)
+	require.NoError(t, err)
+	require.NotNil(t, comment)
+
+	return comment
+}
+
+func testPosts(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	post := createPost(t, repo, model.CreatePostInput{Content: "post", Tags: []string{"go", "db"}})
+	assert.NotEmpty(t, post.ID)
+	assert.Equal(t, model.PostStatusPublished, post.Status)
+	assert.EqualValues(t, 1, post.Version)
+	assert.Equal(t, []string{"db", "go"}, post.Tags)
+
+	got := getPost(t, repo, post.ID)
+	assert.Equal(t, "post", got.Content)
+	assert.Equal(t, "1", got.AuthorID)
+	assert.True(t, got.AreCommentsAllowed)
+	assert.True(t, got.CreatedAt.Equal(post.CreatedAt))
+	assert.Empty(t, got.Comments)
+
+	missing, err := repo.GetPostByID(ctx, missingID)
+	require.NoError(t, err)
+	assert.Nil(t, missing, "a missing post is nil, not an error")
+
+	content, allowed := "edited", false
+	updated, err := repo.PutPost(ctx, model.PutPostInput{ID: post.ID, Content: &content, AreCommentsAllowed: &allowed})
+	require.NoError(t, err)
+	assert.Equal(t, "edited", updated.Content)
+	assert.False(t, updated.AreCommentsAllowed)
+	assert.EqualValues(t, 2, updated.Version)
+	assert.NotNil(t, updated.UpdatedAt)
+	assert.Equal(t, []string{"db", "go"}, updated.Tags, "tags are kept unless given")
+
+	got = getPost(t, repo, post.ID)
+	assert.Equal(t, "edited", got.Content, "the update is stored")
+	assert.EqualValues(t, 2, got.Version)
+
+	stale := int32(1)
+	_, err = repo.PutPost(ctx, model.PutPostInput{ID: post.ID, Content: &content, ExpectedVersion: &stale})
+	var conflict *model.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.EqualValues(t, 2, conflict.Current)
+
+	_, err = repo.PutPost(ctx, model.PutPostInput{ID: missingID, Content: &content})
+	assert.ErrorIs(t, err, service.ErrPostNotFound)
+
+	deleted, err := repo.DeletePost(ctx, post.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	missing, err = repo.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	deleted, err = repo.DeletePost(ctx, post.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted, "deleting a missing post is not an error")
+
+	assert.NoError(t, repo.HealthCheck(ctx))
+}
+
+func testPostPages(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	var want []string
+	for n := range 12 {
+		post := createPost(t, repo, model.CreatePostInput{Content: string(rune('a' + n))})
+		want = append([]string{post.Content}, want...)
+	}
+	draft := model.PostStatusDraft
+	createPost(t, repo, model.CreatePostInput{Content: "draft", Status: &draft})
+
+	got, err := repo.GetPost(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, want[:10], postContents(got), "published posts, newest first, a page of 10")
+
+	got, err = repo.GetPost(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, want[10:], postContents(got))
+
+	got, err = repo.GetPost(ctx, 12)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func testPinnedPosts(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	a := createPost(t, repo, model.CreatePostInput{Content: "a"})
+	b := createPost(t, repo, model.CreatePostInput{Content: "b"})
+	createPost(t, repo, model.CreatePostInput{Content: "c"})
+
+	pinned, err := repo.SetPostPinned(ctx, a.ID, true)
+	require.NoError(t, err)
+	assert.True(t, pinned.IsPinned)
+	assert.True(t, getPost(t, repo, a.ID).IsPinned)
+
+	_, err = repo.SetPostPinned(ctx, b.ID, true)
+	require.NoError(t, err)
+
+	got, err := repo.GetPost(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a", "c"}, postContents(got), "pinned posts first, most recently pinned first")
+
+	// Pinning a pinned post keeps its place.
+	_, err = repo.SetPostPinned(ctx, a.ID, true)
+	require.NoError(t, err)
+
+	got, err = repo.GetPost(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, postContents(got))
+
+	unpinned, err := repo.SetPostPinned(ctx, b.ID, false)
+	require.NoError(t, err)
+	assert.False(t, unpinned.IsPinned)
+
+	got, err = repo.GetPost(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c", "b"}, postContents(got))
+
+	_, err = repo.SetPostPinned(ctx, missingID, true)
+	assert.Error(t, err)
+}
+
+func testDrafts(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	draft, scheduled := model.PostStatusDraft, model.PostStatusScheduled
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	createPost(t, repo, model.CreatePostInput{Content: "draft", Status: &draft})
+	due := createPost(t, repo, model.CreatePostInput{Content: "due", Status: &scheduled, PublishAt: &past})
+	createPost(t, repo, model.CreatePostInput{Content: "later", Status: &scheduled, PublishAt: &future})
+	createPost(t, repo, model.CreatePostInput{AuthorID: "2", Content: "other", Status: &draft})
+	createPost(t, repo, model.CreatePostInput{Content: "published"})
+
+	got, err := repo.GetDrafts(ctx, "1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"later", "due", "draft"}, postContents(got), "unpublished posts of the author, newest first")
+
+	got, err = repo.GetDrafts(ctx, "1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"later"}, postContents(got))
+
+	got, err = repo.GetPost(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"published"}, postContents(got))
+
+	published, err := repo.PublishScheduledPosts(ctx, time.Now())
+	require.NoError(t, err)
+	require.Len(t, published, 1)
+	assert.Equal(t, due.ID, published[0].ID)
+	assert.Equal(t, model.PostStatusPublished, published[0].Status)
+
+	got, err = repo.GetDrafts(ctx, "1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"later", "draft"}, postContents(got))
+
+	got, err = repo.GetPost(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"published", "due"}, postContents(got))
+
+	status := model.PostStatusPublished
+	_, err = repo.PutPost(ctx, model.PutPostInput{ID: due.ID, Status: &status})
+	require.NoError(t, err)
+
+	published, err = repo.PublishScheduledPosts(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, published)
+}
+
+func testComments(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	post := createPost(t, repo, model.CreatePostInput{Content: "post"})
+	comment := postComment(t, repo, post, nil, "comment")
+	assert.NotEmpty(t, comment.ID)
+	assert.Equal(t, post.ID, comment.PostID)
+	assert.Nil(t, comment.ParentCommentID)
+	assert.EqualValues(t, 1, comment.Version)
+
+	reply := postComment(t, repo, post, comment, "reply")
+	require.NotNil(t, reply.ParentCommentID)
+	assert.Equal(t, comment.ID, *reply.ParentCommentID)
+
+	got := getComment(t, repo, comment.ID)
+	assert.Equal(t, "comment", got.Content)
+	assert.Equal(t, "2", got.AuthorID)
+	assert.EqualValues(t, 1, got.ReplyCount)
+	assert.Empty(t, got.Replies)
+	assert.EqualValues(t, 2, getPost(t, repo, post.ID).CommentCount)
+
+	missing, err := repo.GetCommentByID(ctx, missingID)
+	require.NoError(t, err)
+	assert.Nil(t, missing, "a missing comment is nil, not an error")
+
+	_, err = repo.PostComment(ctx, model.PostCommentInput{PostID: missingID, AuthorID: "2", Content: "orphan"})
+	assert.Error(t, err, "the post must exist")
+
+	parentID := missingID
+	_, err = repo.PostComment(ctx, model.PostCommentInput{PostID: post.ID, ParentCommentID: &parentID, AuthorID: "2", Content: "orphan"})
+	assert.Error(t, err, "the parent must exist")
+
+	updated, err := repo.PutComment(ctx, model.PutCommentInput{ID: reply.ID, Content: "edited"})
+	require.NoError(t, err)
+	assert.Equal(t, "edited", updated.Content)
+	assert.EqualValues(t, 2, updated.Version)
+	assert.NotNil(t, updated.UpdatedAt)
+	assert.Equal(t, "edited", getComment(t, repo, reply.ID).Content, "the update is stored")
+
+	stale := int32(1)
+	_, err = repo.PutComment(ctx, model.PutCommentInput{ID: reply.ID, Content: "stale", ExpectedVersion: &stale})
+	var conflict *model.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.EqualValues(t, 2, conflict.Current)
+
+	_, err = repo.PutComment(ctx, model.PutCommentInput{ID: missingID, Content: "missing"})
+	assert.Error(t, err)
+
+	fixed, err := repo.RecountComments(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, fixed, "the counters are kept up to date")
+
+	deleted, err := repo.DeletePost(ctx, post.ID)
+	require.NoError(t, err)
+	require.True(t, deleted)
+
+	missing, err = repo.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	assert.Nil(t, missing, "comments are deleted with the post")
+
+	missing, err = repo.GetCommentByID(ctx, reply.ID)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func testCommentOrders(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	post := createPost(t, repo, model.CreatePostInput{Content: "post"})
+	a := postComment(t, repo, post, nil, "a")
+	b := postComment(t, repo, post, nil, "b")
+	c := postComment(t, repo, post, nil, "c")
+	reply := postComment(t, repo, post, a, "reply")
+	postComment(t, repo, post, reply, "nested")
+	postComment(t, repo, post, b, "other")
+
+	tests := []struct {
+		name   string
+		order  model.CommentOrder
+		first  int32
+		pinned *model.Comment
+		want   []string
+	}{
+		{name: "newest", order: model.CommentOrderNewest, want: []string{"c", "b", "a"}},
+		{name: "oldest", order: model.CommentOrderOldest, want: []string{"a", "b", "c"}},
+		{name: "top", order: model.CommentOrderTop, want: []string{"a", "b", "c"}},
+		{name: "most replies", order: model.CommentOrderMostReplies, want: []string{"b", "a", "c"}},
+		{name: "offset", order: model.CommentOrderNewest, first: 1, want: []string{"b", "a"}},
+		{name: "pinned first", order: model.CommentOrderOldest, pinned: c, want: []string{"c", "a", "b"}},
+		{name: "pinned with offset", order: model.CommentOrderOldest, first: 1, pinned: c, want: []string{"a", "b"}},
+		{name: "pinned reply", order: model.CommentOrderNewest, pinned: reply, want: []string{"c", "b", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.pinned != nil {
+				_, err := repo.SetCommentPinned(ctx, tt.pinned.ID, true)
+				require.NoError(t, err)
+				defer func() {
+					_, err := repo.SetCommentPinned(ctx, tt.pinned.ID, false)
+					require.NoError(t, err)
+				}()
+			}
+
+			got, err := repo.GetCommentByPostID(ctx, post.ID, tt.first, tt.order)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, commentContents(got))
+		})
+	}
+
+	var want []string
+	for n := range 11 {
+		added := postComment(t, repo, post, b, string(rune('a'+n)))
+		want = append(want, added.Content)
+	}
+	want = append([]string{"other"}, want...)
+
+	got, err := repo.GetCommentByParentCommentID(ctx, b.ID, 0, model.CommentOrderOldest)
+	require.NoError(t, err)
+	assert.Equal(t, want[:10], commentContents(got), "direct replies, a page of 10")
+
+	got, err = repo.GetCommentByParentCommentID(ctx, b.ID, 10, model.CommentOrderOldest)
+	require.NoError(t, err)
+	assert.Equal(t, want[10:], commentContents(got))
+
+	got, err = repo.GetCommentByParentCommentID(ctx, a.ID, 0, model.CommentOrderNewest)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reply"}, commentContents(got), "replies of replies are not listed")
+
+	got, err = repo.GetCommentByParentCommentID(ctx, missingID, 0, model.CommentOrderNewest)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	got, err = repo.GetCommentByPostID(ctx, missingID, 0, model.CommentOrderNewest)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = repo.GetCommentByPostID(ctx, post.ID, 0, model.CommentOrder("RANDOM"))
+	assert.Error(t, err)
+}
+
+func testCommentThreads(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	post := createPost(t, repo, model.CreatePostInput{Content: "post"})
+	a := postComment(t, repo, post, nil, "a")
+	b := postComment(t, repo, post, nil, "b")
+	reply := postComment(t, repo, post, a, "reply")
+	nested := postComment(t, repo, post, reply, "nested")
+
+	pinned, err := repo.SetCommentPinned(ctx, a.ID, true)
+	require.NoError(t, err)
+	assert.True(t, pinned.IsPinned)
+
+	_, err = repo.SetCommentPinned(ctx, b.ID, true)
+	require.NoError(t, err)
+	assert.False(t, getComment(t, repo, a.ID).IsPinned, "pinning a comment unpins the other one of the post")
+	assert.True(t, getComment(t, repo, b.ID).IsPinned)
+
+	_, err = repo.SetCommentPinned(ctx, missingID, true)
+	assert.Error(t, err)
+
+	locked, err := repo.SetCommentLocked(ctx, reply.ID, true)
+	require.NoError(t, err)
+	assert.True(t, locked.IsLocked)
+	assert.True(t, getComment(t, repo, nested.ID).IsLocked, "the lock applies to the whole sub-thread")
+	assert.False(t, getComment(t, repo, a.ID).IsLocked)
+
+	_, err = repo.SetCommentLocked(ctx, a.ID, true)
+	require.NoError(t, err)
+	_, err = repo.SetCommentLocked(ctx, reply.ID, false)
+	require.NoError(t, err)
+	assert.True(t, getComment(t, repo, reply.ID).IsLocked, "a locked ancestor keeps the thread locked")
+
+	_, err = repo.SetCommentLocked(ctx, a.ID, false)
+	require.NoError(t, err)
+	assert.False(t, getComment(t, repo, nested.ID).IsLocked)
+
+	_, err = repo.SetCommentLocked(ctx, missingID, true)
+	assert.Error(t, err)
+
+	deleted, err := repo.DeleteComment(ctx, reply.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	missing, err := repo.GetCommentByID(ctx, nested.ID)
+	require.NoError(t, err)
+	assert.Nil(t, missing, "replies are deleted with the comment")
+
+	assert.EqualValues(t, 0, getComment(t, repo, a.ID).ReplyCount)
+	assert.EqualValues(t, 2, getPost(t, repo, post.ID).CommentCount)
+
+	got, err := repo.GetCommentByPostID(ctx, post.ID, 0, model.CommentOrderTop)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, commentContents(got))
+
+	deleted, err = repo.DeleteComment(ctx, reply.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted, "deleting a missing comment is not an error")
+
+	fixed, err := repo.RecountComments(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, fixed)
+}
+
+func testTags(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	draft := model.PostStatusDraft
+	x := createPost(t, repo, model.CreatePostInput{Content: "x", Tags: []string{"go", "db"}})
+	y := createPost(t, repo, model.CreatePostInput{Content: "y", Tags: []string{"go"}})
+	createPost(t, repo, model.CreatePostInput{Content: "draft", Tags: []string{"go"}, Status: &draft})
+
+	got, err := repo.GetPostByTag(ctx, "go", 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"y", "x"}, postContents(got), "published posts, most recently tagged first")
+
+	got, err = repo.GetPostByTag(ctx, "go", 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"y"}, postContents(got))
+
+	got, err = repo.GetPostByTag(ctx, "go", 10, &y.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x"}, postContents(got), "posts tagged before the cursor")
+
+	cursor := missingID
+	got, err = repo.GetPostByTag(ctx, "go", 10, &cursor)
+	require.NoError(t, err)
+	assert.Empty(t, got, "an unknown cursor matches nothing")
+
+	updated, err := repo.PutPost(ctx, model.PutPostInput{ID: x.ID, Tags: []string{"rust", "go"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "rust"}, updated.Tags)
+
+	got, err = repo.GetPostByTag(ctx, "go", 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"y", "x"}, postContents(got), "a kept tag keeps its tagging time")
+
+	got, err = repo.GetPostByTag(ctx, "db", 10, nil)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	trending, err := repo.GetTrendingTags(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*model.TagCount{{Tag: "go", Count: 2}, {Tag: "rust", Count: 1}}, trending)
+
+	trending, err = repo.GetTrendingTags(ctx, time.Now().Add(-time.Hour), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []*model.TagCount{{Tag: "go", Count: 2}}, trending)
+
+	trending, err = repo.GetTrendingTags(ctx, time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, trending)
+
+	_, err = repo.DeletePost(ctx, y.ID)
+	require.NoError(t, err)
+
+	got, err = repo.GetPostByTag(ctx, "go", 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x"}, postContents(got), "tags are deleted with the post")
+}
+
+func testIdempotencyKeys(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	id, err := repo.GetIdempotencyKey(ctx, "scope", "key")
+	require.NoError(t, err)
+	assert.Empty(t, id)
+
+	input := model.CreatePostInput{AuthorID: "1", Content: "post", AreCommentsAllowed: true}
+	post, created, err := repo.CreatePostOnce(ctx, input, "scope", "key", expiresAt)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	retry, created, err := repo.CreatePostOnce(ctx, input, "scope", "key", expiresAt)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, post.ID, retry.ID, "the first result wins")
+
+	events, err := repo.FetchOutbox(ctx, 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 2, "the retry writes no events")
+
+	id, err = repo.GetIdempotencyKey(ctx, "scope", "key")
+	require.NoError(t, err)
+	assert.Equal(t, post.ID, id)
+
+	id, err = repo.GetIdempotencyKey(ctx, "other", "key")
+	require.NoError(t, err)
+	assert.Empty(t, id, "keys are scoped")
+
+	commentInput := model.PostCommentInput{PostID: post.ID, AuthorID: "2", Content: "comment"}
+	comment, created, err := repo.PostCommentOnce(ctx, commentInput, "comments", "key", expiresAt)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	retried, created, err := repo.PostCommentOnce(ctx, commentInput, "comments", "key", expiresAt)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, comment.ID, retried.ID)
+
+	_, err = repo.DeletePost(ctx, post.ID)
+	require.NoError(t, err)
+
+	retry, created, err = repo.CreatePostOnce(ctx, input, "scope", "key", expiresAt)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Nil(t, retry, "the stored post was deleted")
+
+	retried, created, err = repo.PostCommentOnce(ctx, commentInput, "comments", "key", expiresAt)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Nil(t, retried, "the stored comment was deleted with the post")
+
+	_, created, err = repo.CreatePostOnce(ctx, input, "scope", "expired", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	id, err = repo.GetIdempotencyKey(ctx, "scope", "expired")
+	require.NoError(t, err)
+	assert.Empty(t, id, "expired keys are unknown")
+
+	_, created, err = repo.CreatePostOnce(ctx, input, "scope", "expired", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.True(t, created, "an expired key is replaced")
+
+	purged, err := repo.PurgeIdempotencyKeys(ctx, time.Now())
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, purged)
+
+	id, err = repo.GetIdempotencyKey(ctx, "scope", "key")
+	require.NoError(t, err)
+	assert.Equal(t, post.ID, id)
+}
+
+func testOutbox(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	post := createPost(t, repo, model.CreatePostInput{Content: "post"})
+	comment := postComment(t, repo, post, nil, "comment")
+	_, err := repo.DeleteComment(ctx, comment.ID)
+	require.NoError(t, err)
+
+	events, err := repo.FetchOutbox(ctx, 10)
+	require.NoError(t, err)
+
+	types := make([]outbox.EventType, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []outbox.EventType{outbox.PostCreated, outbox.PostPublished, outbox.CommentCreated, outbox.CommentDeleted}, types)
+	require.Len(t, events, 4)
+	assert.Equal(t, post.ID, events[0].AggregateID)
+	assert.Equal(t, comment.ID, events[2].AggregateID)
+
+	var deleted outbox.Deleted
+	require.NoError(t, json.Unmarshal(events[3].Payload, &deleted))
+	assert.Equal(t, outbox.Deleted{ID: comment.ID, PostID: post.ID}, deleted)
+
+	limited, err := repo.FetchOutbox(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+	assert.Equal(t, events[0].ID, limited[0].ID)
+
+	require.NoError(t, repo.AckOutbox(ctx, events[1].ID))
+	require.NoError(t, repo.AckOutbox(ctx, events[1].ID), "acking twice is not an error")
+
+	rest, err := repo.FetchOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, rest, 3)
+	assert.Equal(t, []int64{events[0].ID, events[2].ID, events[3].ID}, []int64{rest[0].ID, rest[1].ID, rest[2].ID})
+}
+
+func testWebhooks(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	posts, err := repo.CreateWebhook(ctx, model.CreateWebhookInput{URL: "https://example.com/posts", Secret: "a", Events: []model.WebhookEvent{model.WebhookEventPostCreated}})
+	require.NoError(t, err)
+	all, err := repo.CreateWebhook(ctx, model.CreateWebhookInput{URL: "https://example.com/all", Secret: "b", Events: []model.WebhookEvent{model.WebhookEventPostCreated, model.WebhookEventCommentCreated}})
+	require.NoError(t, err)
+	assert.Equal(t, []model.WebhookEvent{model.WebhookEventPostCreated, model.WebhookEventCommentCreated}, all.Events)
+
+	hooks, err := repo.GetWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, hooks, 2)
+	assert.Equal(t, []string{posts.ID, all.ID}, []string{hooks[0].ID, hooks[1].ID}, "webhooks in creation order")
+
+	enqueued, err := repo.EnqueueWebhookDeliveries(ctx, 1, model.WebhookEventPostCreated, json.RawMessage(`{"id":"1"}`))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, enqueued)
+
+	enqueued, err = repo.EnqueueWebhookDeliveries(ctx, 2, model.WebhookEventCommentCreated, json.RawMessage(`{"id":"2"}`))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, enqueued)
+
+	deliveries, err := repo.GetWebhookDeliveries(ctx, all.ID, 10, nil)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, model.WebhookEventCommentCreated, deliveries[0].Event, "newest first")
+	assert.Equal(t, model.DeliveryStatusPending, deliveries[0].Status)
+	assert.NotNil(t, deliveries[0].NextAttemptAt)
+	retry := deliveries[1]
+
+	deliveries, err = repo.GetWebhookDeliveries(ctx, posts.ID, 10, nil)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	sent := deliveries[0]
+
+	// A minute ahead, the clock of the database may differ from ours.
+	now := time.Now().Add(time.Minute)
+
+	fetched, err := repo.FetchWebhookDeliveries(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, fetched, 3)
+
+	for _, delivery := range fetched {
+		if delivery.ID != sent.ID {
+			continue
+		}
+		assert.EqualValues(t, 1, delivery.EventID)
+		assert.Equal(t, posts.ID, delivery.WebhookID)
+		assert.Equal(t, "https://example.com/posts", delivery.URL)
+		assert.Equal(t, "a", delivery.Secret)
+		assert.Equal(t, model.WebhookEventPostCreated, delivery.Event)
+		assert.JSONEq(t, `{"id":"1"}`, string(delivery.Payload))
+	}
+
+	leased, err := repo.FetchWebhookDeliveries(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, leased, "fetched deliveries are leased")
+
+	code := int32(200)
+	require.NoError(t, repo.RecordWebhookAttempt(ctx, webhook.Attempt{DeliveryID: sent.ID, Status: model.DeliveryStatusSucceeded, Attempts: 1, ResponseCode: &code}))
+
+	message, next := "timeout", now
+	require.NoError(t, repo.RecordWebhookAttempt(ctx, webhook.Attempt{DeliveryID: retry.ID, Status: model.DeliveryStatusPending, Attempts: 1, Error: &message, NextAttemptAt: &next}))
+
+	require.NoError(t, repo.RecordWebhookAttempt(ctx, webhook.Attempt{DeliveryID: missingID, Status: model.DeliveryStatusFailed}), "an attempt of a missing delivery is ignored")
+
+	deliveries, err = repo.GetWebhookDeliveries(ctx, posts.ID, 10, nil)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, model.DeliveryStatusSucceeded, deliveries[0].Status)
+	assert.EqualValues(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, &code, deliveries[0].ResponseCode)
+	assert.NotNil(t, deliveries[0].DeliveredAt)
+	assert.Nil(t, deliveries[0].NextAttemptAt, "only pending deliveries have a next attempt")
+
+	pending := model.DeliveryStatusPending
+	deliveries, err = repo.GetWebhookDeliveries(ctx, all.ID, 10, &pending)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+
+	deliveries, err = repo.GetWebhookDeliveries(ctx, all.ID, 1, &pending)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, model.WebhookEventCommentCreated, deliveries[0].Event)
+
+	retried, err := repo.FetchWebhookDeliveries(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, retried, 1, "a failed delivery is retried at its next attempt")
+	assert.Equal(t, retry.ID, retried[0].ID)
+	assert.EqualValues(t, 1, retried[0].Attempts)
+
+	deleted, err := repo.DeleteWebhook(ctx, all.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deliveries, err = repo.GetWebhookDeliveries(ctx, all.ID, 10, nil)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries, "deliveries are deleted with the webhook")
+
+	expired, err := repo.FetchWebhookDeliveries(ctx, now.Add(2*repository.WebhookLease), 10)
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+
+	hooks, err = repo.GetWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	assert.Equal(t, posts.ID, hooks[0].ID)
+
+	deleted, err = repo.DeleteWebhook(ctx, all.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
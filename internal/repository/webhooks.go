@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"time"
+
+	"ozon/internal/transport/graph/model"
+)
+
+// WebhookLease is how long a fetched delivery is hidden from other workers
+// while it is being sent.
+const WebhookLease = 5 * time.Minute
+
+func webhookEventsToStrings(events []model.WebhookEvent) []string {
+	output := make([]string, 0, len(events))
+	for _, event := range events {
+		output = append(output, string(event))
+	}
+
+	return output
+}
+
+func webhookEventsFromStrings(events []string) []model.WebhookEvent {
+	output := make([]model.WebhookEvent, 0, len(events))
+	for _, event := range events {
+		output = append(output, model.WebhookEvent(event))
+	}
+
+	return output
+}
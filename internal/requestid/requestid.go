@@ -0,0 +1,49 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the ID of a request. It is taken from the client or a proxy
+// when present and returned in the response.
+const Header = "X-Request-ID"
+
+const maxLen = 128
+
+type idKey struct{}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// FromContext returns the ID of the request, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(idKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Resolve returns id if it can be used as a request ID, printable ASCII of
+// up to 128 characters, and a new ID otherwise.
+func Resolve(id string) string {
+	if valid(id) {
+		return id
+	}
+
+	return uuid.NewString()
+}
+
+func valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+
+	for i := range len(id) {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
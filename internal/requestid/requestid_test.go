@@ -0,0 +1,37 @@
+package requestid
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		wantSame bool
+	}{
+		{name: "client ID is kept", id: "req-42", wantSame: true},
+		{name: "longest accepted ID", id: strings.Repeat("a", 128), wantSame: true},
+		{name: "empty ID is generated", id: ""},
+		{name: "too long ID is replaced", id: strings.Repeat("a", 129)},
+		{name: "ID with spaces is replaced", id: "req 42"},
+		{name: "ID with control characters is replaced", id: "req\n42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Resolve(tt.id)
+
+			if tt.wantSame {
+				assert.Equal(t, tt.id, got)
+				return
+			}
+
+			assert.NoError(t, uuid.Validate(got))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/logger"
+)
+
+const (
+	interval      = time.Second
+	purgeInterval = time.Minute
+)
+
+type Service interface {
+	PublishScheduled(ctx context.Context) ([]*model.Post, error)
+	PurgeIdempotencyKeys(ctx context.Context) (int64, error)
+}
+
+// Scheduler periodically publishes scheduled posts and forgets expired
+// idempotency keys. Subscribers learn about the published posts from the
+// outbox.
+type Scheduler struct {
+	service Service
+	log     logger.Logger
+}
+
+func New(service Service, log logger.Logger) *Scheduler {
+	return &Scheduler{
+		service: service,
+		log:     log,
+	}
+}
+
+// Run blocks until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	purge := time.NewTicker(purgeInterval)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.publish(ctx)
+		case <-purge.C:
+			s.purge(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) publish(ctx context.Context) {
+	posts, err := s.service.PublishScheduled(ctx)
+	if err != nil {
+		s.log.Error("failed to publish scheduled posts", zap.String("err", err.Error()))
+		return
+	}
+
+	for _, post := range posts {
+		s.log.Debug("Published scheduled post", zap.String("id", post.ID))
+	}
+}
+
+func (s *Scheduler) purge(ctx context.Context) {
+	purged, err := s.service.PurgeIdempotencyKeys(ctx)
+	if err != nil {
+		s.log.Error("failed to purge idempotency keys", zap.String("err", err.Error()))
+		return
+	}
+
+	s.log.Debug("Purged idempotency keys", zap.Int64("count", purged))
+}
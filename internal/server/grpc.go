@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"google.golang.org/grpc"
+	"ozon/pkg/logger"
+)
+
+const (
+	defaultGRPCPort = "9090"
+)
+
+type GRPCServer struct {
+	grpcServer *grpc.Server
+	addr       string
+}
+
+func NewGRPC(port string, srv *grpc.Server) *GRPCServer {
+	if port == "" {
+		port = defaultGRPCPort
+	}
+
+	return &GRPCServer{
+		grpcServer: srv,
+		addr:       ":" + port,
+	}
+}
+
+func (s *GRPCServer) Run(ctx context.Context) error {
+	logs := logger.GetLogger()
+	logs.Info("Starting gRPC server")
+
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	err = s.grpcServer.Serve(lis)
+	if !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+
+	return nil
+}
+
+// Stop waits for in-flight calls until ctx is done, open streams are cut off
+// after that.
+func (s *GRPCServer) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		<-done
+		return ctx.Err()
+	}
+
+	return nil
+}
@@ -4,23 +4,56 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	"ozon/pkg/logger"
-	"time"
 )
 
 const (
-	httpPort = ":8080"
+	defaultHTTPPort  = "8080"
+	defaultAdminPort = "9100"
 )
 
+// Config of the public HTTP server, zero timeouts mean no timeout.
+type Config struct {
+	Port              string        `mapstructure:"port"`
+	ReadHeaderTimeout time.Duration `mapstructure:"readHeaderTimeout"`
+	ReadTimeout       time.Duration `mapstructure:"readTimeout"`
+	WriteTimeout      time.Duration `mapstructure:"writeTimeout"`
+	IdleTimeout       time.Duration `mapstructure:"idleTimeout"`
+}
+
 type Server struct {
 	httpServer *http.Server
 }
 
-func New(handler http.Handler) *Server {
+func New(cfg Config, handler http.Handler) *Server {
+	if cfg.Port == "" {
+		cfg.Port = defaultHTTPPort
+	}
+
 	return &Server{
 		httpServer: &http.Server{
-			Addr:    httpPort,
+			Addr:              ":" + cfg.Port,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+	}
+}
+
+// NewAdmin returns a server for operational endpoints, such as metrics, on
+// its own port.
+func NewAdmin(port string, handler http.Handler) *Server {
+	if port == "" {
+		port = defaultAdminPort
+	}
+
+	return &Server{
+		httpServer: &http.Server{
+			Addr:    ":" + port,
 			Handler: handler,
 		},
 	}
@@ -38,9 +71,14 @@ func (s *Server) Run(ctx context.Context) error {
 	return nil
 }
 
-func (s *Server) Stop() error {
-	ctx, shutdown := context.WithTimeout(context.Background(), 5*time.Second)
-	defer shutdown()
+// OnShutdown registers a function to run once Stop has closed the listener,
+// it is meant to end hijacked connections that Stop does not wait for.
+func (s *Server) OnShutdown(f func()) {
+	s.httpServer.RegisterOnShutdown(f)
+}
 
+// Stop closes the listener and waits for in-flight requests until ctx is
+// done. Hijacked connections, such as websockets, are not waited for.
+func (s *Server) Stop(ctx context.Context) error {
 	return s.httpServer.Shutdown(ctx)
 }
@@ -3,7 +3,25 @@ package service
 import "errors"
 
 var (
-	ErrIncorrectPostLen    = errors.New("too long post")
-	ErrIncorrectCommentLen = errors.New("too long comment")
-	ErrIncorrectContentLen = errors.New("incorrect content")
+	ErrIncorrectPostLen        = errors.New("too long post")
+	ErrIncorrectCommentLen     = errors.New("too long comment")
+	ErrIncorrectContentLen     = errors.New("incorrect content")
+	ErrIncorrectTag            = errors.New("incorrect tag")
+	ErrTooManyTags             = errors.New("too many tags")
+	ErrIncorrectWindow         = errors.New("incorrect trending window")
+	ErrIncorrectStatus         = errors.New("incorrect post status")
+	ErrIncorrectPublishAt      = errors.New("incorrect publication time")
+	ErrPostNotFound            = errors.New("post not found")
+	ErrUnauthenticated         = errors.New("unauthenticated")
+	ErrForbidden               = errors.New("forbidden")
+	ErrCommentNotFound         = errors.New("comment not found")
+	ErrThreadLocked            = errors.New("comment thread is locked")
+	ErrIncorrectOrder          = errors.New("incorrect comment order")
+	ErrIncorrectIdempotencyKey = errors.New("incorrect idempotency key")
+	ErrIncorrectWebhookURL     = errors.New("incorrect webhook url")
+	ErrIncorrectWebhookSecret  = errors.New("incorrect webhook secret")
+	ErrIncorrectWebhookEvents  = errors.New("incorrect webhook events")
+	ErrCommentsNotAllowed      = errors.New("not allowed")
+	ErrPostNotPublished        = errors.New("post is not published")
+	ErrIdempotentResultDeleted = errors.New("the result of the idempotency key was deleted")
 )
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"ozon/internal/idempotency"
+	"ozon/internal/tracing"
+	"ozon/internal/transport/graph/model"
+)
+
+const maxIdempotencyKeyLen = 255
+
+// idempotencyKey returns the key a mutation is deduplicated by: its
+// clientMutationId or, failing that, the Idempotency-Key header of the request.
+func idempotencyKey(ctx context.Context, clientMutationID *string) (string, error) {
+	key, _ := idempotency.FromContext(ctx)
+	if clientMutationID != nil {
+		key = *clientMutationID
+	}
+
+	if len(key) > maxIdempotencyKeyLen {
+		return "", ErrIncorrectIdempotencyKey
+	}
+
+	return key, nil
+}
+
+// keyExpiry returns when an idempotency key saved now expires.
+func (s Service) keyExpiry() time.Time {
+	return time.Now().UTC().Add(s.idempotencyTTL)
+}
+
+// rememberedPost returns the post created by the first request with an
+// idempotency key. A retry after the post was deleted fails rather than
+// returning nothing.
+func (s Service) rememberedPost(ctx context.Context, id string) (*model.Post, error) {
+	post, err := s.repo.GetPostByID(ctx, id)
+	if err == nil && post == nil {
+		return nil, ErrIdempotentResultDeleted
+	}
+
+	return post, err
+}
+
+// rememberedComment is rememberedPost for comments.
+func (s Service) rememberedComment(ctx context.Context, id string) (*model.Comment, error) {
+	comment, err := s.repo.GetCommentByID(ctx, id)
+	if err == nil && comment == nil {
+		return nil, ErrIdempotentResultDeleted
+	}
+
+	return comment, err
+}
+
+// PurgeIdempotencyKeys forgets the idempotency keys whose TTL has passed.
+func (s Service) PurgeIdempotencyKeys(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "Service.PurgeIdempotencyKeys")
+	defer func() { tracing.End(span, err) }()
+	return s.repo.PurgeIdempotencyKeys(ctx, time.Now().UTC())
+}
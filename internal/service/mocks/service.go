@@ -13,6 +13,7 @@ import (
 	context "context"
 	model "ozon/internal/transport/graph/model"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -56,6 +57,37 @@ func (mr *MockRepositoryMockRecorder) CreatePost(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockRepository)(nil).CreatePost), ctx, input)
 }
 
+// CreatePostOnce mocks base method.
+func (m *MockRepository) CreatePostOnce(ctx context.Context, input model.CreatePostInput, scope, key string, expiresAt time.Time) (*model.Post, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePostOnce", ctx, input, scope, key, expiresAt)
+	ret0, _ := ret[0].(*model.Post)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreatePostOnce indicates an expected call of CreatePostOnce.
+func (mr *MockRepositoryMockRecorder) CreatePostOnce(ctx, input, scope, key, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePostOnce", reflect.TypeOf((*MockRepository)(nil).CreatePostOnce), ctx, input, scope, key, expiresAt)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockRepository) CreateWebhook(ctx context.Context, input model.CreateWebhookInput) (*model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, input)
+	ret0, _ := ret[0].(*model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockRepositoryMockRecorder) CreateWebhook(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockRepository)(nil).CreateWebhook), ctx, input)
+}
+
 // DeleteComment mocks base method.
 func (m *MockRepository) DeleteComment(ctx context.Context, id string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -86,34 +118,94 @@ func (mr *MockRepositoryMockRecorder) DeletePost(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePost", reflect.TypeOf((*MockRepository)(nil).DeletePost), ctx, id)
 }
 
+// DeleteWebhook mocks base method.
+func (m *MockRepository) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockRepositoryMockRecorder) DeleteWebhook(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockRepository)(nil).DeleteWebhook), ctx, id)
+}
+
+// GetCommentByID mocks base method.
+func (m *MockRepository) GetCommentByID(ctx context.Context, id string) (*model.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentByID", ctx, id)
+	ret0, _ := ret[0].(*model.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentByID indicates an expected call of GetCommentByID.
+func (mr *MockRepositoryMockRecorder) GetCommentByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentByID", reflect.TypeOf((*MockRepository)(nil).GetCommentByID), ctx, id)
+}
+
 // GetCommentByParentCommentID mocks base method.
-func (m *MockRepository) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32) ([]*model.Comment, error) {
+func (m *MockRepository) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCommentByParentCommentID", ctx, parentCommentID, first)
+	ret := m.ctrl.Call(m, "GetCommentByParentCommentID", ctx, parentCommentID, first, orderBy)
 	ret0, _ := ret[0].([]*model.Comment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCommentByParentCommentID indicates an expected call of GetCommentByParentCommentID.
-func (mr *MockRepositoryMockRecorder) GetCommentByParentCommentID(ctx, parentCommentID, first any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetCommentByParentCommentID(ctx, parentCommentID, first, orderBy any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentByParentCommentID", reflect.TypeOf((*MockRepository)(nil).GetCommentByParentCommentID), ctx, parentCommentID, first)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentByParentCommentID", reflect.TypeOf((*MockRepository)(nil).GetCommentByParentCommentID), ctx, parentCommentID, first, orderBy)
 }
 
 // GetCommentByPostID mocks base method.
-func (m *MockRepository) GetCommentByPostID(ctx context.Context, postID string, first int32) ([]*model.Comment, error) {
+func (m *MockRepository) GetCommentByPostID(ctx context.Context, postID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCommentByPostID", ctx, postID, first)
+	ret := m.ctrl.Call(m, "GetCommentByPostID", ctx, postID, first, orderBy)
 	ret0, _ := ret[0].([]*model.Comment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCommentByPostID indicates an expected call of GetCommentByPostID.
-func (mr *MockRepositoryMockRecorder) GetCommentByPostID(ctx, postID, first any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetCommentByPostID(ctx, postID, first, orderBy any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentByPostID", reflect.TypeOf((*MockRepository)(nil).GetCommentByPostID), ctx, postID, first)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentByPostID", reflect.TypeOf((*MockRepository)(nil).GetCommentByPostID), ctx, postID, first, orderBy)
+}
+
+// GetDrafts mocks base method.
+func (m *MockRepository) GetDrafts(ctx context.Context, authorID string, first int32) ([]*model.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDrafts", ctx, authorID, first)
+	ret0, _ := ret[0].([]*model.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDrafts indicates an expected call of GetDrafts.
+func (mr *MockRepositoryMockRecorder) GetDrafts(ctx, authorID, first any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDrafts", reflect.TypeOf((*MockRepository)(nil).GetDrafts), ctx, authorID, first)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockRepository) GetIdempotencyKey(ctx context.Context, scope, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", ctx, scope, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockRepositoryMockRecorder) GetIdempotencyKey(ctx, scope, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockRepository)(nil).GetIdempotencyKey), ctx, scope, key)
 }
 
 // GetPost mocks base method.
@@ -146,6 +238,66 @@ func (mr *MockRepositoryMockRecorder) GetPostByID(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostByID", reflect.TypeOf((*MockRepository)(nil).GetPostByID), ctx, id)
 }
 
+// GetPostByTag mocks base method.
+func (m *MockRepository) GetPostByTag(ctx context.Context, tag string, first int32, after *string) ([]*model.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostByTag", ctx, tag, first, after)
+	ret0, _ := ret[0].([]*model.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostByTag indicates an expected call of GetPostByTag.
+func (mr *MockRepositoryMockRecorder) GetPostByTag(ctx, tag, first, after any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostByTag", reflect.TypeOf((*MockRepository)(nil).GetPostByTag), ctx, tag, first, after)
+}
+
+// GetTrendingTags mocks base method.
+func (m *MockRepository) GetTrendingTags(ctx context.Context, since time.Time, limit int32) ([]*model.TagCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrendingTags", ctx, since, limit)
+	ret0, _ := ret[0].([]*model.TagCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrendingTags indicates an expected call of GetTrendingTags.
+func (mr *MockRepositoryMockRecorder) GetTrendingTags(ctx, since, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrendingTags", reflect.TypeOf((*MockRepository)(nil).GetTrendingTags), ctx, since, limit)
+}
+
+// GetWebhookDeliveries mocks base method.
+func (m *MockRepository) GetWebhookDeliveries(ctx context.Context, webhookID string, first int32, status *model.DeliveryStatus) ([]*model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", ctx, webhookID, first, status)
+	ret0, _ := ret[0].([]*model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries.
+func (mr *MockRepositoryMockRecorder) GetWebhookDeliveries(ctx, webhookID, first, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockRepository)(nil).GetWebhookDeliveries), ctx, webhookID, first, status)
+}
+
+// GetWebhooks mocks base method.
+func (m *MockRepository) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooks", ctx)
+	ret0, _ := ret[0].([]*model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooks indicates an expected call of GetWebhooks.
+func (mr *MockRepositoryMockRecorder) GetWebhooks(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooks", reflect.TypeOf((*MockRepository)(nil).GetWebhooks), ctx)
+}
+
 // PostComment mocks base method.
 func (m *MockRepository) PostComment(ctx context.Context, input model.PostCommentInput) (*model.Comment, error) {
 	m.ctrl.T.Helper()
@@ -161,6 +313,52 @@ func (mr *MockRepositoryMockRecorder) PostComment(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostComment", reflect.TypeOf((*MockRepository)(nil).PostComment), ctx, input)
 }
 
+// PostCommentOnce mocks base method.
+func (m *MockRepository) PostCommentOnce(ctx context.Context, input model.PostCommentInput, scope, key string, expiresAt time.Time) (*model.Comment, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostCommentOnce", ctx, input, scope, key, expiresAt)
+	ret0, _ := ret[0].(*model.Comment)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PostCommentOnce indicates an expected call of PostCommentOnce.
+func (mr *MockRepositoryMockRecorder) PostCommentOnce(ctx, input, scope, key, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostCommentOnce", reflect.TypeOf((*MockRepository)(nil).PostCommentOnce), ctx, input, scope, key, expiresAt)
+}
+
+// PublishScheduledPosts mocks base method.
+func (m *MockRepository) PublishScheduledPosts(ctx context.Context, now time.Time) ([]*model.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishScheduledPosts", ctx, now)
+	ret0, _ := ret[0].([]*model.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishScheduledPosts indicates an expected call of PublishScheduledPosts.
+func (mr *MockRepositoryMockRecorder) PublishScheduledPosts(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishScheduledPosts", reflect.TypeOf((*MockRepository)(nil).PublishScheduledPosts), ctx, now)
+}
+
+// PurgeIdempotencyKeys mocks base method.
+func (m *MockRepository) PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeIdempotencyKeys", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeIdempotencyKeys indicates an expected call of PurgeIdempotencyKeys.
+func (mr *MockRepositoryMockRecorder) PurgeIdempotencyKeys(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeIdempotencyKeys", reflect.TypeOf((*MockRepository)(nil).PurgeIdempotencyKeys), ctx, now)
+}
+
 // PutComment mocks base method.
 func (m *MockRepository) PutComment(ctx context.Context, input model.PutCommentInput) (*model.Comment, error) {
 	m.ctrl.T.Helper()
@@ -190,3 +388,63 @@ func (mr *MockRepositoryMockRecorder) PutPost(ctx, input any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutPost", reflect.TypeOf((*MockRepository)(nil).PutPost), ctx, input)
 }
+
+// RecountComments mocks base method.
+func (m *MockRepository) RecountComments(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecountComments", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecountComments indicates an expected call of RecountComments.
+func (mr *MockRepositoryMockRecorder) RecountComments(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecountComments", reflect.TypeOf((*MockRepository)(nil).RecountComments), ctx)
+}
+
+// SetCommentLocked mocks base method.
+func (m *MockRepository) SetCommentLocked(ctx context.Context, id string, locked bool) (*model.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCommentLocked", ctx, id, locked)
+	ret0, _ := ret[0].(*model.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetCommentLocked indicates an expected call of SetCommentLocked.
+func (mr *MockRepositoryMockRecorder) SetCommentLocked(ctx, id, locked any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCommentLocked", reflect.TypeOf((*MockRepository)(nil).SetCommentLocked), ctx, id, locked)
+}
+
+// SetCommentPinned mocks base method.
+func (m *MockRepository) SetCommentPinned(ctx context.Context, id string, pinned bool) (*model.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCommentPinned", ctx, id, pinned)
+	ret0, _ := ret[0].(*model.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetCommentPinned indicates an expected call of SetCommentPinned.
+func (mr *MockRepositoryMockRecorder) SetCommentPinned(ctx, id, pinned any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCommentPinned", reflect.TypeOf((*MockRepository)(nil).SetCommentPinned), ctx, id, pinned)
+}
+
+// SetPostPinned mocks base method.
+func (m *MockRepository) SetPostPinned(ctx context.Context, id string, pinned bool) (*model.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPostPinned", ctx, id, pinned)
+	ret0, _ := ret[0].(*model.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetPostPinned indicates an expected call of SetPostPinned.
+func (mr *MockRepositoryMockRecorder) SetPostPinned(ctx, id, pinned any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPostPinned", reflect.TypeOf((*MockRepository)(nil).SetPostPinned), ctx, id, pinned)
+}
@@ -2,15 +2,21 @@ package service
 
 import (
 	"context"
-	"errors"
+	"ozon/internal/auth"
+	"ozon/internal/tracing"
 	"ozon/internal/transport/graph/model"
+	"sync/atomic"
+	"time"
 )
 
 const (
-	maxPostLen    = 10000
-	maxCommentLen = 2000
+	defaultPageSize = 10
+
+	defaultIdempotencyTTL = 24 * time.Hour
 )
 
+var tracer = tracing.Tracer("ozon/internal/service")
+
 type Repository interface {
 	CreatePost(ctx context.Context, input model.CreatePostInput) (*model.Post, error)
 	PostComment(ctx context.Context, input model.PostCommentInput) (*model.Comment, error)
@@ -20,44 +26,165 @@ type Repository interface {
 	DeleteComment(ctx context.Context, id string) (bool, error)
 	GetPost(ctx context.Context, first int32) ([]*model.Post, error)
 	GetPostByID(ctx context.Context, id string) (*model.Post, error)
-	GetCommentByPostID(ctx context.Context, postID string, first int32) ([]*model.Comment, error)
-	GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32) ([]*model.Comment, error)
+	GetCommentByPostID(ctx context.Context, postID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error)
+	GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error)
+	GetPostByTag(ctx context.Context, tag string, first int32, after *string) ([]*model.Post, error)
+	GetTrendingTags(ctx context.Context, since time.Time, limit int32) ([]*model.TagCount, error)
+	GetDrafts(ctx context.Context, authorID string, first int32) ([]*model.Post, error)
+	PublishScheduledPosts(ctx context.Context, now time.Time) ([]*model.Post, error)
+	GetCommentByID(ctx context.Context, id string) (*model.Comment, error)
+	SetPostPinned(ctx context.Context, id string, pinned bool) (*model.Post, error)
+	SetCommentPinned(ctx context.Context, id string, pinned bool) (*model.Comment, error)
+	SetCommentLocked(ctx context.Context, id string, locked bool) (*model.Comment, error)
+	RecountComments(ctx context.Context) (int64, error)
+	GetIdempotencyKey(ctx context.Context, scope, key string) (string, error)
+	CreatePostOnce(ctx context.Context, input model.CreatePostInput, scope, key string, expiresAt time.Time) (*model.Post, bool, error)
+	PostCommentOnce(ctx context.Context, input model.PostCommentInput, scope, key string, expiresAt time.Time) (*model.Comment, bool, error)
+	PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+	CreateWebhook(ctx context.Context, input model.CreateWebhookInput) (*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) (bool, error)
+	GetWebhooks(ctx context.Context) ([]*model.Webhook, error)
+	GetWebhookDeliveries(ctx context.Context, webhookID string, first int32, status *model.DeliveryStatus) ([]*model.WebhookDelivery, error)
+}
+
+// Limits bounds the length of user content, in bytes.
+type Limits struct {
+	PostLen    int `mapstructure:"postLen" json:"postLen"`
+	CommentLen int `mapstructure:"commentLen" json:"commentLen"`
 }
 
+// DefaultLimits are used for the limits that are not set.
+var DefaultLimits = Limits{PostLen: 10000, CommentLen: 2000}
+
 type Service struct {
-	repo Repository
+	repo           Repository
+	idempotencyTTL time.Duration
+	// limits is shared by the copies of the value receivers, so that
+	// SetLimits applies to all of them.
+	limits *atomic.Pointer[Limits]
+}
+
+type Option func(*Service)
+
+// WithIdempotencyTTL sets how long the result of a mutation is remembered
+// under its idempotency key.
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		if ttl > 0 {
+			s.idempotencyTTL = ttl
+		}
+	}
+}
+
+// WithLimits overrides the content limits, zero fields keep the defaults.
+func WithLimits(limits Limits) Option {
+	return func(s *Service) {
+		s.limits.Store(&limits)
+	}
 }
 
-func New(repository Repository) *Service {
-	return &Service{repo: repository}
+func New(repository Repository, opts ...Option) *Service {
+	s := &Service{repo: repository, idempotencyTTL: defaultIdempotencyTTL, limits: new(atomic.Pointer[Limits])}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
-func (s Service) CreatePost(ctx context.Context, input model.CreatePostInput) (*model.Post, error) {
+// SetLimits changes the content limits while the service is in use.
+func (s Service) SetLimits(limits Limits) {
+	s.limits.Store(&limits)
+}
+
+func (s Service) currentLimits() Limits {
+	if s.limits == nil {
+		return Limits{}
+	}
+	if limits := s.limits.Load(); limits != nil {
+		return *limits
+	}
+	return Limits{}
+}
+
+func (s Service) maxPostLen() int {
+	if limits := s.currentLimits(); limits.PostLen > 0 {
+		return limits.PostLen
+	}
+	return DefaultLimits.PostLen
+}
+
+func (s Service) maxCommentLen() int {
+	if limits := s.currentLimits(); limits.CommentLen > 0 {
+		return limits.CommentLen
+	}
+	return DefaultLimits.CommentLen
+}
+
+func (s Service) CreatePost(ctx context.Context, input model.CreatePostInput) (_ *model.Post, err error) {
+	ctx, span := tracer.Start(ctx, "Service.CreatePost")
+	defer func() { tracing.End(span, err) }()
 
 	if input.Content == "" {
 		return nil, ErrIncorrectContentLen
 	}
 
-	if len(input.Content) > maxPostLen {
+	if len(input.Content) > s.maxPostLen() {
 		return nil, ErrIncorrectPostLen
 	}
 
-	post, err := s.repo.CreatePost(ctx, input)
+	if input.Tags == nil {
+		input.Tags = extractTags(input.Content)
+	}
 
+	tags, err := normalizeTags(input.Tags)
 	if err != nil {
 		return nil, err
 	}
+	input.Tags = tags
 
-	return post, nil
+	status, publishAt, err := resolveStatus(input.Status, input.PublishAt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	input.Status, input.PublishAt = &status, publishAt
+
+	key, err := idempotencyKey(ctx, input.ClientMutationID)
+	if err != nil {
+		return nil, err
+	}
+	scope := "createPost:" + input.AuthorID
+
+	if key != "" {
+		// A retry usually finds its result without a write, the write
+		// checks the key again for concurrent retries.
+		id, err := s.repo.GetIdempotencyKey(ctx, scope, key)
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			return s.rememberedPost(ctx, id)
+		}
+
+		post, created, err := s.repo.CreatePostOnce(ctx, input, scope, key, s.keyExpiry())
+		if err == nil && !created && post == nil {
+			return nil, ErrIdempotentResultDeleted
+		}
+		return post, err
+	}
+
+	return s.repo.CreatePost(ctx, input)
 }
 
-func (s Service) PostComment(ctx context.Context, input model.PostCommentInput) (*model.Comment, error) {
+func (s Service) PostComment(ctx context.Context, input model.PostCommentInput) (_ *model.Comment, err error) {
+	ctx, span := tracer.Start(ctx, "Service.PostComment")
+	defer func() { tracing.End(span, err) }()
 
 	if input.Content == "" {
 		return nil, ErrIncorrectContentLen
 	}
 
-	if len(input.Content) > maxCommentLen {
+	if len(input.Content) > s.maxCommentLen() {
 		return nil, ErrIncorrectCommentLen
 	}
 
@@ -65,19 +192,98 @@ func (s Service) PostComment(ctx context.Context, input model.PostCommentInput)
 
 	if err == nil && post != nil {
 		if !post.AreCommentsAllowed {
-			return nil, errors.New("not allowed")
+			return nil, ErrCommentsNotAllowed
+		}
+		if post.Status == model.PostStatusDraft || post.Status == model.PostStatusScheduled {
+			return nil, ErrPostNotPublished
 		}
 	}
 
-	comment, err := s.repo.PostComment(ctx, input)
+	if input.ParentCommentID != nil {
+		parent, err := s.repo.GetCommentByID(ctx, *input.ParentCommentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent != nil && parent.IsLocked {
+			return nil, ErrThreadLocked
+		}
+	}
+
+	key, err := idempotencyKey(ctx, input.ClientMutationID)
 	if err != nil {
 		return nil, err
 	}
+	scope := "postComment:" + input.AuthorID
+
+	if key != "" {
+		// A retry usually finds its result without a write, the write
+		// checks the key again for concurrent retries.
+		id, err := s.repo.GetIdempotencyKey(ctx, scope, key)
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			return s.rememberedComment(ctx, id)
+		}
 
-	return comment, nil
+		comment, created, err := s.repo.PostCommentOnce(ctx, input, scope, key, s.keyExpiry())
+		if err == nil && !created && comment == nil {
+			return nil, ErrIdempotentResultDeleted
+		}
+		return comment, err
+	}
+
+	return s.repo.PostComment(ctx, input)
 }
 
-func (s Service) PutPost(ctx context.Context, input model.PutPostInput) (*model.Post, error) {
+// PutPost edits a post. Allowed to moderators and to the author of the post.
+func (s Service) PutPost(ctx context.Context, input model.PutPostInput) (_ *model.Post, err error) {
+	ctx, span := tracer.Start(ctx, "Service.PutPost")
+	defer func() { tracing.End(span, err) }()
+	if input.Content != nil && (*input.Content == "" || len(*input.Content) > s.maxPostLen()) {
+		return nil, ErrIncorrectPostLen
+	}
+
+	if input.Tags == nil && input.Content != nil {
+		input.Tags = extractTags(*input.Content)
+	}
+
+	if input.Tags != nil {
+		tags, err := normalizeTags(input.Tags)
+		if err != nil {
+			return nil, err
+		}
+		input.Tags = tags
+	}
+
+	current, err := s.repo.GetPostByID(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, ErrPostNotFound
+	}
+
+	if !auth.IsModerator(ctx) && !auth.IsAuthor(ctx, current.AuthorID) {
+		return nil, ErrForbidden
+	}
+
+	if input.Status != nil || input.PublishAt != nil {
+		if current.Status == model.PostStatusPublished {
+			// Published posts can be neither unpublished nor rescheduled.
+			if input.PublishAt != nil || *input.Status != model.PostStatusPublished {
+				return nil, ErrIncorrectStatus
+			}
+			input.Status = nil
+		} else {
+			status, publishAt, err := resolveStatus(input.Status, input.PublishAt, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			input.Status, input.PublishAt = &status, publishAt
+		}
+	}
+
 	post, err := s.repo.PutPost(ctx, input)
 	if err != nil {
 		return nil, err
@@ -86,7 +292,17 @@ func (s Service) PutPost(ctx context.Context, input model.PutPostInput) (*model.
 	return post, nil
 }
 
-func (s Service) PutComment(ctx context.Context, input model.PutCommentInput) (*model.Comment, error) {
+func (s Service) PutComment(ctx context.Context, input model.PutCommentInput) (_ *model.Comment, err error) {
+	ctx, span := tracer.Start(ctx, "Service.PutComment")
+	defer func() { tracing.End(span, err) }()
+	if input.Content == "" {
+		return nil, ErrIncorrectContentLen
+	}
+
+	if len(input.Content) > s.maxCommentLen() {
+		return nil, ErrIncorrectCommentLen
+	}
+
 	comment, err := s.repo.PutComment(ctx, input)
 	if err != nil {
 		return nil, err
@@ -95,7 +311,9 @@ func (s Service) PutComment(ctx context.Context, input model.PutCommentInput) (*
 	return comment, nil
 }
 
-func (s Service) DeletePost(ctx context.Context, id string) (bool, error) {
+func (s Service) DeletePost(ctx context.Context, id string) (_ bool, err error) {
+	ctx, span := tracer.Start(ctx, "Service.DeletePost")
+	defer func() { tracing.End(span, err) }()
 	ok, err := s.repo.DeletePost(ctx, id)
 	if err != nil {
 		return false, err
@@ -104,7 +322,9 @@ func (s Service) DeletePost(ctx context.Context, id string) (bool, error) {
 	return ok, nil
 }
 
-func (s Service) DeleteComment(ctx context.Context, id string) (bool, error) {
+func (s Service) DeleteComment(ctx context.Context, id string) (_ bool, err error) {
+	ctx, span := tracer.Start(ctx, "Service.DeleteComment")
+	defer func() { tracing.End(span, err) }()
 	ok, err := s.repo.DeleteComment(ctx, id)
 	if err != nil {
 		return false, err
@@ -113,26 +333,239 @@ func (s Service) DeleteComment(ctx context.Context, id string) (bool, error) {
 	return ok, nil
 }
 
-func (s Service) GetPost(ctx context.Context, first int32) ([]*model.Post, error) {
+func (s Service) GetPost(ctx context.Context, first int32) (_ []*model.Post, err error) {
+	ctx, span := tracer.Start(ctx, "Service.GetPost")
+	defer func() { tracing.End(span, err) }()
 	post, err := s.repo.GetPost(ctx, first)
 
 	return post, err
 }
 
-func (s Service) GetPostByID(ctx context.Context, id string) (*model.Post, error) {
+func (s Service) GetPostByID(ctx context.Context, id string) (_ *model.Post, err error) {
+	ctx, span := tracer.Start(ctx, "Service.GetPostByID")
+	defer func() { tracing.End(span, err) }()
 	post, err := s.repo.GetPostByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-	return post, err
+	// Unpublished posts are visible to their author only.
+	if post != nil && post.Status != model.PostStatusPublished && !auth.IsAuthor(ctx, post.AuthorID) {
+		return nil, ErrPostNotFound
+	}
+
+	return post, nil
 }
 
-func (s Service) GetCommentByPostID(ctx context.Context, postID string, first int32) ([]*model.Comment, error) {
-	comments, err := s.repo.GetCommentByPostID(ctx, postID, first)
+func (s Service) GetCommentByPostID(ctx context.Context, postID string, first int32, orderBy model.CommentOrder) (_ []*model.Comment, err error) {
+	ctx, span := tracer.Start(ctx, "Service.GetCommentByPostID")
+	defer func() { tracing.End(span, err) }()
+	if orderBy == "" {
+		orderBy = model.CommentOrderNewest
+	}
+	if !orderBy.IsValid() {
+		return nil, ErrIncorrectOrder
+	}
+
+	comments, err := s.repo.GetCommentByPostID(ctx, postID, first, orderBy)
 
 	return comments, err
 }
 
-func (s Service) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32) ([]*model.Comment, error) {
-	comments, err := s.repo.GetCommentByParentCommentID(ctx, parentCommentID, first)
+func (s Service) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32, orderBy model.CommentOrder) (_ []*model.Comment, err error) {
+	ctx, span := tracer.Start(ctx, "Service.GetCommentByParentCommentID")
+	defer func() { tracing.End(span, err) }()
+	if orderBy == "" {
+		orderBy = model.CommentOrderNewest
+	}
+	if !orderBy.IsValid() {
+		return nil, ErrIncorrectOrder
+	}
+
+	comments, err := s.repo.GetCommentByParentCommentID(ctx, parentCommentID, first, orderBy)
 
 	return comments, err
 }
+
+func (s Service) GetPostByTag(ctx context.Context, tag string, first int32, after *string) (_ []*model.Post, err error) {
+	ctx, span := tracer.Start(ctx, "Service.GetPostByTag")
+	defer func() { tracing.End(span, err) }()
+	tags, err := normalizeTags([]string{tag})
+	if err != nil {
+		return nil, err
+	}
+
+	if first == 0 {
+		first = defaultPageSize
+	}
+
+	posts, err := s.repo.GetPostByTag(ctx, tags[0], first, after)
+
+	return posts, err
+}
+
+func (s Service) GetTrendingTags(ctx context.Context, window model.TrendingWindow) (_ []*model.TagCount, err error) {
+	ctx, span := tracer.Start(ctx, "Service.GetTrendingTags")
+	defer func() { tracing.End(span, err) }()
+	duration, ok := trendingWindows[window]
+	if !ok {
+		return nil, ErrIncorrectWindow
+	}
+
+	tags, err := s.repo.GetTrendingTags(ctx, time.Now().Add(-duration), trendingLimit)
+
+	return tags, err
+}
+
+func (s Service) GetDrafts(ctx context.Context, first int32) (_ []*model.Post, err error) {
+	ctx, span := tracer.Start(ctx, "Service.GetDrafts")
+	defer func() { tracing.End(span, err) }()
+	user, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	if first == 0 {
+		first = defaultPageSize
+	}
+
+	posts, err := s.repo.GetDrafts(ctx, user.ID, first)
+
+	return posts, err
+}
+
+// PublishScheduled publishes the scheduled posts whose publication time has come.
+func (s Service) PublishScheduled(ctx context.Context) (_ []*model.Post, err error) {
+	ctx, span := tracer.Start(ctx, "Service.PublishScheduled")
+	defer func() { tracing.End(span, err) }()
+	posts, err := s.repo.PublishScheduledPosts(ctx, time.Now().UTC())
+
+	return posts, err
+}
+
+// SetPostPinned pins or unpins a post at the top of the feed.
+// Allowed to moderators and to the author of the post.
+func (s Service) SetPostPinned(ctx context.Context, id string, pinned bool) (_ *model.Post, err error) {
+	ctx, span := tracer.Start(ctx, "Service.SetPostPinned")
+	defer func() { tracing.End(span, err) }()
+	post, err := s.repo.GetPostByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, ErrPostNotFound
+	}
+
+	if !auth.IsModerator(ctx) && !auth.IsAuthor(ctx, post.AuthorID) {
+		return nil, ErrForbidden
+	}
+
+	if pinned && post.Status != model.PostStatusPublished {
+		return nil, ErrIncorrectStatus
+	}
+
+	return s.repo.SetPostPinned(ctx, id, pinned)
+}
+
+// SetCommentPinned pins or unpins a comment at the top of the post comments.
+// Allowed to moderators and to the author of the post the comment belongs to.
+func (s Service) SetCommentPinned(ctx context.Context, id string, pinned bool) (_ *model.Comment, err error) {
+	ctx, span := tracer.Start(ctx, "Service.SetCommentPinned")
+	defer func() { tracing.End(span, err) }()
+	comment, err := s.repo.GetCommentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, ErrCommentNotFound
+	}
+
+	post, err := s.repo.GetPostByID(ctx, comment.PostID)
+	if err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, ErrPostNotFound
+	}
+
+	if !auth.IsModerator(ctx) && !auth.IsAuthor(ctx, post.AuthorID) {
+		return nil, ErrForbidden
+	}
+
+	return s.repo.SetCommentPinned(ctx, id, pinned)
+}
+
+// SetThreadLocked locks or unlocks the sub-thread starting at the comment,
+// the lock applies to all of its replies. Allowed to moderators and to the
+// author of the post the comment belongs to.
+func (s Service) SetThreadLocked(ctx context.Context, commentID string, locked bool) (_ *model.Comment, err error) {
+	ctx, span := tracer.Start(ctx, "Service.SetThreadLocked")
+	defer func() { tracing.End(span, err) }()
+	comment, err := s.repo.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, ErrCommentNotFound
+	}
+
+	post, err := s.repo.GetPostByID(ctx, comment.PostID)
+	if err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, ErrPostNotFound
+	}
+
+	if !auth.IsModerator(ctx) && !auth.IsAuthor(ctx, post.AuthorID) {
+		return nil, ErrForbidden
+	}
+
+	return s.repo.SetCommentLocked(ctx, commentID, locked)
+}
+
+// RecountComments repairs the denormalized comment counters and returns the
+// number of posts and comments whose counters were corrected. Moderators only.
+func (s Service) RecountComments(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "Service.RecountComments")
+	defer func() { tracing.End(span, err) }()
+	if !auth.IsModerator(ctx) {
+		return 0, ErrForbidden
+	}
+
+	return s.repo.RecountComments(ctx)
+}
+
+// resolveStatus derives the status a post is stored with from the requested
+// status and publication time. Published posts get the current time as publishAt.
+func resolveStatus(status *model.PostStatus, publishAt *time.Time, now time.Time) (model.PostStatus, *time.Time, error) {
+	now = now.UTC()
+	if publishAt != nil {
+		utc := publishAt.UTC()
+		publishAt = &utc
+	}
+
+	if status == nil {
+		if publishAt != nil && publishAt.After(now) {
+			return model.PostStatusScheduled, publishAt, nil
+		}
+		return model.PostStatusPublished, &now, nil
+	}
+
+	switch *status {
+	case model.PostStatusDraft:
+		return model.PostStatusDraft, publishAt, nil
+	case model.PostStatusScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return "", nil, ErrIncorrectPublishAt
+		}
+		return model.PostStatusScheduled, publishAt, nil
+	case model.PostStatusPublished:
+		if publishAt != nil && publishAt.After(now) {
+			return "", nil, ErrIncorrectPublishAt
+		}
+		return model.PostStatusPublished, &now, nil
+	default:
+		return "", nil, ErrIncorrectStatus
+	}
+}
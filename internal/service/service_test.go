@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"ozon/internal/auth"
+	"ozon/internal/idempotency"
 	serviceMock "ozon/internal/service/mocks"
 	"ozon/internal/transport/graph/model"
 	"testing"
@@ -127,13 +129,13 @@ func TestService_PostComment(t *testing.T) {
 
 			if tt.shouldCallGetPostByID {
 				repo.EXPECT().
-					GetPostByID(ctx, tt.input.PostID).
+					GetPostByID(derivedFrom(ctx), tt.input.PostID).
 					Return(tt.postByIdMockBehavior.output.post, tt.postByIdMockBehavior.output.err)
 			}
 
 			if !tt.wantErr && tt.want != nil {
 				repo.EXPECT().
-					PostComment(ctx, tt.input).
+					PostComment(derivedFrom(ctx), tt.input).
 					Return(tt.want, nil)
 			}
 			s := &Service{
@@ -199,3 +201,663 @@ func TestService_CreatePost(t *testing.T) {
 		})
 	}
 }
+
+func TestService_CreatePostTags(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		input    model.CreatePostInput
+		wantTags []string
+		wantErr  bool
+	}{
+		{
+			name: "tags are extracted from content",
+			input: model.CreatePostInput{
+				Content: "Hello #Golang and #graphql, again #golang",
+			},
+			wantTags: []string{"golang", "graphql"},
+		},
+		{
+			name: "explicit tags take precedence over content",
+			input: model.CreatePostInput{
+				Content: "Hello #golang",
+				Tags:    []string{"#News", "news"},
+			},
+			wantTags: []string{"news"},
+		},
+		{
+			name: "content without hashtags",
+			input: model.CreatePostInput{
+				Content: "Hello",
+			},
+			wantTags: []string{},
+		},
+		{
+			name: "invalid explicit tag",
+			input: model.CreatePostInput{
+				Content: "Hello",
+				Tags:    []string{"bad tag"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc, ctx := gomock.WithContext(context.Background(), t)
+			repo := serviceMock.NewMockRepository(mc)
+
+			if !tt.wantErr {
+				repo.EXPECT().
+					CreatePost(derivedFrom(ctx), gomock.Cond(func(input model.CreatePostInput) bool {
+						return assert.ObjectsAreEqual(tt.wantTags, input.Tags)
+					})).
+					Return(&model.Post{Tags: tt.wantTags}, nil)
+			}
+
+			s := &Service{
+				repo: repo,
+			}
+
+			got, err := s.CreatePost(ctx, tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Service.CreatePost() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && !assert.Equal(t, tt.wantTags, got.Tags) {
+				t.Errorf("Service.CreatePost() tags = %v, want %v", got.Tags, tt.wantTags)
+			}
+		})
+	}
+}
+
+func TestService_GetPostByIDVisibility(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		post    *model.Post
+		user    *auth.User
+		wantErr bool
+	}{
+		{
+			name: "published post is visible to everyone",
+			post: &model.Post{ID: "1", AuthorID: "1", Status: model.PostStatusPublished},
+		},
+		{
+			name:    "draft is hidden from anonymous callers",
+			post:    &model.Post{ID: "1", AuthorID: "1", Status: model.PostStatusDraft},
+			wantErr: true,
+		},
+		{
+			name:    "scheduled post is hidden from other users",
+			post:    &model.Post{ID: "1", AuthorID: "1", Status: model.PostStatusScheduled},
+			user:    &auth.User{ID: "2"},
+			wantErr: true,
+		},
+		{
+			name: "draft is visible to its author",
+			post: &model.Post{ID: "1", AuthorID: "1", Status: model.PostStatusDraft},
+			user: &auth.User{ID: "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc, ctx := gomock.WithContext(context.Background(), t)
+			repo := serviceMock.NewMockRepository(mc)
+
+			if tt.user != nil {
+				ctx = auth.WithUser(ctx, *tt.user)
+			}
+
+			repo.EXPECT().
+				GetPostByID(derivedFrom(ctx), tt.post.ID).
+				Return(tt.post, nil)
+
+			s := &Service{
+				repo: repo,
+			}
+
+			got, err := s.GetPostByID(ctx, tt.post.ID)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Service.GetPostByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && !assert.Equal(t, tt.post, got) {
+				t.Errorf("Service.GetPostByID() = %v, want %v", got, tt.post)
+			}
+		})
+	}
+}
+
+func TestService_SetPostPinned(t *testing.T) {
+	post := &model.Post{ID: "1", AuthorID: "1", Status: model.PostStatusPublished}
+
+	tests := []struct {
+		name    string
+		user    *auth.User
+		wantErr bool
+	}{
+		{
+			name: "moderator pins any post",
+			user: &auth.User{ID: "2", Role: auth.RoleModerator},
+		},
+		{
+			name: "author pins own post",
+			user: &auth.User{ID: "1", Role: auth.RoleUser},
+		},
+		{
+			name:    "other users cannot pin",
+			user:    &auth.User{ID: "2", Role: auth.RoleUser},
+			wantErr: true,
+		},
+		{
+			name:    "anonymous callers cannot pin",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc, ctx := gomock.WithContext(context.Background(), t)
+			repo := serviceMock.NewMockRepository(mc)
+
+			if tt.user != nil {
+				ctx = auth.WithUser(ctx, *tt.user)
+			}
+
+			repo.EXPECT().
+				GetPostByID(derivedFrom(ctx), post.ID).
+				Return(post, nil)
+
+			if !tt.wantErr {
+				repo.EXPECT().
+					SetPostPinned(derivedFrom(ctx), post.ID, true).
+					Return(&model.Post{ID: post.ID, IsPinned: true}, nil)
+			}
+
+			s := &Service{
+				repo: repo,
+			}
+
+			_, err := s.SetPostPinned(ctx, post.ID, true)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Service.SetPostPinned() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_PostCommentLockedThread(t *testing.T) {
+	parentID := "10"
+
+	tests := []struct {
+		name    string
+		parent  *model.Comment
+		wantErr bool
+	}{
+		{
+			name:   "reply to an open thread",
+			parent: &model.Comment{ID: parentID, PostID: "1"},
+		},
+		{
+			name:    "reply to a locked thread",
+			parent:  &model.Comment{ID: parentID, PostID: "1", IsLocked: true},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc, ctx := gomock.WithContext(context.Background(), t)
+			repo := serviceMock.NewMockRepository(mc)
+
+			input := model.PostCommentInput{
+				PostID:          "1",
+				ParentCommentID: &parentID,
+				Content:         "Test reply",
+			}
+
+			repo.EXPECT().
+				GetPostByID(derivedFrom(ctx), input.PostID).
+				Return(&model.Post{ID: "1", AreCommentsAllowed: true, Status: model.PostStatusPublished}, nil)
+			repo.EXPECT().
+				GetCommentByID(derivedFrom(ctx), parentID).
+				Return(tt.parent, nil)
+
+			if !tt.wantErr {
+				repo.EXPECT().
+					PostComment(derivedFrom(ctx), input).
+					Return(&model.Comment{ID: "11"}, nil)
+			}
+
+			s := &Service{
+				repo: repo,
+			}
+
+			_, err := s.PostComment(ctx, input)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Service.PostComment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_PutPostVersionConflict(t *testing.T) {
+	content := "updated"
+
+	tests := []struct {
+		name            string
+		expectedVersion int32
+		repoErr         error
+		wantCurrent     int32
+	}{
+		{
+			name:            "matching version",
+			expectedVersion: 3,
+		},
+		{
+			name:            "stale version",
+			expectedVersion: 2,
+			repoErr:         &model.VersionConflictError{Current: 3},
+			wantCurrent:     3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc, ctx := gomock.WithContext(context.Background(), t)
+			repo := serviceMock.NewMockRepository(mc)
+			ctx = auth.WithUser(ctx, auth.User{ID: "1", Role: auth.RoleUser})
+
+			input := model.PutPostInput{
+				ID:              "1",
+				Content:         &content,
+				Tags:            []string{},
+				ExpectedVersion: &tt.expectedVersion,
+			}
+
+			repo.EXPECT().
+				GetPostByID(derivedFrom(ctx), input.ID).
+				Return(&model.Post{ID: input.ID, AuthorID: "1", Status: model.PostStatusPublished, Version: 3}, nil)
+
+			var post *model.Post
+			if tt.repoErr == nil {
+				post = &model.Post{ID: input.ID, Content: content, Version: tt.expectedVersion + 1}
+			}
+
+			repo.EXPECT().
+				PutPost(derivedFrom(ctx), input).
+				Return(post, tt.repoErr)
+
+			s := &Service{
+				repo: repo,
+			}
+
+			_, err := s.PutPost(ctx, input)
+
+			var conflict *model.VersionConflictError
+			if tt.repoErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.ErrorAs(t, err, &conflict) {
+				assert.Equal(t, tt.wantCurrent, conflict.Current)
+			}
+		})
+	}
+}
+
+func TestService_PutPostAuthor(t *testing.T) {
+	draft := &model.Post{ID: "1", AuthorID: "1", Status: model.PostStatusDraft}
+	content, published := "edited", model.PostStatusPublished
+
+	tests := []struct {
+		name    string
+		user    *auth.User
+		post    *model.Post
+		input   model.PutPostInput
+		wantErr error
+	}{
+		{
+			name:  "author edits own draft",
+			user:  &auth.User{ID: "1", Role: auth.RoleUser},
+			post:  draft,
+			input: model.PutPostInput{ID: draft.ID, Content: &content},
+		},
+		{
+			name:  "moderator publishes any draft",
+			user:  &auth.User{ID: "2", Role: auth.RoleModerator},
+			post:  draft,
+			input: model.PutPostInput{ID: draft.ID, Status: &published},
+		},
+		{
+			name:    "other users cannot edit a draft",
+			user:    &auth.User{ID: "2", Role: auth.RoleUser},
+			post:    draft,
+			input:   model.PutPostInput{ID: draft.ID, Content: &content},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "other users cannot publish a draft",
+			user:    &auth.User{ID: "2", Role: auth.RoleUser},
+			post:    draft,
+			input:   model.PutPostInput{ID: draft.ID, Status: &published},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "anonymous callers cannot edit",
+			post:    draft,
+			input:   model.PutPostInput{ID: draft.ID, Content: &content},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "missing post",
+			user:    &auth.User{ID: "1", Role: auth.RoleUser},
+			input:   model.PutPostInput{ID: "2", Content: &content},
+			wantErr: ErrPostNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc, ctx := gomock.WithContext(context.Background(), t)
+			repo := serviceMock.NewMockRepository(mc)
+
+			if tt.user != nil {
+				ctx = auth.WithUser(ctx, *tt.user)
+			}
+
+			repo.EXPECT().
+				GetPostByID(derivedFrom(ctx), tt.input.ID).
+				Return(tt.post, nil)
+
+			if tt.wantErr == nil {
+				repo.EXPECT().
+					PutPost(derivedFrom(ctx), gomock.Any()).
+					Return(&model.Post{ID: tt.input.ID}, nil)
+			}
+
+			s := &Service{
+				repo: repo,
+			}
+
+			_, err := s.PutPost(ctx, tt.input)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestService_CreatePostIdempotent(t *testing.T) {
+	const (
+		key   = "retry-1"
+		scope = "createPost:1"
+	)
+
+	tests := []struct {
+		name     string
+		storedID string
+		// winner is the post stored by a concurrent retry, if any.
+		winner  *model.Post
+		deleted bool
+		wantID  string
+		wantErr error
+	}{
+		{
+			name:   "first request creates the post",
+			wantID: "5",
+		},
+		{
+			name:     "retry returns the original post",
+			storedID: "3",
+			wantID:   "3",
+		},
+		{
+			name:     "retry after the post was deleted",
+			storedID: "3",
+			deleted:  true,
+			wantErr:  ErrIdempotentResultDeleted,
+		},
+		{
+			name:   "concurrent retry keeps the winner",
+			winner: &model.Post{ID: "4"},
+			wantID: "4",
+		},
+		{
+			name:    "concurrent retry after the winner was deleted",
+			deleted: true,
+			wantErr: ErrIdempotentResultDeleted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc, ctx := gomock.WithContext(context.Background(), t)
+			repo := serviceMock.NewMockRepository(mc)
+
+			ctx = idempotency.WithKey(ctx, key)
+
+			repo.EXPECT().
+				GetIdempotencyKey(derivedFrom(ctx), scope, key).
+				Return(tt.storedID, nil)
+
+			if tt.storedID != "" {
+				stored := &model.Post{ID: tt.storedID}
+				if tt.deleted {
+					stored = nil
+				}
+				repo.EXPECT().
+					GetPostByID(derivedFrom(ctx), tt.storedID).
+					Return(stored, nil)
+			} else {
+				created, output := true, &model.Post{ID: "5"}
+				if tt.winner != nil || tt.deleted {
+					created, output = false, tt.winner
+				}
+				repo.EXPECT().
+					CreatePostOnce(derivedFrom(ctx), gomock.Any(), scope, key, gomock.Any()).
+					Return(output, created, nil)
+			}
+
+			s := &Service{
+				repo: repo,
+			}
+
+			post, err := s.CreatePost(ctx, model.CreatePostInput{AuthorID: "1", Content: "hello"})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantID, post.ID)
+			}
+		})
+	}
+}
+
+func TestService_CreateWebhook(t *testing.T) {
+	moderator := &auth.User{ID: "1", Role: auth.RoleModerator}
+
+	tests := []struct {
+		name    string
+		user    *auth.User
+		input   model.CreateWebhookInput
+		wantErr error
+	}{
+		{
+			name: "moderator registers a webhook",
+			user: moderator,
+			input: model.CreateWebhookInput{
+				URL:    "https://bot.example.com/hook",
+				Secret: "secret",
+				Events: []model.WebhookEvent{model.WebhookEventPostCreated, model.WebhookEventPostCreated},
+			},
+		},
+		{
+			name: "other users cannot register webhooks",
+			user: &auth.User{ID: "2", Role: auth.RoleUser},
+			input: model.CreateWebhookInput{
+				URL:    "https://bot.example.com/hook",
+				Secret: "secret",
+				Events: []model.WebhookEvent{model.WebhookEventPostCreated},
+			},
+			wantErr: ErrForbidden,
+		},
+		{
+			name: "url must be absolute http",
+			user: moderator,
+			input: model.CreateWebhookInput{
+				URL:    "ftp://bot.example.com/hook",
+				Secret: "secret",
+				Events: []model.WebhookEvent{model.WebhookEventPostCreated},
+			},
+			wantErr: ErrIncorrectWebhookURL,
+		},
+		{
+			name: "events are required",
+			user: moderator,
+			input: model.CreateWebhookInput{
+				URL:    "https://bot.example.com/hook",
+				Secret: "secret",
+			},
+			wantErr: ErrIncorrectWebhookEvents,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc, ctx := gomock.WithContext(context.Background(), t)
+			repo := serviceMock.NewMockRepository(mc)
+
+			ctx = auth.WithUser(ctx, *tt.user)
+
+			if tt.wantErr == nil {
+				repo.EXPECT().
+					CreateWebhook(derivedFrom(ctx), model.CreateWebhookInput{
+						URL:    tt.input.URL,
+						Secret: tt.input.Secret,
+						Events: []model.WebhookEvent{model.WebhookEventPostCreated},
+					}).
+					Return(&model.Webhook{ID: "1"}, nil)
+			}
+
+			s := &Service{
+				repo: repo,
+			}
+
+			_, err := s.CreateWebhook(ctx, tt.input)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+// derivedFrom matches the contexts derived from ctx, such as the ones
+// carrying the span of a service method.
+func derivedFrom(ctx context.Context) gomock.Matcher {
+	return gomock.Cond(func(child context.Context) bool {
+		return child.Done() == ctx.Done()
+	})
+}
+
+func TestService_SetLimits(t *testing.T) {
+	mc, ctx := gomock.WithContext(context.Background(), t)
+	s := New(serviceMock.NewMockRepository(mc), WithLimits(Limits{PostLen: 5}))
+
+	_, err := s.CreatePost(ctx, model.CreatePostInput{Content: "too long"})
+	assert.ErrorIs(t, err, ErrIncorrectPostLen)
+
+	_, err = s.PostComment(ctx, model.PostCommentInput{Content: string(make([]byte, 2001))})
+	assert.ErrorIs(t, err, ErrIncorrectCommentLen, "unset limits keep the defaults")
+
+	// The service is used by value, the new limits apply to its copies.
+	copied := *s
+	s.SetLimits(Limits{PostLen: 5, CommentLen: 3})
+
+	_, err = copied.PutComment(ctx, model.PutCommentInput{Content: "four"})
+	assert.ErrorIs(t, err, ErrIncorrectCommentLen)
+}
+
+func TestService_EditContent(t *testing.T) {
+	empty, tooLong := "", string(make([]byte, DefaultLimits.PostLen+1))
+
+	tests := []struct {
+		name    string
+		limits  *Limits
+		edit    func(ctx context.Context, s *Service) error
+		wantErr error
+	}{
+		{
+			name: "empty post",
+			edit: func(ctx context.Context, s *Service) error {
+				_, err := s.PutPost(ctx, model.PutPostInput{ID: "1", Content: &empty})
+				return err
+			},
+			wantErr: ErrIncorrectPostLen,
+		},
+		{
+			name: "too long post",
+			edit: func(ctx context.Context, s *Service) error {
+				_, err := s.PutPost(ctx, model.PutPostInput{ID: "1", Content: &tooLong})
+				return err
+			},
+			wantErr: ErrIncorrectPostLen,
+		},
+		{
+			name: "empty comment",
+			edit: func(ctx context.Context, s *Service) error {
+				_, err := s.PutComment(ctx, model.PutCommentInput{ID: "1"})
+				return err
+			},
+			wantErr: ErrIncorrectContentLen,
+		},
+		{
+			name: "too long comment",
+			edit: func(ctx context.Context, s *Service) error {
+				_, err := s.PutComment(ctx, model.PutCommentInput{ID: "1", Content: string(make([]byte, DefaultLimits.CommentLen+1))})
+				return err
+			},
+			wantErr: ErrIncorrectCommentLen,
+		},
+		{
+			name:   "post over a changed limit",
+			limits: &Limits{PostLen: 5},
+			edit: func(ctx context.Context, s *Service) error {
+				content := "edited"
+				_, err := s.PutPost(ctx, model.PutPostInput{ID: "1", Content: &content})
+				return err
+			},
+			wantErr: ErrIncorrectPostLen,
+		},
+		{
+			name:   "comment over a changed limit",
+			limits: &Limits{CommentLen: 5},
+			edit: func(ctx context.Context, s *Service) error {
+				_, err := s.PutComment(ctx, model.PutCommentInput{ID: "1", Content: "edited"})
+				return err
+			},
+			wantErr: ErrIncorrectCommentLen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc, ctx := gomock.WithContext(context.Background(), t)
+			s := New(serviceMock.NewMockRepository(mc))
+
+			if tt.limits != nil {
+				s.SetLimits(*tt.limits)
+			}
+
+			assert.ErrorIs(t, tt.edit(ctx, s), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ozon/internal/transport/graph/model"
+)
+
+const (
+	maxTagsPerPost = 10
+	maxTagLen      = 50
+	trendingLimit  = 10
+)
+
+var (
+	hashtagPattern = regexp.MustCompile(`#([\p{L}\p{N}_]+)`)
+	tagPattern     = regexp.MustCompile(`^[\p{L}\p{N}_]+$`)
+)
+
+var trendingWindows = map[model.TrendingWindow]time.Duration{
+	model.TrendingWindowHour:  time.Hour,
+	model.TrendingWindowDay:   24 * time.Hour,
+	model.TrendingWindowWeek:  7 * 24 * time.Hour,
+	model.TrendingWindowMonth: 30 * 24 * time.Hour,
+}
+
+// extractTags returns the hashtags mentioned in content without the leading '#'.
+func extractTags(content string) []string {
+	var tags []string
+	for _, match := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		tags = append(tags, match[1])
+	}
+
+	return tags
+}
+
+// normalizeTags lowercases tags, strips a leading '#', drops duplicates and
+// validates the result against the per-post limits.
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(tags))
+	output := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLen || !tagPattern.MatchString(tag) {
+			return nil, ErrIncorrectTag
+		}
+
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		output = append(output, tag)
+	}
+
+	if len(output) > maxTagsPerPost {
+		return nil, ErrTooManyTags
+	}
+
+	return output, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"net/url"
+
+	"ozon/internal/auth"
+	"ozon/internal/tracing"
+	"ozon/internal/transport/graph/model"
+)
+
+// CreateWebhook registers a receiver for the given events. Moderators only.
+func (s Service) CreateWebhook(ctx context.Context, input model.CreateWebhookInput) (_ *model.Webhook, err error) {
+	ctx, span := tracer.Start(ctx, "Service.CreateWebhook")
+	defer func() { tracing.End(span, err) }()
+	if !auth.IsModerator(ctx) {
+		return nil, ErrForbidden
+	}
+
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrIncorrectWebhookURL
+	}
+
+	if input.Secret == "" {
+		return nil, ErrIncorrectWebhookSecret
+	}
+
+	seen := make(map[model.WebhookEvent]struct{}, len(input.Events))
+	events := make([]model.WebhookEvent, 0, len(input.Events))
+	for _, event := range input.Events {
+		if !event.IsValid() {
+			return nil, ErrIncorrectWebhookEvents
+		}
+		if _, ok := seen[event]; ok {
+			continue
+		}
+		seen[event] = struct{}{}
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		return nil, ErrIncorrectWebhookEvents
+	}
+	input.Events = events
+
+	return s.repo.CreateWebhook(ctx, input)
+}
+
+// DeleteWebhook removes a webhook together with its delivery log. Moderators only.
+func (s Service) DeleteWebhook(ctx context.Context, id string) (_ bool, err error) {
+	ctx, span := tracer.Start(ctx, "Service.DeleteWebhook")
+	defer func() { tracing.End(span, err) }()
+	if !auth.IsModerator(ctx) {
+		return false, ErrForbidden
+	}
+
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// GetWebhooks lists the registered webhooks. Moderators only.
+func (s Service) GetWebhooks(ctx context.Context) (_ []*model.Webhook, err error) {
+	ctx, span := tracer.Start(ctx, "Service.GetWebhooks")
+	defer func() { tracing.End(span, err) }()
+	if !auth.IsModerator(ctx) {
+		return nil, ErrForbidden
+	}
+
+	return s.repo.GetWebhooks(ctx)
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook, newest first. Moderators only.
+func (s Service) GetWebhookDeliveries(ctx context.Context, webhookID string, first int32, status *model.DeliveryStatus) (_ []*model.WebhookDelivery, err error) {
+	ctx, span := tracer.Start(ctx, "Service.GetWebhookDeliveries")
+	defer func() { tracing.End(span, err) }()
+	if !auth.IsModerator(ctx) {
+		return nil, ErrForbidden
+	}
+
+	if first == 0 {
+		first = defaultPageSize
+	}
+
+	return s.repo.GetWebhookDeliveries(ctx, webhookID, first, status)
+}
@@ -0,0 +1,66 @@
+package settings
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"ozon/internal/ratelimit"
+	"ozon/internal/service"
+)
+
+// Features switches optional parts of the public API on and off.
+type Features struct {
+	Introspection bool `mapstructure:"introspection" json:"introspection"`
+	Playground    bool `mapstructure:"playground" json:"playground"`
+}
+
+// Settings are the part of the config that is safe to change while the
+// server is running.
+type Settings struct {
+	LogLevel  string           `json:"logLevel"`
+	Limits    service.Limits   `json:"limits"`
+	RateLimit ratelimit.Config `json:"rateLimit"`
+	Features  Features         `json:"features"`
+}
+
+// Store holds the current settings. Readers always see a complete set, either
+// the one before an update or the one after it.
+type Store struct {
+	current atomic.Pointer[Settings]
+
+	mu       sync.Mutex
+	handlers []func(Settings)
+}
+
+func NewStore(s Settings) *Store {
+	store := &Store{}
+	store.current.Store(&s)
+
+	return store
+}
+
+// Load returns the current settings.
+func (s *Store) Load() Settings {
+	return *s.current.Load()
+}
+
+// OnChange registers a function to apply the settings of every later update.
+func (s *Store) OnChange(f func(Settings)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers = append(s.handlers, f)
+}
+
+// Update replaces the settings and applies them in the order the handlers
+// were registered. Concurrent updates are applied one after another.
+func (s *Store) Update(next Settings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.current.Store(&next)
+
+	for _, f := range s.handlers {
+		f(next)
+	}
+}
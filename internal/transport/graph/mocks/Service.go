@@ -15,6 +15,66 @@ type Service struct {
 	mock.Mock
 }
 
+// CreatePost provides a mock function with given fields: ctx, input
+func (_m *Service) CreatePost(ctx context.Context, input model.CreatePostInput) (*model.Post, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePost")
+	}
+
+	var r0 *model.Post
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.CreatePostInput) (*model.Post, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.CreatePostInput) *model.Post); ok {
+		r0 = rf(ctx, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Post)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.CreatePostInput) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWebhook provides a mock function with given fields: ctx, input
+func (_m *Service) CreateWebhook(ctx context.Context, input model.CreateWebhookInput) (*model.Webhook, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhook")
+	}
+
+	var r0 *model.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.CreateWebhookInput) (*model.Webhook, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.CreateWebhookInput) *model.Webhook); ok {
+		r0 = rf(ctx, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.CreateWebhookInput) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteComment provides a mock function with given fields: ctx, id
 func (_m *Service) DeleteComment(ctx context.Context, id string) (bool, error) {
 	ret := _m.Called(ctx, id)
@@ -71,9 +131,37 @@ func (_m *Service) DeletePost(ctx context.Context, id string) (bool, error) {
 	return r0, r1
 }
 
-// GetCommentByParentCommentID provides a mock function with given fields: ctx, parentCommentID, first
-func (_m *Service) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32) ([]*model.Comment, error) {
-	ret := _m.Called(ctx, parentCommentID, first)
+// DeleteWebhook provides a mock function with given fields: ctx, id
+func (_m *Service) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWebhook")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCommentByParentCommentID provides a mock function with given fields: ctx, parentCommentID, first, orderBy
+func (_m *Service) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
+	ret := _m.Called(ctx, parentCommentID, first, orderBy)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetCommentByParentCommentID")
@@ -81,19 +169,19 @@ func (_m *Service) GetCommentByParentCommentID(ctx context.Context, parentCommen
 
 	var r0 []*model.Comment
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int32) ([]*model.Comment, error)); ok {
-		return rf(ctx, parentCommentID, first)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int32, model.CommentOrder) ([]*model.Comment, error)); ok {
+		return rf(ctx, parentCommentID, first, orderBy)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, int32) []*model.Comment); ok {
-		r0 = rf(ctx, parentCommentID, first)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int32, model.CommentOrder) []*model.Comment); ok {
+		r0 = rf(ctx, parentCommentID, first, orderBy)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*model.Comment)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, int32) error); ok {
-		r1 = rf(ctx, parentCommentID, first)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int32, model.CommentOrder) error); ok {
+		r1 = rf(ctx, parentCommentID, first, orderBy)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -101,9 +189,9 @@ func (_m *Service) GetCommentByParentCommentID(ctx context.Context, parentCommen
 	return r0, r1
 }
 
-// GetCommentByPostID provides a mock function with given fields: ctx, postID, first
-func (_m *Service) GetCommentByPostID(ctx context.Context, postID string, first int32) ([]*model.Comment, error) {
-	ret := _m.Called(ctx, postID, first)
+// GetCommentByPostID provides a mock function with given fields: ctx, postID, first, orderBy
+func (_m *Service) GetCommentByPostID(ctx context.Context, postID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
+	ret := _m.Called(ctx, postID, first, orderBy)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetCommentByPostID")
@@ -111,19 +199,49 @@ func (_m *Service) GetCommentByPostID(ctx context.Context, postID string, first
 
 	var r0 []*model.Comment
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int32) ([]*model.Comment, error)); ok {
-		return rf(ctx, postID, first)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int32, model.CommentOrder) ([]*model.Comment, error)); ok {
+		return rf(ctx, postID, first, orderBy)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, int32) []*model.Comment); ok {
-		r0 = rf(ctx, postID, first)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int32, model.CommentOrder) []*model.Comment); ok {
+		r0 = rf(ctx, postID, first, orderBy)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*model.Comment)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, int32) error); ok {
-		r1 = rf(ctx, postID, first)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int32, model.CommentOrder) error); ok {
+		r1 = rf(ctx, postID, first, orderBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDrafts provides a mock function with given fields: ctx, first
+func (_m *Service) GetDrafts(ctx context.Context, first int32) ([]*model.Post, error) {
+	ret := _m.Called(ctx, first)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDrafts")
+	}
+
+	var r0 []*model.Post
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) ([]*model.Post, error)); ok {
+		return rf(ctx, first)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) []*model.Post); ok {
+		r0 = rf(ctx, first)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Post)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(ctx, first)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -191,29 +309,29 @@ func (_m *Service) GetPostByID(ctx context.Context, id string) (*model.Post, err
 	return r0, r1
 }
 
-// NewComment provides a mock function with given fields: ctx, postID, userID
-func (_m *Service) NewComment(ctx context.Context, postID int32, userID string) (<-chan *model.Comment, error) {
-	ret := _m.Called(ctx, postID, userID)
+// GetPostByTag provides a mock function with given fields: ctx, tag, first, after
+func (_m *Service) GetPostByTag(ctx context.Context, tag string, first int32, after *string) ([]*model.Post, error) {
+	ret := _m.Called(ctx, tag, first, after)
 
 	if len(ret) == 0 {
-		panic("no return value specified for NewComment")
+		panic("no return value specified for GetPostByTag")
 	}
 
-	var r0 <-chan *model.Comment
+	var r0 []*model.Post
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int32, string) (<-chan *model.Comment, error)); ok {
-		return rf(ctx, postID, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int32, *string) ([]*model.Post, error)); ok {
+		return rf(ctx, tag, first, after)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int32, string) <-chan *model.Comment); ok {
-		r0 = rf(ctx, postID, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int32, *string) []*model.Post); ok {
+		r0 = rf(ctx, tag, first, after)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(<-chan *model.Comment)
+			r0 = ret.Get(0).([]*model.Post)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int32, string) error); ok {
-		r1 = rf(ctx, postID, userID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int32, *string) error); ok {
+		r1 = rf(ctx, tag, first, after)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -221,29 +339,59 @@ func (_m *Service) NewComment(ctx context.Context, postID int32, userID string)
 	return r0, r1
 }
 
-// PostComment provides a mock function with given fields: ctx, input
-func (_m *Service) PostComment(ctx context.Context, input model.PostCommentInput) (*model.Comment, error) {
-	ret := _m.Called(ctx, input)
+// GetTrendingTags provides a mock function with given fields: ctx, window
+func (_m *Service) GetTrendingTags(ctx context.Context, window model.TrendingWindow) ([]*model.TagCount, error) {
+	ret := _m.Called(ctx, window)
 
 	if len(ret) == 0 {
-		panic("no return value specified for PostComment")
+		panic("no return value specified for GetTrendingTags")
 	}
 
-	var r0 *model.Comment
+	var r0 []*model.TagCount
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, model.PostCommentInput) (*model.Comment, error)); ok {
-		return rf(ctx, input)
+	if rf, ok := ret.Get(0).(func(context.Context, model.TrendingWindow) ([]*model.TagCount, error)); ok {
+		return rf(ctx, window)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, model.PostCommentInput) *model.Comment); ok {
-		r0 = rf(ctx, input)
+	if rf, ok := ret.Get(0).(func(context.Context, model.TrendingWindow) []*model.TagCount); ok {
+		r0 = rf(ctx, window)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Comment)
+			r0 = ret.Get(0).([]*model.TagCount)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, model.PostCommentInput) error); ok {
-		r1 = rf(ctx, input)
+	if rf, ok := ret.Get(1).(func(context.Context, model.TrendingWindow) error); ok {
+		r1 = rf(ctx, window)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookDeliveries provides a mock function with given fields: ctx, webhookID, first, status
+func (_m *Service) GetWebhookDeliveries(ctx context.Context, webhookID string, first int32, status *model.DeliveryStatus) ([]*model.WebhookDelivery, error) {
+	ret := _m.Called(ctx, webhookID, first, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhookDeliveries")
+	}
+
+	var r0 []*model.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int32, *model.DeliveryStatus) ([]*model.WebhookDelivery, error)); ok {
+		return rf(ctx, webhookID, first, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int32, *model.DeliveryStatus) []*model.WebhookDelivery); ok {
+		r0 = rf(ctx, webhookID, first, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int32, *model.DeliveryStatus) error); ok {
+		r1 = rf(ctx, webhookID, first, status)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -251,28 +399,58 @@ func (_m *Service) PostComment(ctx context.Context, input model.PostCommentInput
 	return r0, r1
 }
 
-// PostPost provides a mock function with given fields: ctx, input
-func (_m *Service) PostPost(ctx context.Context, input model.PostPostInput) (*model.Post, error) {
+// GetWebhooks provides a mock function with given fields: ctx
+func (_m *Service) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhooks")
+	}
+
+	var r0 []*model.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*model.Webhook, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*model.Webhook); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PostComment provides a mock function with given fields: ctx, input
+func (_m *Service) PostComment(ctx context.Context, input model.PostCommentInput) (*model.Comment, error) {
 	ret := _m.Called(ctx, input)
 
 	if len(ret) == 0 {
-		panic("no return value specified for PostPost")
+		panic("no return value specified for PostComment")
 	}
 
-	var r0 *model.Post
+	var r0 *model.Comment
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, model.PostPostInput) (*model.Post, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, model.PostCommentInput) (*model.Comment, error)); ok {
 		return rf(ctx, input)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, model.PostPostInput) *model.Post); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, model.PostCommentInput) *model.Comment); ok {
 		r0 = rf(ctx, input)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Post)
+			r0 = ret.Get(0).(*model.Comment)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, model.PostPostInput) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, model.PostCommentInput) error); ok {
 		r1 = rf(ctx, input)
 	} else {
 		r1 = ret.Error(1)
@@ -341,6 +519,124 @@ func (_m *Service) PutPost(ctx context.Context, input model.PutPostInput) (*mode
 	return r0, r1
 }
 
+// RecountComments provides a mock function with given fields: ctx
+func (_m *Service) RecountComments(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecountComments")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetCommentPinned provides a mock function with given fields: ctx, id, pinned
+func (_m *Service) SetCommentPinned(ctx context.Context, id string, pinned bool) (*model.Comment, error) {
+	ret := _m.Called(ctx, id, pinned)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCommentPinned")
+	}
+
+	var r0 *model.Comment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (*model.Comment, error)); ok {
+		return rf(ctx, id, pinned)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *model.Comment); ok {
+		r0 = rf(ctx, id, pinned)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Comment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, id, pinned)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPostPinned provides a mock function with given fields: ctx, id, pinned
+func (_m *Service) SetPostPinned(ctx context.Context, id string, pinned bool) (*model.Post, error) {
+	ret := _m.Called(ctx, id, pinned)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPostPinned")
+	}
+
+	var r0 *model.Post
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (*model.Post, error)); ok {
+		return rf(ctx, id, pinned)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *model.Post); ok {
+		r0 = rf(ctx, id, pinned)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Post)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, id, pinned)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetThreadLocked provides a mock function with given fields: ctx, commentID, locked
+func (_m *Service) SetThreadLocked(ctx context.Context, commentID string, locked bool) (*model.Comment, error) {
+	ret := _m.Called(ctx, commentID, locked)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetThreadLocked")
+	}
+
+	var r0 *model.Comment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (*model.Comment, error)); ok {
+		return rf(ctx, commentID, locked)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *model.Comment); ok {
+		r0 = rf(ctx, commentID, locked)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Comment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, commentID, locked)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewService(t interface {
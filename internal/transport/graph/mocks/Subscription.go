@@ -16,7 +16,7 @@ type Subscription struct {
 }
 
 // Check provides a mock function with given fields: postId
-func (_m *Subscription) Check(postId int32) bool {
+func (_m *Subscription) Check(postId string) bool {
 	ret := _m.Called(postId)
 
 	if len(ret) == 0 {
@@ -24,7 +24,7 @@ func (_m *Subscription) Check(postId int32) bool {
 	}
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(int32) bool); ok {
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
 		r0 = rf(postId)
 	} else {
 		r0 = ret.Get(0).(bool)
@@ -33,25 +33,40 @@ func (_m *Subscription) Check(postId int32) bool {
 	return r0
 }
 
-// Publish provides a mock function with given fields: ctx, comment
-func (_m *Subscription) Publish(ctx context.Context, comment *model.Comment) {
-	_m.Called(ctx, comment)
-}
-
 // Subscribe provides a mock function with given fields: ctx, postId
-func (_m *Subscription) Subscribe(ctx context.Context, postId int32) <-chan *model.Comment {
+func (_m *Subscription) Subscribe(ctx context.Context, postId string) chan *model.Comment {
 	ret := _m.Called(ctx, postId)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Subscribe")
 	}
 
-	var r0 <-chan *model.Comment
-	if rf, ok := ret.Get(0).(func(context.Context, int32) <-chan *model.Comment); ok {
+	var r0 chan *model.Comment
+	if rf, ok := ret.Get(0).(func(context.Context, string) chan *model.Comment); ok {
 		r0 = rf(ctx, postId)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(<-chan *model.Comment)
+			r0 = ret.Get(0).(chan *model.Comment)
+		}
+	}
+
+	return r0
+}
+
+// SubscribePosts provides a mock function with given fields: ctx
+func (_m *Subscription) SubscribePosts(ctx context.Context) chan *model.Post {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SubscribePosts")
+	}
+
+	var r0 chan *model.Post
+	if rf, ok := ret.Get(0).(func(context.Context) chan *model.Post); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *model.Post)
 		}
 	}
 
@@ -59,10 +74,15 @@ func (_m *Subscription) Subscribe(ctx context.Context, postId int32) <-chan *mod
 }
 
 // Unsubscribe provides a mock function with given fields: ctx, postId, ch
-func (_m *Subscription) Unsubscribe(ctx context.Context, postId int32, ch chan *model.Comment) {
+func (_m *Subscription) Unsubscribe(ctx context.Context, postId string, ch chan *model.Comment) {
 	_m.Called(ctx, postId, ch)
 }
 
+// UnsubscribePosts provides a mock function with given fields: ctx, ch
+func (_m *Subscription) UnsubscribePosts(ctx context.Context, ch chan *model.Post) {
+	_m.Called(ctx, ch)
+}
+
 // NewSubscription creates a new instance of Subscription. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewSubscription(t interface {
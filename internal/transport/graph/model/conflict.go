@@ -0,0 +1,13 @@
+package model
+
+import "fmt"
+
+// VersionConflictError is returned by conditional updates when the expected
+// version no longer matches the stored one.
+type VersionConflictError struct {
+	Current int32
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("version conflict: current version is %d", e.Current)
+}
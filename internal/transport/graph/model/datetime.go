@@ -0,0 +1,32 @@
+package model
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// MarshalDateTime serializes the DateTime scalar as an RFC 3339 string in UTC.
+func MarshalDateTime(t time.Time) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		_, _ = io.WriteString(w, strconv.Quote(t.UTC().Format(time.RFC3339Nano)))
+	})
+}
+
+// UnmarshalDateTime accepts an RFC 3339 string with any offset and converts it to UTC.
+func UnmarshalDateTime(v any) (time.Time, error) {
+	str, ok := v.(string)
+	if !ok {
+		return time.Time{}, errors.New("DateTime must be an RFC 3339 string")
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, str)
+	if err != nil {
+		return time.Time{}, errors.New("DateTime must be an RFC 3339 string")
+	}
+
+	return t.UTC(), nil
+}
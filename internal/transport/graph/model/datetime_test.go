@@ -0,0 +1,74 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalDateTime(t *testing.T) {
+	tests := []struct {
+		name string
+		time time.Time
+		want string
+	}{
+		{
+			name: "utc",
+			time: time.Date(2025, 4, 11, 12, 0, 0, 0, time.UTC),
+			want: `"2025-04-11T12:00:00Z"`,
+		},
+		{
+			name: "offset converted to utc",
+			time: time.Date(2025, 4, 11, 15, 0, 0, 0, time.FixedZone("MSK", 3*60*60)),
+			want: `"2025-04-11T12:00:00Z"`,
+		},
+		{
+			name: "fraction of a second",
+			time: time.Date(2025, 4, 11, 12, 0, 0, 500_000_000, time.UTC),
+			want: `"2025-04-11T12:00:00.5Z"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			MarshalDateTime(tt.time).MarshalGQL(&buf)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestUnmarshalDateTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "utc", value: "2025-04-11T12:00:00Z", want: time.Date(2025, 4, 11, 12, 0, 0, 0, time.UTC)},
+		{name: "offset", value: "2025-04-11T15:00:00+03:00", want: time.Date(2025, 4, 11, 12, 0, 0, 0, time.UTC)},
+		{name: "fraction of a second", value: "2025-04-11T12:00:00.5Z", want: time.Date(2025, 4, 11, 12, 0, 0, 500_000_000, time.UTC)},
+		{name: "no offset", value: "2025-04-11T12:00:00", wantErr: true},
+		{name: "date only", value: "2025-04-11", wantErr: true},
+		{name: "not a date", value: "yesterday", wantErr: true},
+		{name: "unix time", value: 1744372800, wantErr: true},
+		{name: "null", value: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalDateTime(tt.value)
+			if tt.wantErr {
+				assert.EqualError(t, err, "DateTime must be an RFC 3339 string")
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, time.UTC, got.Location())
+		})
+	}
+}
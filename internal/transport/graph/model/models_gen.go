@@ -2,28 +2,49 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
 type Comment struct {
-	ID              string     `json:"id"`
-	PostID          string     `json:"postId"`
-	ParentCommentID *string    `json:"parentCommentId,omitempty"`
-	AuthorID        string     `json:"authorId"`
-	Content         string     `json:"content"`
-	CreatedAt       string     `json:"createdAt"`
-	UpdatedAt       string     `json:"updatedAt"`
-	Replies         []*Comment `json:"replies"`
+	ID              string    `json:"id"`
+	PostID          string    `json:"postId"`
+	ParentCommentID *string   `json:"parentCommentId,omitempty"`
+	AuthorID        string    `json:"authorId"`
+	Content         string    `json:"content"`
+	CreatedAt       time.Time `json:"createdAt"`
+	// Null until the first edit.
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	IsPinned   bool       `json:"isPinned"`
+	IsLocked   bool       `json:"isLocked"`
+	ReplyCount int32      `json:"replyCount"`
+	// Incremented on every edit, see expectedVersion of putCommentInput.
+	Version int32      `json:"version"`
+	Replies []*Comment `json:"replies"`
 }
 
 type Mutation struct {
 }
 
 type Post struct {
-	ID                 string     `json:"id"`
-	AuthorID           string     `json:"authorId"`
-	Content            string     `json:"content"`
-	AreCommentsAllowed bool       `json:"areCommentsAllowed"`
-	CreatedAt          string     `json:"createdAt"`
-	UpdatedAt          string     `json:"updatedAt"`
-	Comments           []*Comment `json:"comments"`
+	ID                 string    `json:"id"`
+	AuthorID           string    `json:"authorId"`
+	Content            string    `json:"content"`
+	AreCommentsAllowed bool      `json:"areCommentsAllowed"`
+	CreatedAt          time.Time `json:"createdAt"`
+	// Null until the first edit.
+	UpdatedAt    *time.Time `json:"updatedAt,omitempty"`
+	Status       PostStatus `json:"status"`
+	PublishAt    *time.Time `json:"publishAt,omitempty"`
+	IsPinned     bool       `json:"isPinned"`
+	CommentCount int32      `json:"commentCount"`
+	// Incremented on every edit, see expectedVersion of putPostInput.
+	Version  int32      `json:"version"`
+	Tags     []string   `json:"tags"`
+	Comments []*Comment `json:"comments"`
 }
 
 type Query struct {
@@ -32,10 +53,48 @@ type Query struct {
 type Subscription struct {
 }
 
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int32  `json:"count"`
+}
+
+type Webhook struct {
+	ID        string         `json:"id"`
+	URL       string         `json:"url"`
+	Events    []WebhookEvent `json:"events"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+type WebhookDelivery struct {
+	ID        string         `json:"id"`
+	WebhookID string         `json:"webhookId"`
+	Event     WebhookEvent   `json:"event"`
+	Status    DeliveryStatus `json:"status"`
+	Attempts  int32          `json:"attempts"`
+	// HTTP status of the last attempt, null if the receiver could not be reached.
+	ResponseCode  *int32     `json:"responseCode,omitempty"`
+	LastError     *string    `json:"lastError,omitempty"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty"`
+}
+
 type CreatePostInput struct {
-	AuthorID           string `json:"authorId"`
-	Content            string `json:"content"`
-	AreCommentsAllowed bool   `json:"areCommentsAllowed"`
+	AuthorID           string      `json:"authorId"`
+	Content            string      `json:"content"`
+	AreCommentsAllowed bool        `json:"areCommentsAllowed"`
+	Tags               []string    `json:"tags,omitempty"`
+	Status             *PostStatus `json:"status,omitempty"`
+	PublishAt          *time.Time  `json:"publishAt,omitempty"`
+	// Retries with the same key return the originally created post. Takes precedence over the Idempotency-Key header.
+	ClientMutationID *string `json:"clientMutationId,omitempty"`
+}
+
+type CreateWebhookInput struct {
+	URL string `json:"url"`
+	// Key of the HMAC-SHA256 signature sent in the X-Webhook-Signature header.
+	Secret string         `json:"secret"`
+	Events []WebhookEvent `json:"events"`
 }
 
 type PostCommentInput struct {
@@ -43,15 +102,256 @@ type PostCommentInput struct {
 	ParentCommentID *string `json:"parentCommentId,omitempty"`
 	AuthorID        string  `json:"authorId"`
 	Content         string  `json:"content"`
+	// Retries with the same key return the originally created comment. Takes precedence over the Idempotency-Key header.
+	ClientMutationID *string `json:"clientMutationId,omitempty"`
 }
 
 type PutCommentInput struct {
 	ID      string `json:"id"`
 	Content string `json:"content"`
+	// When set, the update is rejected with a CONFLICT error if the comment has been edited since.
+	ExpectedVersion *int32 `json:"expectedVersion,omitempty"`
 }
 
 type PutPostInput struct {
-	ID                 string  `json:"id"`
-	Content            *string `json:"content,omitempty"`
-	AreCommentsAllowed *bool   `json:"areCommentsAllowed,omitempty"`
+	ID                 string      `json:"id"`
+	Content            *string     `json:"content,omitempty"`
+	AreCommentsAllowed *bool       `json:"areCommentsAllowed,omitempty"`
+	Tags               []string    `json:"tags,omitempty"`
+	Status             *PostStatus `json:"status,omitempty"`
+	PublishAt          *time.Time  `json:"publishAt,omitempty"`
+	// When set, the update is rejected with a CONFLICT error if the post has been edited since.
+	ExpectedVersion *int32 `json:"expectedVersion,omitempty"`
+}
+
+type CommentOrder string
+
+const (
+	// Newest comments first.
+	CommentOrderNewest CommentOrder = "NEWEST"
+	// Oldest comments first.
+	CommentOrderOldest CommentOrder = "OLDEST"
+	// Comments with the largest threads (all nested replies) first.
+	CommentOrderTop CommentOrder = "TOP"
+	// Comments with the most direct replies first.
+	CommentOrderMostReplies CommentOrder = "MOST_REPLIES"
+)
+
+var AllCommentOrder = []CommentOrder{
+	CommentOrderNewest,
+	CommentOrderOldest,
+	CommentOrderTop,
+	CommentOrderMostReplies,
+}
+
+func (e CommentOrder) IsValid() bool {
+	switch e {
+	case CommentOrderNewest, CommentOrderOldest, CommentOrderTop, CommentOrderMostReplies:
+		return true
+	}
+	return false
+}
+
+func (e CommentOrder) String() string {
+	return string(e)
+}
+
+func (e *CommentOrder) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CommentOrder(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CommentOrder", str)
+	}
+	return nil
+}
+
+func (e CommentOrder) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "PENDING"
+	DeliveryStatusSucceeded DeliveryStatus = "SUCCEEDED"
+	// Gave up after the last retry.
+	DeliveryStatusFailed DeliveryStatus = "FAILED"
+)
+
+var AllDeliveryStatus = []DeliveryStatus{
+	DeliveryStatusPending,
+	DeliveryStatusSucceeded,
+	DeliveryStatusFailed,
+}
+
+func (e DeliveryStatus) IsValid() bool {
+	switch e {
+	case DeliveryStatusPending, DeliveryStatusSucceeded, DeliveryStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e DeliveryStatus) String() string {
+	return string(e)
+}
+
+func (e *DeliveryStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DeliveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DeliveryStatus", str)
+	}
+	return nil
+}
+
+func (e DeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type PostStatus string
+
+const (
+	PostStatusDraft     PostStatus = "DRAFT"
+	PostStatusScheduled PostStatus = "SCHEDULED"
+	PostStatusPublished PostStatus = "PUBLISHED"
+)
+
+var AllPostStatus = []PostStatus{
+	PostStatusDraft,
+	PostStatusScheduled,
+	PostStatusPublished,
+}
+
+func (e PostStatus) IsValid() bool {
+	switch e {
+	case PostStatusDraft, PostStatusScheduled, PostStatusPublished:
+		return true
+	}
+	return false
+}
+
+func (e PostStatus) String() string {
+	return string(e)
+}
+
+func (e *PostStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PostStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PostStatus", str)
+	}
+	return nil
+}
+
+func (e PostStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TrendingWindow string
+
+const (
+	TrendingWindowHour  TrendingWindow = "HOUR"
+	TrendingWindowDay   TrendingWindow = "DAY"
+	TrendingWindowWeek  TrendingWindow = "WEEK"
+	TrendingWindowMonth TrendingWindow = "MONTH"
+)
+
+var AllTrendingWindow = []TrendingWindow{
+	TrendingWindowHour,
+	TrendingWindowDay,
+	TrendingWindowWeek,
+	TrendingWindowMonth,
+}
+
+func (e TrendingWindow) IsValid() bool {
+	switch e {
+	case TrendingWindowHour, TrendingWindowDay, TrendingWindowWeek, TrendingWindowMonth:
+		return true
+	}
+	return false
+}
+
+func (e TrendingWindow) String() string {
+	return string(e)
+}
+
+func (e *TrendingWindow) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TrendingWindow(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TrendingWindow", str)
+	}
+	return nil
+}
+
+func (e TrendingWindow) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type WebhookEvent string
+
+const (
+	WebhookEventPostCreated    WebhookEvent = "POST_CREATED"
+	WebhookEventPostPublished  WebhookEvent = "POST_PUBLISHED"
+	WebhookEventPostUpdated    WebhookEvent = "POST_UPDATED"
+	WebhookEventPostDeleted    WebhookEvent = "POST_DELETED"
+	WebhookEventCommentCreated WebhookEvent = "COMMENT_CREATED"
+	WebhookEventCommentUpdated WebhookEvent = "COMMENT_UPDATED"
+	WebhookEventCommentDeleted WebhookEvent = "COMMENT_DELETED"
+)
+
+var AllWebhookEvent = []WebhookEvent{
+	WebhookEventPostCreated,
+	WebhookEventPostPublished,
+	WebhookEventPostUpdated,
+	WebhookEventPostDeleted,
+	WebhookEventCommentCreated,
+	WebhookEventCommentUpdated,
+	WebhookEventCommentDeleted,
+}
+
+func (e WebhookEvent) IsValid() bool {
+	switch e {
+	case WebhookEventPostCreated, WebhookEventPostPublished, WebhookEventPostUpdated, WebhookEventPostDeleted, WebhookEventCommentCreated, WebhookEventCommentUpdated, WebhookEventCommentDeleted:
+		return true
+	}
+	return false
+}
+
+func (e WebhookEvent) String() string {
+	return string(e)
+}
+
+func (e *WebhookEvent) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookEvent(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookEvent", str)
+	}
+	return nil
+}
+
+func (e WebhookEvent) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
@@ -2,6 +2,10 @@ package graph
 
 import (
 	"context"
+	"errors"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"net/http"
+	"ozon/internal/service"
 	"ozon/internal/transport/graph/model"
 	"ozon/pkg/logger"
 )
@@ -19,15 +23,27 @@ type Service interface {
 	DeleteComment(ctx context.Context, id string) (bool, error)
 	GetPost(ctx context.Context, first int32) ([]*model.Post, error)
 	GetPostByID(ctx context.Context, id string) (*model.Post, error)
-	GetCommentByPostID(ctx context.Context, postID string, first int32) ([]*model.Comment, error)
-	GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32) ([]*model.Comment, error)
+	GetCommentByPostID(ctx context.Context, postID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error)
+	GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error)
+	GetPostByTag(ctx context.Context, tag string, first int32, after *string) ([]*model.Post, error)
+	GetTrendingTags(ctx context.Context, window model.TrendingWindow) ([]*model.TagCount, error)
+	GetDrafts(ctx context.Context, first int32) ([]*model.Post, error)
+	SetPostPinned(ctx context.Context, id string, pinned bool) (*model.Post, error)
+	SetCommentPinned(ctx context.Context, id string, pinned bool) (*model.Comment, error)
+	SetThreadLocked(ctx context.Context, commentID string, locked bool) (*model.Comment, error)
+	RecountComments(ctx context.Context) (int64, error)
+	CreateWebhook(ctx context.Context, input model.CreateWebhookInput) (*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) (bool, error)
+	GetWebhooks(ctx context.Context) ([]*model.Webhook, error)
+	GetWebhookDeliveries(ctx context.Context, webhookID string, first int32, status *model.DeliveryStatus) ([]*model.WebhookDelivery, error)
 }
 
 type Subscription interface {
 	Subscribe(ctx context.Context, postId string) chan *model.Comment
 	Unsubscribe(ctx context.Context, postId string, ch chan *model.Comment)
-	Publish(ctx context.Context, comment *model.Comment)
 	Check(postId string) bool
+	SubscribePosts(ctx context.Context) chan *model.Post
+	UnsubscribePosts(ctx context.Context, ch chan *model.Post)
 }
 
 type Resolver struct {
@@ -43,3 +59,45 @@ func NewResolver(srv Service, logs logger.Logger, subscription Subscription) *Re
 		subscription: subscription,
 	}
 }
+
+// errorCode maps service errors to the "code" extension of a GraphQL error,
+// the same way the REST and gRPC APIs map them.
+func errorCode(err error, fallback int) int {
+	switch {
+	case errors.Is(err, service.ErrIncorrectPostLen), errors.Is(err, service.ErrIncorrectCommentLen),
+		errors.Is(err, service.ErrIncorrectContentLen), errors.Is(err, service.ErrIncorrectTag),
+		errors.Is(err, service.ErrTooManyTags), errors.Is(err, service.ErrIncorrectWindow),
+		errors.Is(err, service.ErrIncorrectStatus), errors.Is(err, service.ErrIncorrectPublishAt),
+		errors.Is(err, service.ErrIncorrectOrder), errors.Is(err, service.ErrIncorrectIdempotencyKey),
+		errors.Is(err, service.ErrPostNotPublished):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrPostNotFound), errors.Is(err, service.ErrCommentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrThreadLocked), errors.Is(err, service.ErrIdempotentResultDeleted):
+		return http.StatusConflict
+	default:
+		return fallback
+	}
+}
+
+// conflictError reports a stale expectedVersion together with the version the
+// client should re-read. It returns nil if err is not a version conflict.
+func conflictError(err error, message string) *gqlerror.Error {
+	var conflict *model.VersionConflictError
+	if !errors.As(err, &conflict) {
+		return nil
+	}
+
+	return &gqlerror.Error{
+		Message: message,
+		Extensions: map[string]interface{}{
+			"code":           http.StatusConflict,
+			"reason":         "CONFLICT",
+			"currentVersion": conflict.Current,
+		},
+	}
+}
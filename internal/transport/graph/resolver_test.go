@@ -0,0 +1,33 @@
+package graph
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"ozon/internal/service"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "too long post", err: service.ErrIncorrectPostLen, want: http.StatusBadRequest},
+		{name: "too long comment", err: service.ErrIncorrectCommentLen, want: http.StatusBadRequest},
+		{name: "empty content", err: service.ErrIncorrectContentLen, want: http.StatusBadRequest},
+		{name: "incorrect status", err: service.ErrIncorrectStatus, want: http.StatusBadRequest},
+		{name: "incorrect publication time", err: service.ErrIncorrectPublishAt, want: http.StatusBadRequest},
+		{name: "forbidden", err: service.ErrForbidden, want: http.StatusForbidden},
+		{name: "wrapped not found", err: fmt.Errorf("PsqlPool update posts %w", service.ErrPostNotFound), want: http.StatusNotFound},
+		{name: "unknown", err: fmt.Errorf("connection refused"), want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, errorCode(tt.err, http.StatusInternalServerError))
+		})
+	}
+}
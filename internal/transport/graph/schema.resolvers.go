@@ -7,28 +7,58 @@ package graph
 import (
 	"context"
 	"net/http"
+	"ozon/internal/auth"
 	"ozon/internal/transport/graph/model"
 
 	"github.com/vektah/gqlparser/v2/gqlerror"
 	"go.uber.org/zap"
 )
 
+// Replies is the resolver for the replies field.
+func (r *commentResolver) Replies(ctx context.Context, obj *model.Comment, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
+	if first < 0 {
+		r.logs.For(ctx).Debug("invalid input arguments: first must be positive")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: first must be positive",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	comments, err := r.service.GetCommentByParentCommentID(ctx, obj.ID, first, orderBy)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to fetch comment replies", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to fetch comment replies",
+			Extensions: map[string]interface{}{
+				"code": http.StatusInternalServerError,
+			},
+		}
+	}
+
+	return comments, nil
+}
+
 // CreatePost is the resolver for the createPost field.
 func (r *mutationResolver) CreatePost(ctx context.Context, input model.CreatePostInput) (*model.Post, error) {
-
-	r.logs.Debug("Creating post", zap.Any("input", input))
+	r.logs.For(ctx).Debug("Creating post", zap.Any("input", input))
 
 	post, err := r.service.CreatePost(ctx, model.CreatePostInput{
 		Content:            input.Content,
 		AreCommentsAllowed: input.AreCommentsAllowed,
 		AuthorID:           input.AuthorID,
+		Tags:               input.Tags,
+		Status:             input.Status,
+		PublishAt:          input.PublishAt,
+		ClientMutationID:   input.ClientMutationID,
 	})
 	if err != nil {
-		r.logs.Error("failed to create post", zap.String("err", err.Error()))
+		r.logs.For(ctx).Error("failed to create post", zap.String("err", err.Error()))
 		return nil, &gqlerror.Error{
 			Message: "failed to create post",
 			Extensions: map[string]interface{}{
-				"code": http.StatusBadRequest,
+				"code": errorCode(err, http.StatusBadRequest),
 			},
 		}
 	}
@@ -38,35 +68,33 @@ func (r *mutationResolver) CreatePost(ctx context.Context, input model.CreatePos
 
 // PostComment is the resolver for the postComment field.
 func (r *mutationResolver) PostComment(ctx context.Context, input model.PostCommentInput) (*model.Comment, error) {
-
-	r.logs.Debug("Creating comment", zap.Any("input", input))
+	r.logs.For(ctx).Debug("Creating comment", zap.Any("input", input))
 
 	comment, err := r.service.PostComment(ctx, model.PostCommentInput{
-		PostID:          input.PostID,
-		ParentCommentID: input.ParentCommentID,
-		Content:         input.Content,
-		AuthorID:        input.AuthorID,
+		PostID:           input.PostID,
+		ParentCommentID:  input.ParentCommentID,
+		Content:          input.Content,
+		AuthorID:         input.AuthorID,
+		ClientMutationID: input.ClientMutationID,
 	})
 
 	if err != nil {
-		r.logs.Error("failed to create comment", zap.String("err", err.Error()))
+		r.logs.For(ctx).Error("failed to create comment", zap.String("err", err.Error()))
 		return nil, &gqlerror.Error{
 			Message: "failed to create comment",
 			Extensions: map[string]interface{}{
-				"code": http.StatusBadRequest,
+				"code": errorCode(err, http.StatusBadRequest),
 			},
 		}
 	}
 
-	r.subscription.Publish(ctx, comment)
-
 	return comment, nil
 }
 
 // PutPost is the resolver for the putPost field.
 func (r *mutationResolver) PutPost(ctx context.Context, input model.PutPostInput) (*model.Post, error) {
 	if input.ID == "" {
-		r.logs.Debug("invalid input arguments: missing post ID")
+		r.logs.For(ctx).Debug("invalid input arguments: missing post ID")
 		return nil, &gqlerror.Error{
 			Message: "invalid argument: missing post ID",
 			Extensions: map[string]interface{}{
@@ -75,25 +103,18 @@ func (r *mutationResolver) PutPost(ctx context.Context, input model.PutPostInput
 		}
 	}
 
-	if input.Content != nil && len(*input.Content) > 2000 {
-		r.logs.Debug("input content is too long")
-		return nil, &gqlerror.Error{
-			Message: "content is too long",
-			Extensions: map[string]interface{}{
-				"code": http.StatusBadRequest,
-			},
-		}
-	}
-
-	r.logs.Debug("Updating post", zap.Any("input", input))
+	r.logs.For(ctx).Debug("Updating post", zap.Any("input", input))
 
 	post, err := r.service.PutPost(ctx, input)
 	if err != nil {
-		r.logs.Error("failed to update post", zap.String("err", err.Error()))
+		r.logs.For(ctx).Error("failed to update post", zap.String("err", err.Error()))
+		if gqlErr := conflictError(err, "post was modified concurrently"); gqlErr != nil {
+			return nil, gqlErr
+		}
 		return nil, &gqlerror.Error{
 			Message: "failed to update post",
 			Extensions: map[string]interface{}{
-				"code": http.StatusInternalServerError,
+				"code": errorCode(err, http.StatusBadRequest),
 			},
 		}
 	}
@@ -104,7 +125,7 @@ func (r *mutationResolver) PutPost(ctx context.Context, input model.PutPostInput
 // PutComment is the resolver for the putComment field.
 func (r *mutationResolver) PutComment(ctx context.Context, input model.PutCommentInput) (*model.Comment, error) {
 	if input.ID == "" {
-		r.logs.Debug("invalid input arguments: missing comment ID")
+		r.logs.For(ctx).Debug("invalid input arguments: missing comment ID")
 		return nil, &gqlerror.Error{
 			Message: "invalid argument: missing comment ID",
 			Extensions: map[string]interface{}{
@@ -113,18 +134,8 @@ func (r *mutationResolver) PutComment(ctx context.Context, input model.PutCommen
 		}
 	}
 
-	if len(input.Content) > 2000 {
-		r.logs.Info("comment is too long")
-		return nil, &gqlerror.Error{
-			Message: "comment is too long",
-			Extensions: map[string]interface{}{
-				"code": http.StatusBadRequest,
-			},
-		}
-	}
-
 	if input.Content == "" {
-		r.logs.Info("invalid input arguments: empty content")
+		r.logs.For(ctx).Info("invalid input arguments: empty content")
 		return nil, &gqlerror.Error{
 			Message: "invalid argument: empty content",
 			Extensions: map[string]interface{}{
@@ -133,15 +144,18 @@ func (r *mutationResolver) PutComment(ctx context.Context, input model.PutCommen
 		}
 	}
 
-	r.logs.Debug("Updating comment", zap.Any("input", input))
+	r.logs.For(ctx).Debug("Updating comment", zap.Any("input", input))
 
 	comment, err := r.service.PutComment(ctx, input)
 	if err != nil {
-		r.logs.Error("failed to update comment", zap.String("err", err.Error()))
+		r.logs.For(ctx).Error("failed to update comment", zap.String("err", err.Error()))
+		if gqlErr := conflictError(err, "comment was modified concurrently"); gqlErr != nil {
+			return nil, gqlErr
+		}
 		return nil, &gqlerror.Error{
 			Message: "failed to update comment",
 			Extensions: map[string]interface{}{
-				"code": http.StatusInternalServerError,
+				"code": errorCode(err, http.StatusBadRequest),
 			},
 		}
 	}
@@ -152,7 +166,7 @@ func (r *mutationResolver) PutComment(ctx context.Context, input model.PutCommen
 // DeletePost is the resolver for the deletePost field.
 func (r *mutationResolver) DeletePost(ctx context.Context, id string) (bool, error) {
 	if id == "" {
-		r.logs.Debug("invalid input arguments: missing post ID")
+		r.logs.For(ctx).Debug("invalid input arguments: missing post ID")
 		return false, &gqlerror.Error{
 			Message: "invalid argument: missing post ID",
 			Extensions: map[string]interface{}{
@@ -161,11 +175,11 @@ func (r *mutationResolver) DeletePost(ctx context.Context, id string) (bool, err
 		}
 	}
 
-	r.logs.Debug("Deleting post", zap.String("id", id))
+	r.logs.For(ctx).Debug("Deleting post", zap.String("id", id))
 
 	success, err := r.service.DeletePost(ctx, id)
 	if err != nil {
-		r.logs.Error("failed to delete post", zap.String("err", err.Error()))
+		r.logs.For(ctx).Error("failed to delete post", zap.String("err", err.Error()))
 		return false, &gqlerror.Error{
 			Message: "failed to delete post",
 			Extensions: map[string]interface{}{
@@ -180,7 +194,7 @@ func (r *mutationResolver) DeletePost(ctx context.Context, id string) (bool, err
 // DeleteComment is the resolver for the deleteComment field.
 func (r *mutationResolver) DeleteComment(ctx context.Context, id string) (bool, error) {
 	if id == "" {
-		r.logs.Debug("invalid input arguments: missing comment ID")
+		r.logs.For(ctx).Debug("invalid input arguments: missing comment ID")
 		return false, &gqlerror.Error{
 			Message: "invalid argument: missing comment ID",
 			Extensions: map[string]interface{}{
@@ -189,11 +203,11 @@ func (r *mutationResolver) DeleteComment(ctx context.Context, id string) (bool,
 		}
 	}
 
-	r.logs.Debug("Deleting comment", zap.String("id", id))
+	r.logs.For(ctx).Debug("Deleting comment", zap.String("id", id))
 
 	success, err := r.service.DeleteComment(ctx, id)
 	if err != nil {
-		r.logs.Error("failed to delete comment", zap.String("err", err.Error()))
+		r.logs.For(ctx).Error("failed to delete comment", zap.String("err", err.Error()))
 		return false, &gqlerror.Error{
 			Message: "failed to delete comment",
 			Extensions: map[string]interface{}{
@@ -205,10 +219,260 @@ func (r *mutationResolver) DeleteComment(ctx context.Context, id string) (bool,
 	return success, nil
 }
 
+// PinPost is the resolver for the pinPost field.
+func (r *mutationResolver) PinPost(ctx context.Context, id string) (*model.Post, error) {
+	if id == "" {
+		r.logs.For(ctx).Debug("invalid input arguments: missing post ID")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: missing post ID",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	r.logs.For(ctx).Debug("Pinning post", zap.String("id", id))
+
+	post, err := r.service.SetPostPinned(ctx, id, true)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to pin post", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to pin post",
+			Extensions: map[string]interface{}{
+				"code": errorCode(err, http.StatusInternalServerError),
+			},
+		}
+	}
+
+	return post, nil
+}
+
+// UnpinPost is the resolver for the unpinPost field.
+func (r *mutationResolver) UnpinPost(ctx context.Context, id string) (*model.Post, error) {
+	if id == "" {
+		r.logs.For(ctx).Debug("invalid input arguments: missing post ID")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: missing post ID",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	r.logs.For(ctx).Debug("Unpinning post", zap.String("id", id))
+
+	post, err := r.service.SetPostPinned(ctx, id, false)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to unpin post", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to unpin post",
+			Extensions: map[string]interface{}{
+				"code": errorCode(err, http.StatusInternalServerError),
+			},
+		}
+	}
+
+	return post, nil
+}
+
+// PinComment is the resolver for the pinComment field.
+func (r *mutationResolver) PinComment(ctx context.Context, id string) (*model.Comment, error) {
+	if id == "" {
+		r.logs.For(ctx).Debug("invalid input arguments: missing comment ID")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: missing comment ID",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	r.logs.For(ctx).Debug("Pinning comment", zap.String("id", id))
+
+	comment, err := r.service.SetCommentPinned(ctx, id, true)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to pin comment", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to pin comment",
+			Extensions: map[string]interface{}{
+				"code": errorCode(err, http.StatusInternalServerError),
+			},
+		}
+	}
+
+	return comment, nil
+}
+
+// UnpinComment is the resolver for the unpinComment field.
+func (r *mutationResolver) UnpinComment(ctx context.Context, id string) (*model.Comment, error) {
+	if id == "" {
+		r.logs.For(ctx).Debug("invalid input arguments: missing comment ID")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: missing comment ID",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	r.logs.For(ctx).Debug("Unpinning comment", zap.String("id", id))
+
+	comment, err := r.service.SetCommentPinned(ctx, id, false)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to unpin comment", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to unpin comment",
+			Extensions: map[string]interface{}{
+				"code": errorCode(err, http.StatusInternalServerError),
+			},
+		}
+	}
+
+	return comment, nil
+}
+
+// LockThread is the resolver for the lockThread field.
+func (r *mutationResolver) LockThread(ctx context.Context, commentID string) (*model.Comment, error) {
+	if commentID == "" {
+		r.logs.For(ctx).Debug("invalid input arguments: missing comment ID")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: missing comment ID",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	r.logs.For(ctx).Debug("Locking thread", zap.String("commentId", commentID))
+
+	comment, err := r.service.SetThreadLocked(ctx, commentID, true)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to lock thread", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to lock thread",
+			Extensions: map[string]interface{}{
+				"code": errorCode(err, http.StatusInternalServerError),
+			},
+		}
+	}
+
+	return comment, nil
+}
+
+// UnlockThread is the resolver for the unlockThread field.
+func (r *mutationResolver) UnlockThread(ctx context.Context, commentID string) (*model.Comment, error) {
+	if commentID == "" {
+		r.logs.For(ctx).Debug("invalid input arguments: missing comment ID")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: missing comment ID",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	r.logs.For(ctx).Debug("Unlocking thread", zap.String("commentId", commentID))
+
+	comment, err := r.service.SetThreadLocked(ctx, commentID, false)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to unlock thread", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to unlock thread",
+			Extensions: map[string]interface{}{
+				"code": errorCode(err, http.StatusInternalServerError),
+			},
+		}
+	}
+
+	return comment, nil
+}
+
+// RecountComments is the resolver for the recountComments field.
+func (r *mutationResolver) RecountComments(ctx context.Context) (int32, error) {
+	r.logs.For(ctx).Info("Recounting comments")
+
+	fixed, err := r.service.RecountComments(ctx)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to recount comments", zap.String("err", err.Error()))
+		return 0, &gqlerror.Error{
+			Message: "failed to recount comments",
+			Extensions: map[string]interface{}{
+				"code": errorCode(err, http.StatusInternalServerError),
+			},
+		}
+	}
+
+	r.logs.For(ctx).Info("Comment counters recounted", zap.Int64("fixed", fixed))
+
+	return int32(fixed), nil
+}
+
+// CreateWebhook is the resolver for the createWebhook field.
+func (r *mutationResolver) CreateWebhook(ctx context.Context, input model.CreateWebhookInput) (*model.Webhook, error) {
+	r.logs.For(ctx).Info("Creating webhook", zap.String("url", input.URL), zap.Any("events", input.Events))
+
+	webhook, err := r.service.CreateWebhook(ctx, input)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to create webhook", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to create webhook",
+			Extensions: map[string]interface{}{
+				"code": errorCode(err, http.StatusBadRequest),
+			},
+		}
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook is the resolver for the deleteWebhook field.
+func (r *mutationResolver) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	r.logs.For(ctx).Info("Deleting webhook", zap.String("id", id))
+
+	ok, err := r.service.DeleteWebhook(ctx, id)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to delete webhook", zap.String("err", err.Error()))
+		return false, &gqlerror.Error{
+			Message: "failed to delete webhook",
+			Extensions: map[string]interface{}{
+				"code": errorCode(err, http.StatusInternalServerError),
+			},
+		}
+	}
+
+	return ok, nil
+}
+
+// Comments is the resolver for the comments field.
+func (r *postResolver) Comments(ctx context.Context, obj *model.Post, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
+	if first < 0 {
+		r.logs.For(ctx).Debug("invalid input arguments: first must be positive")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: first must be positive",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	comments, err := r.service.GetCommentByPostID(ctx, obj.ID, first, orderBy)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to fetch post comments", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to fetch post comments",
+			Extensions: map[string]interface{}{
+				"code": http.StatusInternalServerError,
+			},
+		}
+	}
+
+	return comments, nil
+}
+
 // GetPost is the resolver for the getPost field.
 func (r *queryResolver) GetPost(ctx context.Context, first int32) ([]*model.Post, error) {
 	if first < 0 {
-		r.logs.Debug("invalid input arguments: first must be positive")
+		r.logs.For(ctx).Debug("invalid input arguments: first must be positive")
 		return nil, &gqlerror.Error{
 			Message: "invalid argument: first must be positive",
 			Extensions: map[string]interface{}{
@@ -217,11 +481,11 @@ func (r *queryResolver) GetPost(ctx context.Context, first int32) ([]*model.Post
 		}
 	}
 
-	r.logs.Debug("Fetching posts", zap.Int32("first", first))
+	r.logs.For(ctx).Debug("Fetching posts", zap.Int32("first", first))
 
 	posts, err := r.service.GetPost(ctx, first)
 	if err != nil {
-		r.logs.Error("failed to fetch posts", zap.String("err", err.Error()))
+		r.logs.For(ctx).Error("failed to fetch posts", zap.String("err", err.Error()))
 		return nil, &gqlerror.Error{
 			Message: "failed to fetch posts",
 			Extensions: map[string]interface{}{
@@ -236,7 +500,7 @@ func (r *queryResolver) GetPost(ctx context.Context, first int32) ([]*model.Post
 // GetPostByID is the resolver for the getPostById field.
 func (r *queryResolver) GetPostByID(ctx context.Context, id string) (*model.Post, error) {
 	if id == "" {
-		r.logs.Debug("invalid input arguments: missing post ID")
+		r.logs.For(ctx).Debug("invalid input arguments: missing post ID")
 		return nil, &gqlerror.Error{
 			Message: "invalid argument: missing post ID",
 			Extensions: map[string]interface{}{
@@ -245,11 +509,11 @@ func (r *queryResolver) GetPostByID(ctx context.Context, id string) (*model.Post
 		}
 	}
 
-	r.logs.Debug("Fetching post by ID", zap.String("id", id))
+	r.logs.For(ctx).Debug("Fetching post by ID", zap.String("id", id))
 
 	post, err := r.service.GetPostByID(ctx, id)
 	if err != nil {
-		r.logs.Error("failed to fetch post by ID", zap.String("err", err.Error()))
+		r.logs.For(ctx).Error("failed to fetch post by ID", zap.String("err", err.Error()))
 		return nil, &gqlerror.Error{
 			Message: "failed to fetch post by ID",
 			Extensions: map[string]interface{}{
@@ -262,9 +526,9 @@ func (r *queryResolver) GetPostByID(ctx context.Context, id string) (*model.Post
 }
 
 // GetCommentByPostID is the resolver for the getCommentByPostId field.
-func (r *queryResolver) GetCommentByPostID(ctx context.Context, postID string, first int32) ([]*model.Comment, error) {
+func (r *queryResolver) GetCommentByPostID(ctx context.Context, postID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
 	if postID == "" {
-		r.logs.Debug("invalid input arguments: missing post ID")
+		r.logs.For(ctx).Debug("invalid input arguments: missing post ID")
 		return nil, &gqlerror.Error{
 			Message: "invalid argument: missing post ID",
 			Extensions: map[string]interface{}{
@@ -274,7 +538,7 @@ func (r *queryResolver) GetCommentByPostID(ctx context.Context, postID string, f
 	}
 
 	if first < 0 {
-		r.logs.Debug("invalid input arguments: first must be positive")
+		r.logs.For(ctx).Debug("invalid input arguments: first must be positive")
 		return nil, &gqlerror.Error{
 			Message: "invalid argument: first must be positive",
 			Extensions: map[string]interface{}{
@@ -283,11 +547,11 @@ func (r *queryResolver) GetCommentByPostID(ctx context.Context, postID string, f
 		}
 	}
 
-	r.logs.Debug("Fetching comments by post ID", zap.String("postID", postID), zap.Int32("first", first))
+	r.logs.For(ctx).Debug("Fetching comments by post ID", zap.String("postID", postID), zap.Int32("first", first), zap.String("orderBy", orderBy.String()))
 
-	comments, err := r.service.GetCommentByPostID(ctx, postID, first)
+	comments, err := r.service.GetCommentByPostID(ctx, postID, first, orderBy)
 	if err != nil {
-		r.logs.Error("failed to fetch comments by post ID", zap.String("err", err.Error()))
+		r.logs.For(ctx).Error("failed to fetch comments by post ID", zap.String("err", err.Error()))
 		return nil, &gqlerror.Error{
 			Message: "failed to fetch comments by post ID",
 			Extensions: map[string]interface{}{
@@ -300,9 +564,9 @@ func (r *queryResolver) GetCommentByPostID(ctx context.Context, postID string, f
 }
 
 // GetCommentByParentCommentID is the resolver for the getCommentByParentCommentId field.
-func (r *queryResolver) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32) ([]*model.Comment, error) {
+func (r *queryResolver) GetCommentByParentCommentID(ctx context.Context, parentCommentID string, first int32, orderBy model.CommentOrder) ([]*model.Comment, error) {
 	if parentCommentID == "" {
-		r.logs.Debug("invalid input arguments: missing parent comment ID")
+		r.logs.For(ctx).Debug("invalid input arguments: missing parent comment ID")
 		return nil, &gqlerror.Error{
 			Message: "invalid argument: missing parent comment ID",
 			Extensions: map[string]interface{}{
@@ -312,7 +576,7 @@ func (r *queryResolver) GetCommentByParentCommentID(ctx context.Context, parentC
 	}
 
 	if first < 0 {
-		r.logs.Debug("invalid input arguments: first must be positive")
+		r.logs.For(ctx).Debug("invalid input arguments: first must be positive")
 		return nil, &gqlerror.Error{
 			Message: "invalid argument: first must be positive",
 			Extensions: map[string]interface{}{
@@ -321,11 +585,11 @@ func (r *queryResolver) GetCommentByParentCommentID(ctx context.Context, parentC
 		}
 	}
 
-	r.logs.Debug("Fetching comments by parent comment ID", zap.String("parentCommentID", parentCommentID), zap.Int32("first", first))
+	r.logs.For(ctx).Debug("Fetching comments by parent comment ID", zap.String("parentCommentID", parentCommentID), zap.Int32("first", first), zap.String("orderBy", orderBy.String()))
 
-	comments, err := r.service.GetCommentByParentCommentID(ctx, parentCommentID, first)
+	comments, err := r.service.GetCommentByParentCommentID(ctx, parentCommentID, first, orderBy)
 	if err != nil {
-		r.logs.Error("failed to fetch comments by parent comment ID", zap.String("err", err.Error()))
+		r.logs.For(ctx).Error("failed to fetch comments by parent comment ID", zap.String("err", err.Error()))
 		return nil, &gqlerror.Error{
 			Message: "failed to fetch comments by parent comment ID",
 			Extensions: map[string]interface{}{
@@ -337,6 +601,146 @@ func (r *queryResolver) GetCommentByParentCommentID(ctx context.Context, parentC
 	return comments, nil
 }
 
+// PostsByTag is the resolver for the postsByTag field.
+func (r *queryResolver) PostsByTag(ctx context.Context, tag string, first int32, after *string) ([]*model.Post, error) {
+	if tag == "" {
+		r.logs.For(ctx).Debug("invalid input arguments: missing tag")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: missing tag",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	if first < 0 {
+		r.logs.For(ctx).Debug("invalid input arguments: first must be positive")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: first must be positive",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	r.logs.For(ctx).Debug("Fetching posts by tag", zap.String("tag", tag), zap.Int32("first", first), zap.Stringp("after", after))
+
+	posts, err := r.service.GetPostByTag(ctx, tag, first, after)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to fetch posts by tag", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to fetch posts by tag",
+			Extensions: map[string]interface{}{
+				"code": http.StatusInternalServerError,
+			},
+		}
+	}
+
+	return posts, nil
+}
+
+// TrendingTags is the resolver for the trendingTags field.
+func (r *queryResolver) TrendingTags(ctx context.Context, window model.TrendingWindow) ([]*model.TagCount, error) {
+	r.logs.For(ctx).Debug("Fetching trending tags", zap.String("window", window.String()))
+
+	tags, err := r.service.GetTrendingTags(ctx, window)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to fetch trending tags", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to fetch trending tags",
+			Extensions: map[string]interface{}{
+				"code": http.StatusInternalServerError,
+			},
+		}
+	}
+
+	return tags, nil
+}
+
+// MyDrafts is the resolver for the myDrafts field.
+func (r *queryResolver) MyDrafts(ctx context.Context, first int32) ([]*model.Post, error) {
+	if _, ok := auth.FromContext(ctx); !ok {
+		r.logs.For(ctx).Debug("unauthenticated request for drafts")
+		return nil, &gqlerror.Error{
+			Message: "unauthenticated",
+			Extensions: map[string]interface{}{
+				"code": http.StatusUnauthorized,
+			},
+		}
+	}
+
+	if first < 0 {
+		r.logs.For(ctx).Debug("invalid input arguments: first must be positive")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: first must be positive",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	r.logs.For(ctx).Debug("Fetching drafts", zap.Int32("first", first))
+
+	posts, err := r.service.GetDrafts(ctx, first)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to fetch drafts", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to fetch drafts",
+			Extensions: map[string]interface{}{
+				"code": http.StatusInternalServerError,
+			},
+		}
+	}
+
+	return posts, nil
+}
+
+// Webhooks is the resolver for the webhooks field.
+func (r *queryResolver) Webhooks(ctx context.Context) ([]*model.Webhook, error) {
+	r.logs.For(ctx).Debug("Fetching webhooks")
+
+	webhooks, err := r.service.GetWebhooks(ctx)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to fetch webhooks", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to fetch webhooks",
+			Extensions: map[string]interface{}{
+				"code": errorCode(err, http.StatusInternalServerError),
+			},
+		}
+	}
+
+	return webhooks, nil
+}
+
+// WebhookDeliveries is the resolver for the webhookDeliveries field.
+func (r *queryResolver) WebhookDeliveries(ctx context.Context, webhookID string, first int32, status *model.DeliveryStatus) ([]*model.WebhookDelivery, error) {
+	if first < 0 {
+		r.logs.For(ctx).Debug("invalid input arguments: first must be positive")
+		return nil, &gqlerror.Error{
+			Message: "invalid argument: first must be positive",
+			Extensions: map[string]interface{}{
+				"code": http.StatusBadRequest,
+			},
+		}
+	}
+
+	r.logs.For(ctx).Debug("Fetching webhook deliveries", zap.String("webhookId", webhookID), zap.Int32("first", first))
+
+	deliveries, err := r.service.GetWebhookDeliveries(ctx, webhookID, first, status)
+	if err != nil {
+		r.logs.For(ctx).Error("failed to fetch webhook deliveries", zap.String("err", err.Error()))
+		return nil, &gqlerror.Error{
+			Message: "failed to fetch webhook deliveries",
+			Extensions: map[string]interface{}{
+				"code": errorCode(err, http.StatusInternalServerError),
+			},
+		}
+	}
+
+	return deliveries, nil
+}
+
 // SubscriptionForComment is the resolver for the subscriptionForComment field.
 func (r *subscriptionResolver) SubscriptionForComment(ctx context.Context, postID string) (<-chan *model.Comment, error) {
 	if !r.subscription.Check(postID) {
@@ -346,28 +750,51 @@ func (r *subscriptionResolver) SubscriptionForComment(ctx context.Context, postI
 		}
 	}
 
-	r.logs.Debug("creating new subscription", zap.String("postId", postID))
+	r.logs.For(ctx).Debug("creating new subscription", zap.String("postId", postID))
 
 	ch := r.subscription.Subscribe(ctx, postID)
 
 	go func() {
 		<-ctx.Done()
-		r.logs.Debug("Unsubscribing from comments", zap.String("postId", postID))
+		r.logs.For(ctx).Debug("Unsubscribing from comments", zap.String("postId", postID))
 		r.subscription.Unsubscribe(ctx, postID, ch)
 	}()
 
 	return ch, nil
 }
 
+// PostCreated is the resolver for the postCreated field.
+func (r *subscriptionResolver) PostCreated(ctx context.Context) (<-chan *model.Post, error) {
+	r.logs.For(ctx).Debug("creating new post subscription")
+
+	ch := r.subscription.SubscribePosts(ctx)
+
+	go func() {
+		<-ctx.Done()
+		r.logs.For(ctx).Debug("Unsubscribing from posts")
+		r.subscription.UnsubscribePosts(ctx, ch)
+	}()
+
+	return ch, nil
+}
+
+// Comment returns CommentResolver implementation.
+func (r *Resolver) Comment() CommentResolver { return &commentResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Post returns PostResolver implementation.
+func (r *Resolver) Post() PostResolver { return &postResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
 // Subscription returns SubscriptionResolver implementation.
 func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
 
+type commentResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type postResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 type subscriptionResolver struct{ *Resolver }
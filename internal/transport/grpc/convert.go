@@ -0,0 +1,165 @@
+package grpc
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"ozon/internal/service"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/api/posts"
+)
+
+var errBadEnum = errors.New("unknown enum value")
+
+var postStatuses = map[model.PostStatus]posts.PostStatus{
+	model.PostStatusDraft:     posts.PostStatus_POST_STATUS_DRAFT,
+	model.PostStatusScheduled: posts.PostStatus_POST_STATUS_SCHEDULED,
+	model.PostStatusPublished: posts.PostStatus_POST_STATUS_PUBLISHED,
+}
+
+var commentOrders = map[posts.CommentOrder]model.CommentOrder{
+	posts.CommentOrder_COMMENT_ORDER_NEWEST:       model.CommentOrderNewest,
+	posts.CommentOrder_COMMENT_ORDER_OLDEST:       model.CommentOrderOldest,
+	posts.CommentOrder_COMMENT_ORDER_TOP:          model.CommentOrderTop,
+	posts.CommentOrder_COMMENT_ORDER_MOST_REPLIES: model.CommentOrderMostReplies,
+}
+
+// grpcStatus maps service errors to gRPC statuses. A version conflict
+// carries the current version in an ErrorInfo detail with reason CONFLICT.
+func grpcStatus(err error) *status.Status {
+	var conflict *model.VersionConflictError
+
+	switch {
+	case errors.As(err, &conflict):
+		st := status.New(codes.Aborted, "version conflict")
+		detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+			Reason:   "CONFLICT",
+			Metadata: map[string]string{"currentVersion": strconv.Itoa(int(conflict.Current))},
+		})
+		if detailErr != nil {
+			return st
+		}
+		return detailed
+	case errors.Is(err, errBadEnum),
+		errors.Is(err, service.ErrIncorrectPostLen), errors.Is(err, service.ErrIncorrectCommentLen),
+		errors.Is(err, service.ErrIncorrectContentLen), errors.Is(err, service.ErrIncorrectTag),
+		errors.Is(err, service.ErrTooManyTags), errors.Is(err, service.ErrIncorrectStatus),
+		errors.Is(err, service.ErrIncorrectPublishAt), errors.Is(err, service.ErrIncorrectOrder),
+		errors.Is(err, service.ErrIncorrectIdempotencyKey):
+		return status.New(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrUnauthenticated):
+		return status.New(codes.Unauthenticated, err.Error())
+	case errors.Is(err, service.ErrForbidden):
+		return status.New(codes.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrPostNotFound), errors.Is(err, service.ErrCommentNotFound):
+		return status.New(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrThreadLocked), errors.Is(err, service.ErrCommentsNotAllowed),
+		errors.Is(err, service.ErrPostNotPublished), errors.Is(err, service.ErrIdempotentResultDeleted):
+		return status.New(codes.FailedPrecondition, err.Error())
+	default:
+		return status.New(codes.Internal, "internal error")
+	}
+}
+
+func fromPostStatus(postStatus posts.PostStatus) (*model.PostStatus, error) {
+	if postStatus == posts.PostStatus_POST_STATUS_UNSPECIFIED {
+		return nil, nil
+	}
+
+	for output, value := range postStatuses {
+		if value == postStatus {
+			return &output, nil
+		}
+	}
+
+	return nil, errBadEnum
+}
+
+func fromCommentOrder(order posts.CommentOrder) (model.CommentOrder, error) {
+	if order == posts.CommentOrder_COMMENT_ORDER_UNSPECIFIED {
+		return "", nil
+	}
+
+	output, ok := commentOrders[order]
+	if !ok {
+		return "", errBadEnum
+	}
+
+	return output, nil
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+
+	output := ts.AsTime()
+	return &output
+}
+
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func toPosts(input []*model.Post) []*posts.Post {
+	output := make([]*posts.Post, 0, len(input))
+	for _, post := range input {
+		if post != nil {
+			output = append(output, toPost(post))
+		}
+	}
+
+	return output
+}
+
+func toPost(post *model.Post) *posts.Post {
+	return &posts.Post{
+		Id:                 post.ID,
+		AuthorId:           post.AuthorID,
+		Content:            post.Content,
+		AreCommentsAllowed: post.AreCommentsAllowed,
+		CreatedAt:          timestamppb.New(post.CreatedAt),
+		UpdatedAt:          toTimestamp(post.UpdatedAt),
+		Status:             postStatuses[post.Status],
+		PublishAt:          toTimestamp(post.PublishAt),
+		IsPinned:           post.IsPinned,
+		CommentCount:       post.CommentCount,
+		Version:            post.Version,
+		Tags:               post.Tags,
+	}
+}
+
+func toComments(input []*model.Comment) []*posts.Comment {
+	output := make([]*posts.Comment, 0, len(input))
+	for _, comment := range input {
+		if comment != nil {
+			output = append(output, toComment(comment))
+		}
+	}
+
+	return output
+}
+
+func toComment(comment *model.Comment) *posts.Comment {
+	return &posts.Comment{
+		Id:              comment.ID,
+		PostId:          comment.PostID,
+		ParentCommentId: comment.ParentCommentID,
+		AuthorId:        comment.AuthorID,
+		Content:         comment.Content,
+		CreatedAt:       timestamppb.New(comment.CreatedAt),
+		UpdatedAt:       toTimestamp(comment.UpdatedAt),
+		IsPinned:        comment.IsPinned,
+		IsLocked:        comment.IsLocked,
+		ReplyCount:      comment.ReplyCount,
+		Version:         comment.Version,
+	}
+}
@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/api/posts"
+)
+
+func TestFromCommentOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   posts.CommentOrder
+		want    model.CommentOrder
+		wantErr error
+	}{
+		{name: "unspecified", order: posts.CommentOrder_COMMENT_ORDER_UNSPECIFIED, want: ""},
+		{name: "newest", order: posts.CommentOrder_COMMENT_ORDER_NEWEST, want: model.CommentOrderNewest},
+		{name: "oldest", order: posts.CommentOrder_COMMENT_ORDER_OLDEST, want: model.CommentOrderOldest},
+		{name: "top", order: posts.CommentOrder_COMMENT_ORDER_TOP, want: model.CommentOrderTop},
+		{name: "most replies", order: posts.CommentOrder_COMMENT_ORDER_MOST_REPLIES, want: model.CommentOrderMostReplies},
+		{name: "unknown", order: 42, wantErr: errBadEnum},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fromCommentOrder(tt.order)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommentOrders_CoverModel(t *testing.T) {
+	var mapped []model.CommentOrder
+	for _, order := range commentOrders {
+		mapped = append(mapped, order)
+	}
+
+	assert.ElementsMatch(t, model.AllCommentOrder, mapped, "every GraphQL comment order has a gRPC value")
+}
@@ -0,0 +1,349 @@
+package grpc
+
+import (
+	"context"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"ozon/internal/auth"
+	"ozon/internal/idempotency"
+	"ozon/internal/ratelimit"
+	"ozon/internal/requestid"
+	"ozon/internal/service"
+	"ozon/internal/transport/graph"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/api/posts"
+	"ozon/pkg/logger"
+)
+
+type Server struct {
+	posts.UnimplementedPostsServer
+
+	service graph.Service
+	log     logger.Logger
+	ps      graph.Subscription
+	limiter *ratelimit.Limiter
+}
+
+type Option func(*Server)
+
+// WithRateLimiter limits the calls per client IP address, sharing the
+// buckets with the HTTP APIs when given the same limiter.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(s *Server) {
+		s.limiter = limiter
+	}
+}
+
+// New returns a gRPC server serving the Posts API over the given service.
+// Comment events of WatchComments are taken from the subscription hub.
+func New(service graph.Service, log logger.Logger, ps graph.Subscription, opts ...Option) *grpc.Server {
+	impl := &Server{service: service, log: log, ps: ps}
+	for _, opt := range opts {
+		opt(impl)
+	}
+
+	s := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(impl.unaryRateLimitInterceptor, impl.unaryMetadataInterceptor),
+		grpc.ChainStreamInterceptor(impl.streamRateLimitInterceptor, impl.streamMetadataInterceptor),
+	)
+
+	posts.RegisterPostsServer(s, impl)
+	reflection.Register(s)
+
+	return s
+}
+
+func (s *Server) ListPosts(ctx context.Context, request *posts.ListPostsRequest) (*posts.ListPostsResponse, error) {
+	output, err := s.service.GetPost(ctx, request.GetOffset())
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	return &posts.ListPostsResponse{Posts: toPosts(output)}, nil
+}
+
+func (s *Server) GetPost(ctx context.Context, request *posts.GetPostRequest) (*posts.Post, error) {
+	post, err := s.service.GetPostByID(ctx, request.GetId())
+	if err == nil && post == nil {
+		err = service.ErrPostNotFound
+	}
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	return toPost(post), nil
+}
+
+func (s *Server) CreatePost(ctx context.Context, request *posts.CreatePostRequest) (*posts.Post, error) {
+	input := model.CreatePostInput{
+		AuthorID:           request.GetAuthorId(),
+		Content:            request.GetContent(),
+		AreCommentsAllowed: request.GetAreCommentsAllowed(),
+		Tags:               request.GetTags(),
+		PublishAt:          fromTimestamp(request.GetPublishAt()),
+		ClientMutationID:   request.ClientMutationId,
+	}
+
+	var err error
+	if input.Status, err = fromPostStatus(request.GetStatus()); err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	post, err := s.service.CreatePost(ctx, input)
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	return toPost(post), nil
+}
+
+func (s *Server) UpdatePost(ctx context.Context, request *posts.UpdatePostRequest) (*posts.Post, error) {
+	input := model.PutPostInput{
+		ID:                 request.GetId(),
+		Content:            request.Content,
+		AreCommentsAllowed: request.AreCommentsAllowed,
+		PublishAt:          fromTimestamp(request.GetPublishAt()),
+		ExpectedVersion:    request.ExpectedVersion,
+	}
+	if request.GetUpdateTags() {
+		input.Tags = append([]string{}, request.GetTags()...)
+	}
+
+	var err error
+	if input.Status, err = fromPostStatus(request.GetStatus()); err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	post, err := s.service.PutPost(ctx, input)
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	return toPost(post), nil
+}
+
+func (s *Server) DeletePost(ctx context.Context, request *posts.DeletePostRequest) (*posts.DeletePostResponse, error) {
+	ok, err := s.service.DeletePost(ctx, request.GetId())
+	if err == nil && !ok {
+		err = service.ErrPostNotFound
+	}
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	return &posts.DeletePostResponse{}, nil
+}
+
+func (s *Server) ListComments(ctx context.Context, request *posts.ListCommentsRequest) (*posts.ListCommentsResponse, error) {
+	orderBy, err := fromCommentOrder(request.GetOrderBy())
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	comments, err := s.service.GetCommentByPostID(ctx, request.GetPostId(), request.GetOffset(), orderBy)
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	return &posts.ListCommentsResponse{Comments: toComments(comments)}, nil
+}
+
+func (s *Server) ListReplies(ctx context.Context, request *posts.ListRepliesRequest) (*posts.ListCommentsResponse, error) {
+	orderBy, err := fromCommentOrder(request.GetOrderBy())
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	comments, err := s.service.GetCommentByParentCommentID(ctx, request.GetCommentId(), request.GetOffset(), orderBy)
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	return &posts.ListCommentsResponse{Comments: toComments(comments)}, nil
+}
+
+func (s *Server) PostComment(ctx context.Context, request *posts.PostCommentRequest) (*posts.Comment, error) {
+	comment, err := s.service.PostComment(ctx, model.PostCommentInput{
+		PostID:           request.GetPostId(),
+		ParentCommentID:  request.ParentCommentId,
+		AuthorID:         request.GetAuthorId(),
+		Content:          request.GetContent(),
+		ClientMutationID: request.ClientMutationId,
+	})
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	return toComment(comment), nil
+}
+
+func (s *Server) UpdateComment(ctx context.Context, request *posts.UpdateCommentRequest) (*posts.Comment, error) {
+	comment, err := s.service.PutComment(ctx, model.PutCommentInput{
+		ID:              request.GetId(),
+		Content:         request.GetContent(),
+		ExpectedVersion: request.ExpectedVersion,
+	})
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	return toComment(comment), nil
+}
+
+func (s *Server) DeleteComment(ctx context.Context, request *posts.DeleteCommentRequest) (*posts.DeleteCommentResponse, error) {
+	ok, err := s.service.DeleteComment(ctx, request.GetId())
+	if err == nil && !ok {
+		err = service.ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+
+	return &posts.DeleteCommentResponse{}, nil
+}
+
+func (s *Server) WatchComments(request *posts.WatchCommentsRequest, stream grpc.ServerStreamingServer[posts.CommentEvent]) error {
+	ctx := stream.Context()
+	postID := request.GetPostId()
+
+	if !s.ps.Check(postID) {
+		post, err := s.service.GetPostByID(ctx, postID)
+		if err == nil && post == nil {
+			err = service.ErrPostNotFound
+		}
+		if err != nil {
+			return s.fail(ctx, err)
+		}
+	}
+
+	s.log.For(ctx).Debug("creating new gRPC comment stream", zap.String("postId", postID))
+
+	ch := s.ps.Subscribe(ctx, postID)
+	defer func() {
+		s.log.For(ctx).Debug("Unsubscribing gRPC stream from comments", zap.String("postId", postID))
+		s.ps.Unsubscribe(ctx, postID, ch)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case comment, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(&posts.CommentEvent{Comment: toComment(comment)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// fail converts a service error into a gRPC status, internal errors are
+// logged and hidden from the client.
+func (s *Server) fail(ctx context.Context, err error) error {
+	st := grpcStatus(err)
+	if st.Code() == codes.Internal {
+		method, _ := grpc.Method(ctx)
+		s.log.For(ctx).Error("gRPC request failed", zap.String("method", method), zap.String("err", err.Error()))
+	}
+
+	return st.Err()
+}
+
+// withMetadata puts the caller identified by the gateway and the idempotency
+// key of a retried call into the context, like the HTTP middlewares do. The
+// request ID is taken from the x-request-id metadata, or generated, and
+// returned in the response header.
+func (s *Server) withMetadata(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := requestid.Resolve(first(md, requestid.Header))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, id))
+
+	ctx = requestid.WithID(ctx, id)
+	log := s.log.With(zap.String("requestId", id))
+
+	if userID := first(md, auth.UserIDHeader); userID != "" {
+		role := auth.Role(first(md, auth.UserRoleHeader))
+		if role == "" {
+			role = auth.RoleUser
+		}
+
+		ctx = auth.WithUser(ctx, auth.User{ID: userID, Role: role})
+		log = log.With(zap.String("userId", userID))
+	}
+
+	if key := first(md, idempotency.Header); key != "" {
+		ctx = idempotency.WithKey(ctx, key)
+	}
+
+	return logger.WithContext(ctx, log)
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (s *Server) unaryMetadataInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(s.withMetadata(ctx), req)
+}
+
+func (s *Server) streamMetadataInterceptor(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ServerStream: stream, ctx: s.withMetadata(stream.Context())})
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+var errRateLimited = status.Error(codes.ResourceExhausted, "rate limit exceeded")
+
+// allow reports whether the client IP address of the call is within the
+// rate limit. Like X-User-ID over HTTP, the user-id metadata is not verified
+// and does not pick the bucket.
+func (s *Server) allow(ctx context.Context) bool {
+	if s.limiter == nil {
+		return true
+	}
+
+	var address string
+	if p, ok := peer.FromContext(ctx); ok {
+		address = p.Addr.String()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	return s.limiter.Allow(s.limiter.ClientIP(address, md.Get("x-forwarded-for")))
+}
+
+func (s *Server) unaryRateLimitInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !s.allow(ctx) {
+		return nil, errRateLimited
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *Server) streamRateLimitInterceptor(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !s.allow(stream.Context()) {
+		return errRateLimited
+	}
+
+	return handler(srv, stream)
+}
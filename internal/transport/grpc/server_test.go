@@ -0,0 +1,212 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"ozon/internal/auth"
+	"ozon/internal/ratelimit"
+	"ozon/internal/requestid"
+	"ozon/internal/service"
+	"ozon/internal/transport/graph/mocks"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/api/posts"
+	"ozon/pkg/logger"
+)
+
+func newClient(t *testing.T, srv *mocks.Service, ps *mocks.Subscription, opts ...Option) posts.PostsClient {
+	lis := bufconn.Listen(1 << 20)
+	s := New(srv, logger.Logger{Logger: zap.NewNop()}, ps, opts...)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return posts.NewPostsClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(s *mocks.Service)
+		call     func(ctx context.Context, client posts.PostsClient) error
+		wantCode codes.Code
+	}{
+		{
+			name: "get post",
+			setup: func(s *mocks.Service) {
+				s.On("GetPostByID", mock.Anything, "1").
+					Return(&model.Post{ID: "1", Content: "hello", Status: model.PostStatusPublished}, nil)
+			},
+			call: func(ctx context.Context, client posts.PostsClient) error {
+				post, err := client.GetPost(ctx, &posts.GetPostRequest{Id: "1"})
+				if err == nil {
+					assert.Equal(t, "hello", post.GetContent())
+					assert.Equal(t, posts.PostStatus_POST_STATUS_PUBLISHED, post.GetStatus())
+				}
+				return err
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "missing post",
+			setup: func(s *mocks.Service) {
+				s.On("GetPostByID", mock.Anything, "2").Return(nil, nil)
+			},
+			call: func(ctx context.Context, client posts.PostsClient) error {
+				_, err := client.GetPost(ctx, &posts.GetPostRequest{Id: "2"})
+				return err
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "caller from metadata",
+			setup: func(s *mocks.Service) {
+				s.On("DeleteComment", mock.MatchedBy(func(ctx context.Context) bool {
+					return auth.IsModerator(ctx)
+				}), "5").Return(true, nil)
+			},
+			call: func(ctx context.Context, client posts.PostsClient) error {
+				ctx = metadata.AppendToOutgoingContext(ctx, "x-user-id", "1", "x-user-role", "moderator")
+				_, err := client.DeleteComment(ctx, &posts.DeleteCommentRequest{Id: "5"})
+				return err
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:  "unknown order",
+			setup: func(s *mocks.Service) {},
+			call: func(ctx context.Context, client posts.PostsClient) error {
+				_, err := client.ListComments(ctx, &posts.ListCommentsRequest{PostId: "1", OrderBy: 42})
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "forbidden",
+			setup: func(s *mocks.Service) {
+				s.On("DeletePost", mock.Anything, "1").Return(false, service.ErrForbidden)
+			},
+			call: func(ctx context.Context, client posts.PostsClient) error {
+				_, err := client.DeletePost(ctx, &posts.DeletePostRequest{Id: "1"})
+				return err
+			},
+			wantCode: codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := mocks.NewService(t)
+			tt.setup(srv)
+
+			err := tt.call(context.Background(), newClient(t, srv, mocks.NewSubscription(t)))
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestServer_VersionConflict(t *testing.T) {
+	srv := mocks.NewService(t)
+	srv.On("PutComment", mock.Anything, mock.MatchedBy(func(input model.PutCommentInput) bool {
+		return input.ID == "5" && *input.ExpectedVersion == 1
+	})).Return(nil, &model.VersionConflictError{Current: 2})
+
+	version := int32(1)
+	_, err := newClient(t, srv, mocks.NewSubscription(t)).UpdateComment(context.Background(),
+		&posts.UpdateCommentRequest{Id: "5", Content: "edited", ExpectedVersion: &version})
+
+	st := status.Convert(err)
+	require.Equal(t, codes.Aborted, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	info := st.Details()[0].(*errdetails.ErrorInfo)
+	assert.Equal(t, "CONFLICT", info.GetReason())
+	assert.Equal(t, "2", info.GetMetadata()["currentVersion"])
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	srv := mocks.NewService(t)
+	srv.On("GetPostByID", mock.Anything, "1").Return(&model.Post{ID: "1", Status: model.PostStatusPublished}, nil).Once()
+
+	limiter := ratelimit.New(ratelimit.Config{RPS: 0.001, Burst: 1})
+	client := newClient(t, srv, mocks.NewSubscription(t), WithRateLimiter(limiter))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), auth.UserIDHeader, "1")
+	_, err := client.GetPost(ctx, &posts.GetPostRequest{Id: "1"})
+	require.NoError(t, err)
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), auth.UserIDHeader, "2")
+	_, err = client.GetPost(ctx, &posts.GetPostRequest{Id: "1"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "user-id does not pick the bucket")
+
+	stream, err := client.WatchComments(context.Background(), &posts.WatchCommentsRequest{PostId: "1"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "streams are limited too")
+}
+
+func TestServer_WatchComments(t *testing.T) {
+	ch := make(chan *model.Comment, 1)
+
+	ps := mocks.NewSubscription(t)
+	ps.On("Check", "1").Return(true)
+	ps.On("Subscribe", mock.Anything, "1").Return(ch)
+	unsubscribed := make(chan struct{})
+	ps.On("Unsubscribe", mock.Anything, "1", ch).Run(func(mock.Arguments) { close(unsubscribed) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := newClient(t, mocks.NewService(t), ps).WatchComments(ctx, &posts.WatchCommentsRequest{PostId: "1"})
+	require.NoError(t, err)
+
+	ch <- &model.Comment{ID: "7", PostID: "1", Content: "first"}
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "7", event.GetComment().GetId())
+	assert.Equal(t, "first", event.GetComment().GetContent())
+
+	cancel()
+	<-unsubscribed
+}
+
+func TestServer_RequestID(t *testing.T) {
+	srv := mocks.NewService(t)
+	srv.On("GetPostByID", mock.MatchedBy(func(ctx context.Context) bool {
+		id, ok := requestid.FromContext(ctx)
+		return ok && id == "req-42"
+	}), "1").Return(&model.Post{ID: "1"}, nil)
+	srv.On("GetPostByID", mock.Anything, "2").Return(&model.Post{ID: "2"}, nil)
+
+	client := newClient(t, srv, mocks.NewSubscription(t))
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "req-42")
+	_, err := client.GetPost(ctx, &posts.GetPostRequest{Id: "1"}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"req-42"}, header.Get(requestid.Header))
+
+	_, err = client.GetPost(context.Background(), &posts.GetPostRequest{Id: "2"}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Len(t, header.Get(requestid.Header), 1)
+	assert.NotEqual(t, "req-42", header.Get(requestid.Header)[0], "a new ID is generated")
+}
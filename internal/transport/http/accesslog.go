@@ -0,0 +1,234 @@
+package http
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.uber.org/zap"
+	"ozon/pkg/logger"
+)
+
+// Headers identifying the client application, as sent by Apollo clients.
+const (
+	clientNameHeader    = "Apollographql-Client-Name"
+	clientVersionHeader = "Apollographql-Client-Version"
+)
+
+const redactedValue = "[redacted]"
+
+type AccessLogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// RedactVariables are the names of variables, and of fields of input
+	// objects, whose values are not logged. Case is ignored.
+	RedactVariables []string `mapstructure:"redactVariables"`
+	// Sampling maps operation names, case ignored, to the share of their
+	// operations that is logged. Other operations, as well as slow and
+	// failed ones, are always logged.
+	Sampling map[string]float64 `mapstructure:"sampling"`
+	// SlowThreshold is the duration from which an operation is logged at
+	// warn level, zero disables it.
+	SlowThreshold time.Duration `mapstructure:"slowThreshold"`
+}
+
+// accessLog is a gqlgen extension that logs one line per operation, a
+// subscription is logged when it ends.
+type accessLog struct {
+	log      logger.Logger
+	redacted map[string]bool
+	sampling map[string]float64
+	slow     time.Duration
+	sample   func() float64
+}
+
+var (
+	_ graphql.HandlerExtension     = accessLog{}
+	_ graphql.OperationInterceptor = accessLog{}
+	_ graphql.ResponseInterceptor  = accessLog{}
+)
+
+func newAccessLog(log logger.Logger, cfg AccessLogConfig) accessLog {
+	a := accessLog{
+		log:      log,
+		redacted: make(map[string]bool, len(cfg.RedactVariables)),
+		sampling: make(map[string]float64, len(cfg.Sampling)),
+		slow:     cfg.SlowThreshold,
+		sample:   rand.Float64,
+	}
+
+	for _, name := range cfg.RedactVariables {
+		a.redacted[strings.ToLower(name)] = true
+	}
+	for name, ratio := range cfg.Sampling {
+		a.sampling[strings.ToLower(name)] = ratio
+	}
+
+	return a
+}
+
+func (accessLog) ExtensionName() string {
+	return "AccessLog"
+}
+
+func (accessLog) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+type accessLoggedKey struct{}
+
+// accessEntry sums up the responses of an operation.
+type accessEntry struct {
+	start     time.Time
+	responses int
+	errors    int
+	size      int
+}
+
+func (e *accessEntry) add(response *graphql.Response) {
+	e.responses++
+	e.errors += len(response.Errors)
+	e.size += len(response.Data)
+}
+
+func (a accessLog) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	// The logger is taken before the operation is added to it by the
+	// extensions that run after this one.
+	log := a.log.For(ctx)
+	oc := graphql.GetOperationContext(ctx)
+	entry := &accessEntry{start: startTime(oc)}
+
+	// Responses of the operation are logged here rather than by
+	// InterceptResponse, which only logs requests that failed before it.
+	handler := next(context.WithValue(ctx, accessLoggedKey{}, true))
+
+	if oc.Operation == nil || oc.Operation.Operation != ast.Subscription {
+		return func(ctx context.Context) *graphql.Response {
+			response := handler(ctx)
+			if response != nil {
+				entry.add(response)
+			}
+
+			a.write(log, oc, entry)
+			return response
+		}
+	}
+
+	return func(ctx context.Context) *graphql.Response {
+		response := handler(ctx)
+		if response == nil {
+			a.write(log, oc, entry)
+			return nil
+		}
+
+		entry.add(response)
+		return response
+	}
+}
+
+func (a accessLog) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	response := next(ctx)
+	if logged, _ := ctx.Value(accessLoggedKey{}).(bool); logged || response == nil {
+		return response
+	}
+
+	var oc *graphql.OperationContext
+	if graphql.HasOperationContext(ctx) {
+		oc = graphql.GetOperationContext(ctx)
+	}
+
+	entry := &accessEntry{start: startTime(oc)}
+	entry.add(response)
+	a.write(a.log.For(ctx), oc, entry)
+
+	return response
+}
+
+func (a accessLog) write(log logger.Logger, oc *graphql.OperationContext, entry *accessEntry) {
+	name, operationType := "anonymous", ""
+	var variables map[string]any
+	var clientName, clientVersion string
+
+	if oc != nil {
+		// The operation is only known once the document is valid.
+		if oc.Operation != nil {
+			operationType = string(oc.Operation.Operation)
+			if oc.Operation.Name != "" {
+				name = oc.Operation.Name
+			}
+		} else if oc.OperationName != "" {
+			name = oc.OperationName
+		}
+
+		variables = oc.Variables
+		clientName, clientVersion = oc.Headers.Get(clientNameHeader), oc.Headers.Get(clientVersionHeader)
+	}
+
+	var duration time.Duration
+	if !entry.start.IsZero() {
+		duration = time.Since(entry.start)
+	}
+
+	slow := a.slow > 0 && duration >= a.slow
+	if ratio, ok := a.sampling[strings.ToLower(name)]; ok && !slow && entry.errors == 0 && a.sample() >= ratio {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("operation", name),
+		zap.String("operationType", operationType),
+		zap.Any("variables", a.redact(variables)),
+		zap.Duration("duration", duration),
+		zap.Int("errors", entry.errors),
+		zap.Int("responseSize", entry.size),
+	}
+	if operationType == string(ast.Subscription) {
+		fields = append(fields, zap.Int("responses", entry.responses))
+	}
+	if clientName != "" {
+		fields = append(fields, zap.String("clientName", clientName))
+	}
+	if clientVersion != "" {
+		fields = append(fields, zap.String("clientVersion", clientVersion))
+	}
+
+	if slow {
+		log.Warn("slow graphql operation", fields...)
+		return
+	}
+
+	log.Info("graphql operation", fields...)
+}
+
+// redact replaces the values of redacted variables and input fields.
+func (a accessLog) redact(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for key, field := range value {
+			if a.redacted[strings.ToLower(key)] {
+				out[key] = redactedValue
+				continue
+			}
+			out[key] = a.redact(field)
+		}
+		return out
+	case []any:
+		out := make([]any, len(value))
+		for i, item := range value {
+			out[i] = a.redact(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func startTime(oc *graphql.OperationContext) time.Time {
+	if oc == nil || oc.Stats.OperationStart.IsZero() {
+		return time.Now()
+	}
+	return oc.Stats.OperationStart
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"ozon/internal/transport/graph/mocks"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/logger"
+)
+
+func TestAccessLog(t *testing.T) {
+	srv := mocks.NewService(t)
+	srv.On("CreateWebhook", mock.Anything, mock.Anything).Return(&model.Webhook{ID: "1", URL: "https://example.com"}, nil)
+	srv.On("GetPost", mock.Anything, int32(1)).Return([]*model.Post{}, nil)
+
+	tests := []struct {
+		name    string
+		cfg     AccessLogConfig
+		query   string
+		headers map[string]string
+		level   zapcore.Level
+		message string
+		fields  map[string]any
+	}{
+		{
+			name:  "variables are redacted",
+			cfg:   AccessLogConfig{Enabled: true, RedactVariables: []string{"SECRET"}},
+			query: `{"query":"mutation Hook($input: createWebhookInput!) { createWebhook(input: $input) { id } }","variables":{"input":{"url":"https://example.com","secret":"s3cr3t","events":["POST_CREATED"]}}}`,
+			headers: map[string]string{
+				clientNameHeader:    "web",
+				clientVersionHeader: "1.2.0",
+			},
+			level:   zapcore.InfoLevel,
+			message: "graphql operation",
+			fields: map[string]any{
+				"operation":     "Hook",
+				"operationType": "mutation",
+				"variables": map[string]any{"input": map[string]any{
+					"url":    "https://example.com",
+					"secret": redactedValue,
+					"events": []any{"POST_CREATED"},
+				}},
+				"errors":        int64(0),
+				"responseSize":  int64(len(`{"createWebhook":{"id":"1"}}`)),
+				"clientName":    "web",
+				"clientVersion": "1.2.0",
+			},
+		},
+		{
+			name:    "slow operations are logged at warn level",
+			cfg:     AccessLogConfig{Enabled: true, SlowThreshold: time.Nanosecond},
+			query:   `{"query":"query Posts { getPost(first: 1) { id } }"}`,
+			level:   zapcore.WarnLevel,
+			message: "slow graphql operation",
+			fields: map[string]any{
+				"operation":     "Posts",
+				"operationType": "query",
+				"variables":     map[string]any{},
+				"errors":        int64(0),
+				"responseSize":  int64(len(`{"getPost":[]}`)),
+			},
+		},
+		{
+			name:    "sampled operations are skipped",
+			cfg:     AccessLogConfig{Enabled: true, Sampling: map[string]float64{"posts": 0}},
+			query:   `{"query":"query Posts { getPost(first: 1) { id } }"}`,
+			message: "",
+		},
+		{
+			name:    "failed operations are logged regardless of sampling",
+			cfg:     AccessLogConfig{Enabled: true, Sampling: map[string]float64{"broken": 0}},
+			query:   `{"query":"query Broken { missing }","operationName":"Broken"}`,
+			level:   zapcore.InfoLevel,
+			message: "graphql operation",
+			fields: map[string]any{
+				"operation":     "Broken",
+				"operationType": "",
+				"variables":     map[string]any{},
+				"errors":        int64(1),
+				"responseSize":  int64(0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.InfoLevel)
+
+			e := echo.New()
+			NewHandler(e, srv, logger.Logger{Logger: zap.New(core)}, mocks.NewSubscription(t), WithAccessLog(tt.cfg))
+
+			request := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(tt.query))
+			request.Header.Set("Content-Type", "application/json")
+			for key, value := range tt.headers {
+				request.Header.Set(key, value)
+			}
+			e.ServeHTTP(httptest.NewRecorder(), request)
+
+			entries := logs.FilterMessageSnippet("graphql operation").All()
+			if tt.message == "" {
+				assert.Empty(t, entries)
+				return
+			}
+
+			require.Len(t, entries, 1)
+			assert.Equal(t, tt.level, entries[0].Level)
+			assert.Equal(t, tt.message, entries[0].Message)
+
+			fields := entries[0].ContextMap()
+			assert.Len(t, entries[0].Context, len(fields), "no field is logged twice")
+			assert.Contains(t, fields, "requestId")
+			assert.Contains(t, fields, "duration")
+			delete(fields, "requestId")
+			delete(fields, "duration")
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// NewAdminHandler registers the operational endpoints served on the admin
+// port, away from the public API.
+func NewAdminHandler(e *echo.Echo, gatherer prometheus.Gatherer) {
+	e.GET("/metrics", echo.WrapHandler(promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})))
+}
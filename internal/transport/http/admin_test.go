@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"ozon/internal/metrics"
+	"ozon/internal/transport/graph/mocks"
+	"ozon/pkg/logger"
+)
+
+func TestAdminMetrics(t *testing.T) {
+	e := echo.New()
+	NewHandler(e, mocks.NewService(t), logger.Logger{Logger: zap.NewNop()}, mocks.NewSubscription(t))
+
+	operations := metrics.GraphQLOperations.WithLabelValues("MissingPost", "query")
+	resolverErrors := metrics.GraphQLResolvers.WithLabelValues("Query.getPostById", "error")
+	badRequests := metrics.GraphQLErrors.WithLabelValues("400")
+	before := []float64{testutil.ToFloat64(operations), testutil.ToFloat64(resolverErrors), testutil.ToFloat64(badRequests)}
+
+	request := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query MissingPost { getPostById(id: \"\") { id } }"}`))
+	request.Header.Set("Content-Type", "application/json")
+	e.ServeHTTP(httptest.NewRecorder(), request)
+
+	admin := echo.New()
+	NewAdminHandler(admin, metrics.Registry)
+
+	response := httptest.NewRecorder()
+	admin.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, response.Code)
+
+	assert.Contains(t, response.Body.String(), `ozon_graphql_operations_total{operation="MissingPost",type="query"}`)
+
+	assert.Equal(t, before[0]+1, testutil.ToFloat64(operations))
+	assert.Equal(t, before[1]+1, testutil.ToFloat64(resolverErrors))
+	assert.Equal(t, before[2]+1, testutil.ToFloat64(badRequests))
+}
@@ -0,0 +1,33 @@
+package http
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"ozon/internal/settings"
+)
+
+// featureToggles is a gqlgen extension that enables introspection per
+// operation, following the current settings.
+type featureToggles struct {
+	features func() settings.Features
+}
+
+var (
+	_ graphql.HandlerExtension        = featureToggles{}
+	_ graphql.OperationContextMutator = featureToggles{}
+)
+
+func (featureToggles) ExtensionName() string {
+	return "FeatureToggles"
+}
+
+func (featureToggles) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (f featureToggles) MutateOperationContext(_ context.Context, oc *graphql.OperationContext) *gqlerror.Error {
+	oc.DisableIntrospection = !f.features().Introspection
+	return nil
+}
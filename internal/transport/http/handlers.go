@@ -1,34 +1,83 @@
 package http
 
 import (
+	"net/http"
+
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
 	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo"
 	"github.com/vektah/gqlparser/v2/ast"
-	"ozon/internal/Subscription"
+	"go.uber.org/zap"
+	"ozon/internal/auth"
+	"ozon/internal/idempotency"
+	"ozon/internal/metrics"
+	"ozon/internal/ratelimit"
+	"ozon/internal/settings"
 	"ozon/internal/transport/graph"
 	"ozon/pkg/logger"
 )
 
 type Handler struct {
-	service graph.Service
-	log     logger.Logger
-	ps      graph.Subscription
+	service  graph.Service
+	log      logger.Logger
+	ps       graph.Subscription
+	limiter  *ratelimit.Limiter
+	features func() settings.Features
+	access   *AccessLogConfig
+}
+
+type Option func(*Handler)
+
+// WithRateLimiter limits the requests to the GraphQL and REST APIs per
+// client IP address.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(h *Handler) {
+		h.limiter = limiter
+	}
+}
+
+// WithFeatures makes the optional features depend on the current settings,
+// all of them are enabled otherwise.
+func WithFeatures(features func() settings.Features) Option {
+	return func(h *Handler) {
+		h.features = features
+	}
+}
+
+// WithAccessLog logs every GraphQL operation as configured.
+func WithAccessLog(cfg AccessLogConfig) Option {
+	return func(h *Handler) {
+		if cfg.Enabled {
+			h.access = &cfg
+		}
+	}
 }
 
-func NewHandler(e *echo.Echo, service graph.Service, log logger.Logger) {
+func NewHandler(e *echo.Echo, service graph.Service, log logger.Logger, ps graph.Subscription, opts ...Option) {
 	handler := &Handler{
-		service: service,
-		log:     log,
-		ps:      Subscription.New(),
+		service:  service,
+		log:      log,
+		ps:       ps,
+		features: func() settings.Features { return settings.Features{Introspection: true, Playground: true} },
+	}
+	for _, opt := range opts {
+		opt(handler)
 	}
 
-	e.POST("/query", handler.graphqlHandler())
-	e.GET("/query", handler.graphqlHandler())
+	e.Use(handler.requestIDMiddleware)
+	e.Use(tracingMiddleware)
+	e.Use(userMiddleware)
+	e.Use(idempotencyMiddleware)
+
+	e.POST("/query", handler.graphqlHandler(), handler.rateLimitMiddleware)
+	e.GET("/query", handler.graphqlHandler(), handler.rateLimitMiddleware)
 	e.GET("/", handler.playgroundHandler())
+
+	handler.registerREST(e)
 }
 
 func (h *Handler) graphqlHandler() echo.HandlerFunc {
@@ -42,12 +91,27 @@ func (h *Handler) graphqlHandler() echo.HandlerFunc {
 
 	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
 
-	srv.Use(extension.Introspection{})
+	// The access log is registered before requestLogging to add the
+	// operation to the logger itself, also for requests failing validation.
+	if h.access != nil {
+		srv.Use(newAccessLog(h.log, *h.access))
+	}
+	srv.Use(requestLogging{})
+	srv.Use(graphQLTracer{})
+	srv.Use(graphQLMetrics{})
+	srv.Use(featureToggles{features: h.features})
+	srv.Use(idempotencyScope{})
 	srv.Use(extension.AutomaticPersistedQuery{
 		Cache: lru.New[string](100),
 	})
 
 	return func(c echo.Context) error {
+		// A websocket request is served until the connection closes.
+		if websocket.IsWebSocketUpgrade(c.Request()) {
+			metrics.WebsocketConnections.Inc()
+			defer metrics.WebsocketConnections.Dec()
+		}
+
 		srv.ServeHTTP(c.Response().Writer, c.Request())
 		return nil
 	}
@@ -57,7 +121,63 @@ func (h *Handler) playgroundHandler() echo.HandlerFunc {
 	srv := playground.Handler("GraphQL", "/query")
 
 	return func(c echo.Context) error {
+		if !h.features().Playground {
+			return echo.ErrNotFound
+		}
+
 		srv.ServeHTTP(c.Response().Writer, c.Request())
 		return nil
 	}
 }
+
+// userMiddleware puts the caller identified by the gateway into the request context.
+func userMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if id := c.Request().Header.Get(auth.UserIDHeader); id != "" {
+			role := auth.Role(c.Request().Header.Get(auth.UserRoleHeader))
+			if role == "" {
+				role = auth.RoleUser
+			}
+
+			ctx := auth.WithUser(c.Request().Context(), auth.User{ID: id, Role: role})
+			if log, ok := logger.FromContext(ctx); ok {
+				ctx = logger.WithContext(ctx, log.With(zap.String("userId", id)))
+			}
+			c.SetRequest(c.Request().WithContext(ctx))
+		}
+
+		return next(c)
+	}
+}
+
+// idempotencyMiddleware puts the Idempotency-Key of a retried request into the request context.
+func idempotencyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if key := c.Request().Header.Get(idempotency.Header); key != "" {
+			ctx := idempotency.WithKey(c.Request().Context(), key)
+			c.SetRequest(c.Request().WithContext(ctx))
+		}
+
+		return next(c)
+	}
+}
+
+// rateLimitMiddleware rejects the requests of a client IP address that has
+// exceeded the rate limit with 429 Too Many Requests. X-User-ID is not
+// verified by the service, so it does not pick the bucket.
+func (h *Handler) rateLimitMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if h.limiter == nil {
+			return next(c)
+		}
+
+		request := c.Request()
+		key := h.limiter.ClientIP(request.RemoteAddr, request.Header.Values(echo.HeaderXForwardedFor))
+
+		if !h.limiter.Allow(key) {
+			return echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+		}
+
+		return next(c)
+	}
+}
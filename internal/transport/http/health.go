@@ -0,0 +1,26 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"ozon/internal/health"
+)
+
+// NewHealthHandler registers the liveness and readiness probes.
+func NewHealthHandler(e *echo.Echo, h *health.Health) {
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, health.Report{Status: health.StatusOK})
+	})
+
+	e.GET("/readyz", func(c echo.Context) error {
+		report := h.Ready(c.Request().Context())
+
+		status := http.StatusOK
+		if report.Status != health.StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		return c.JSON(status, report)
+	})
+}
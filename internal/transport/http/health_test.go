@@ -0,0 +1,43 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"ozon/internal/health"
+)
+
+func TestHealth(t *testing.T) {
+	var storageErr error
+
+	probes := health.New()
+	probes.Add("storage", func(context.Context) error { return storageErr })
+
+	e := echo.New()
+	NewHealthHandler(e, probes)
+
+	probe := func(target string) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		e.ServeHTTP(response, httptest.NewRequest(http.MethodGet, target, nil))
+		return response
+	}
+
+	response := probe("/readyz")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"status":"ok","components":{"storage":{"status":"ok"}}}`, response.Body.String())
+
+	storageErr = errors.New("connection refused")
+	response = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.JSONEq(t, `{"status":"unavailable","components":{"storage":{"status":"unavailable","error":"connection refused"}}}`, response.Body.String())
+
+	storageErr = nil
+	probes.ShutDown()
+	assert.Equal(t, http.StatusServiceUnavailable, probe("/readyz").Code)
+	assert.Equal(t, http.StatusOK, probe("/healthz").Code)
+}
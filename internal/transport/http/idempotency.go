@@ -0,0 +1,34 @@
+package http
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"ozon/internal/idempotency"
+)
+
+// idempotencyScope is a gqlgen extension that scopes the Idempotency-Key of
+// the request to each mutation field by its alias, a request may run
+// several createPost or postComment mutations.
+type idempotencyScope struct{}
+
+var (
+	_ graphql.HandlerExtension = idempotencyScope{}
+	_ graphql.FieldInterceptor = idempotencyScope{}
+)
+
+func (idempotencyScope) ExtensionName() string {
+	return "IdempotencyScope"
+}
+
+func (idempotencyScope) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (idempotencyScope) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	if fc := graphql.GetFieldContext(ctx); fc != nil && fc.Object == "Mutation" {
+		ctx = idempotency.Scoped(ctx, fc.Field.Alias)
+	}
+
+	return next(ctx)
+}
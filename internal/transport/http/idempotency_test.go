@@ -0,0 +1,43 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"ozon/internal/idempotency"
+	"ozon/internal/transport/graph/mocks"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/logger"
+)
+
+func TestIdempotencyScope(t *testing.T) {
+	srv := mocks.NewService(t)
+	for alias, id := range map[string]string{"first": "1", "second": "2"} {
+		srv.On("CreatePost", mock.MatchedBy(func(ctx context.Context) bool {
+			key, _ := idempotency.FromContext(ctx)
+			return key == "retry-1:"+alias
+		}), mock.Anything).Return(&model.Post{ID: id}, nil).Once()
+	}
+
+	e := echo.New()
+	NewHandler(e, srv, logger.Logger{Logger: zap.NewNop()}, mocks.NewSubscription(t))
+
+	query := `{"query":"mutation { ` +
+		`first: createPost(input: {authorId: \"1\", content: \"a\", areCommentsAllowed: true}) { id } ` +
+		`second: createPost(input: {authorId: \"1\", content: \"b\", areCommentsAllowed: true}) { id } }"}`
+	request := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(query))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(idempotency.Header, "retry-1")
+
+	response := httptest.NewRecorder()
+	e.ServeHTTP(response, request)
+
+	assert.JSONEq(t, `{"data":{"first":{"id":"1"},"second":{"id":"2"}}}`, response.Body.String(), "each mutation is deduplicated by its own key")
+}
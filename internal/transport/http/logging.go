@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"maps"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+	"ozon/internal/requestid"
+	"ozon/pkg/logger"
+)
+
+// requestIDMiddleware takes the ID of the request from the X-Request-ID
+// header, or generates one, and returns it in the response. The request
+// context carries the ID and a logger that adds it to every entry.
+func (h *Handler) requestIDMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := requestid.Resolve(c.Request().Header.Get(requestid.Header))
+		c.Response().Header().Set(requestid.Header, id)
+
+		ctx := requestid.WithID(c.Request().Context(), id)
+		ctx = logger.WithContext(ctx, h.log.With(zap.String("requestId", id)))
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		return next(c)
+	}
+}
+
+// requestLogging is a gqlgen extension that adds the operation to the
+// request logger and the request ID to the extensions of every error.
+type requestLogging struct{}
+
+var (
+	_ graphql.HandlerExtension     = requestLogging{}
+	_ graphql.OperationInterceptor = requestLogging{}
+	_ graphql.ResponseInterceptor  = requestLogging{}
+)
+
+func (requestLogging) ExtensionName() string {
+	return "RequestLogging"
+}
+
+func (requestLogging) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (requestLogging) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	log, ok := logger.FromContext(ctx)
+	if !ok {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+
+	var operationType, name string
+	if oc.Operation != nil {
+		operationType, name = string(oc.Operation.Operation), oc.Operation.Name
+	}
+
+	if name == "" {
+		name = "anonymous"
+	}
+
+	return next(logger.WithContext(ctx, log.With(zap.String("operation", name), zap.String("operationType", operationType))))
+}
+
+func (requestLogging) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	response := next(ctx)
+
+	id, ok := requestid.FromContext(ctx)
+	if !ok || response == nil {
+		return response
+	}
+
+	// Errors may be shared, such as those of a cached failure, so they are
+	// copied rather than changed in place.
+	for i, err := range response.Errors {
+		withID := *err
+		withID.Extensions = maps.Clone(err.Extensions)
+		if withID.Extensions == nil {
+			withID.Extensions = make(map[string]any, 1)
+		}
+		withID.Extensions["requestId"] = id
+
+		response.Errors[i] = &withID
+	}
+
+	return response
+}
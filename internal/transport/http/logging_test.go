@@ -0,0 +1,66 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"ozon/internal/auth"
+	"ozon/internal/requestid"
+	"ozon/internal/transport/graph/mocks"
+	"ozon/pkg/logger"
+)
+
+func TestRequestLogging(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	e := echo.New()
+	NewHandler(e, mocks.NewService(t), logger.Logger{Logger: zap.New(core)}, mocks.NewSubscription(t))
+
+	query := func(id string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query MissingPost { getPostById(id: \"\") { id } }"}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set(auth.UserIDHeader, "7")
+		if id != "" {
+			request.Header.Set(requestid.Header, id)
+		}
+
+		response := httptest.NewRecorder()
+		e.ServeHTTP(response, request)
+		return response
+	}
+
+	response := query("req-42")
+	assert.Equal(t, "req-42", response.Header().Get(requestid.Header))
+
+	var body struct {
+		Errors []struct {
+			Extensions map[string]any `json:"extensions"`
+		} `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &body))
+	require.Len(t, body.Errors, 1)
+	assert.Equal(t, "req-42", body.Errors[0].Extensions["requestId"])
+	assert.EqualValues(t, http.StatusBadRequest, body.Errors[0].Extensions["code"])
+
+	entries := logs.FilterMessage("invalid input arguments: missing post ID").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]any{
+		"requestId":     "req-42",
+		"userId":        "7",
+		"operation":     "MissingPost",
+		"operationType": "query",
+	}, entries[0].ContextMap())
+
+	response = query("")
+	assert.NoError(t, uuid.Validate(response.Header().Get(requestid.Header)), "an ID is generated when the client sends none")
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"ozon/internal/metrics"
+)
+
+// graphQLMetrics is a gqlgen extension that counts and times operations and
+// fields with a resolver, and counts returned errors by their code.
+type graphQLMetrics struct{}
+
+var (
+	_ graphql.HandlerExtension    = graphQLMetrics{}
+	_ graphql.ResponseInterceptor = graphQLMetrics{}
+	_ graphql.FieldInterceptor    = graphQLMetrics{}
+)
+
+func (graphQLMetrics) ExtensionName() string {
+	return "Metrics"
+}
+
+func (graphQLMetrics) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (graphQLMetrics) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	start := time.Now()
+	response := next(ctx)
+
+	operationType, name := "unknown", "anonymous"
+	if graphql.HasOperationContext(ctx) {
+		if op := graphql.GetOperationContext(ctx).Operation; op != nil {
+			operationType = string(op.Operation)
+			if op.Name != "" {
+				name = op.Name
+			}
+		}
+	}
+
+	metrics.GraphQLOperations.WithLabelValues(name, operationType).Inc()
+	metrics.GraphQLOperationDuration.WithLabelValues(name, operationType).Observe(time.Since(start).Seconds())
+
+	if response != nil {
+		for _, err := range response.Errors {
+			code := "unknown"
+			if value, ok := err.Extensions["code"]; ok {
+				code = fmt.Sprint(value)
+			}
+			metrics.GraphQLErrors.WithLabelValues(code).Inc()
+		}
+	}
+
+	return response
+}
+
+func (graphQLMetrics) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	field := fc.Object + "." + fc.Field.Name
+	start := time.Now()
+
+	res, err := next(ctx)
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+
+	metrics.GraphQLResolvers.WithLabelValues(field, status).Inc()
+	metrics.GraphQLResolverDuration.WithLabelValues(field).Observe(time.Since(start).Seconds())
+
+	return res, err
+}
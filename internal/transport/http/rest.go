@@ -0,0 +1,454 @@
+package http
+
+import (
+	_ "embed"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+	"ozon/internal/service"
+	"ozon/internal/transport/graph/model"
+)
+
+//go:embed openapi.json
+var openAPI []byte
+
+// postResponse is the REST representation of a post. Unlike model.Post it
+// never embeds the comment tree, comments are paginated separately.
+type postResponse struct {
+	ID                 string           `json:"id"`
+	AuthorID           string           `json:"authorId"`
+	Content            string           `json:"content"`
+	AreCommentsAllowed bool             `json:"areCommentsAllowed"`
+	CreatedAt          time.Time        `json:"createdAt"`
+	UpdatedAt          *time.Time       `json:"updatedAt"`
+	Status             model.PostStatus `json:"status"`
+	PublishAt          *time.Time       `json:"publishAt"`
+	IsPinned           bool             `json:"isPinned"`
+	CommentCount       int32            `json:"commentCount"`
+	Version            int32            `json:"version"`
+	Tags               []string         `json:"tags"`
+}
+
+type commentResponse struct {
+	ID              string     `json:"id"`
+	PostID          string     `json:"postId"`
+	ParentCommentID *string    `json:"parentCommentId"`
+	AuthorID        string     `json:"authorId"`
+	Content         string     `json:"content"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       *time.Time `json:"updatedAt"`
+	IsPinned        bool       `json:"isPinned"`
+	IsLocked        bool       `json:"isLocked"`
+	ReplyCount      int32      `json:"replyCount"`
+	Version         int32      `json:"version"`
+}
+
+// cursorPageSize is the default limit of the cursor-paginated lists.
+const cursorPageSize = 10
+
+// pageResponse is a page of a list. NextOffset or NextCursor, depending on
+// the list, is set while more items may follow.
+type pageResponse[T any] struct {
+	Items      []T     `json:"items"`
+	NextOffset *int32  `json:"nextOffset,omitempty"`
+	NextCursor *string `json:"nextCursor,omitempty"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Status         int    `json:"status"`
+	Message        string `json:"message"`
+	CurrentVersion *int32 `json:"currentVersion,omitempty"`
+}
+
+type postCommentRequest struct {
+	ParentCommentID  *string `json:"parentCommentId"`
+	AuthorID         string  `json:"authorId"`
+	Content          string  `json:"content"`
+	ClientMutationID *string `json:"clientMutationId"`
+}
+
+func (h *Handler) registerREST(e *echo.Echo) {
+	api := e.Group("/api/v1", h.rateLimitMiddleware)
+
+	api.GET("/openapi.json", h.openAPI)
+
+	api.GET("/posts", h.listPosts)
+	api.POST("/posts", h.createPost)
+	api.GET("/posts/:id", h.getPost)
+	api.PATCH("/posts/:id", h.putPost)
+	api.DELETE("/posts/:id", h.deletePost)
+
+	api.GET("/posts/:id/comments", h.listComments)
+	api.POST("/posts/:id/comments", h.postComment)
+	api.GET("/comments/:id/replies", h.listReplies)
+	api.PATCH("/comments/:id", h.putComment)
+	api.DELETE("/comments/:id", h.deleteComment)
+
+	api.GET("/tags/trending", h.trendingTags)
+	api.GET("/tags/:tag/posts", h.listPostsByTag)
+	api.GET("/drafts", h.listDrafts)
+}
+
+func (h *Handler) openAPI(c echo.Context) error {
+	return c.Blob(http.StatusOK, "application/json", openAPI)
+}
+
+func (h *Handler) listPosts(c echo.Context) error {
+	offset, err := queryInt(c, "offset")
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	posts, err := h.service.GetPost(c.Request().Context(), offset)
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, offsetPage(toPosts(posts), offset))
+}
+
+func (h *Handler) createPost(c echo.Context) error {
+	var input model.CreatePostInput
+	if err := c.Bind(&input); err != nil {
+		return h.fail(c, errBadBody)
+	}
+
+	post, err := h.service.CreatePost(c.Request().Context(), input)
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, toPost(post))
+}
+
+func (h *Handler) getPost(c echo.Context) error {
+	post, err := h.service.GetPostByID(c.Request().Context(), c.Param("id"))
+	if err == nil && post == nil {
+		err = service.ErrPostNotFound
+	}
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, toPost(post))
+}
+
+func (h *Handler) putPost(c echo.Context) error {
+	var input model.PutPostInput
+	if err := c.Bind(&input); err != nil {
+		return h.fail(c, errBadBody)
+	}
+	input.ID = c.Param("id")
+
+	post, err := h.service.PutPost(c.Request().Context(), input)
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, toPost(post))
+}
+
+func (h *Handler) deletePost(c echo.Context) error {
+	ok, err := h.service.DeletePost(c.Request().Context(), c.Param("id"))
+	if err == nil && !ok {
+		err = service.ErrPostNotFound
+	}
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) listComments(c echo.Context) error {
+	offset, err := queryInt(c, "offset")
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	orderBy := model.CommentOrder(c.QueryParam("orderBy"))
+
+	comments, err := h.service.GetCommentByPostID(c.Request().Context(), c.Param("id"), offset, orderBy)
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, offsetPage(toComments(comments), offset))
+}
+
+func (h *Handler) postComment(c echo.Context) error {
+	var request postCommentRequest
+	if err := c.Bind(&request); err != nil {
+		return h.fail(c, errBadBody)
+	}
+
+	comment, err := h.service.PostComment(c.Request().Context(), model.PostCommentInput{
+		PostID:           c.Param("id"),
+		ParentCommentID:  request.ParentCommentID,
+		AuthorID:         request.AuthorID,
+		Content:          request.Content,
+		ClientMutationID: request.ClientMutationID,
+	})
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, toComment(comment))
+}
+
+func (h *Handler) listReplies(c echo.Context) error {
+	offset, err := queryInt(c, "offset")
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	orderBy := model.CommentOrder(c.QueryParam("orderBy"))
+
+	comments, err := h.service.GetCommentByParentCommentID(c.Request().Context(), c.Param("id"), offset, orderBy)
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, offsetPage(toComments(comments), offset))
+}
+
+func (h *Handler) putComment(c echo.Context) error {
+	var input model.PutCommentInput
+	if err := c.Bind(&input); err != nil {
+		return h.fail(c, errBadBody)
+	}
+	input.ID = c.Param("id")
+
+	comment, err := h.service.PutComment(c.Request().Context(), input)
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, toComment(comment))
+}
+
+func (h *Handler) deleteComment(c echo.Context) error {
+	ok, err := h.service.DeleteComment(c.Request().Context(), c.Param("id"))
+	if err == nil && !ok {
+		err = service.ErrCommentNotFound
+	}
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) trendingTags(c echo.Context) error {
+	window := model.TrendingWindow(c.QueryParam("window"))
+	if window == "" {
+		window = model.TrendingWindowDay
+	}
+
+	tags, err := h.service.GetTrendingTags(c.Request().Context(), window)
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, pageResponse[*model.TagCount]{Items: nonNil(tags)})
+}
+
+func (h *Handler) listPostsByTag(c echo.Context) error {
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	var after *string
+	if cursor := c.QueryParam("after"); cursor != "" {
+		after = &cursor
+	}
+
+	if limit == 0 {
+		limit = cursorPageSize
+	}
+
+	// One post more than the page tells whether another page follows.
+	posts, err := h.service.GetPostByTag(c.Request().Context(), c.Param("tag"), limit+1, after)
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	var output pageResponse[postResponse]
+	if len(posts) > int(limit) {
+		posts = posts[:limit]
+		output.NextCursor = &posts[len(posts)-1].ID
+	}
+	output.Items = toPosts(posts)
+
+	return c.JSON(http.StatusOK, output)
+}
+
+func (h *Handler) listDrafts(c echo.Context) error {
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	posts, err := h.service.GetDrafts(c.Request().Context(), limit)
+	if err != nil {
+		return h.fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, pageResponse[postResponse]{Items: toPosts(posts)})
+}
+
+var (
+	errBadBody  = errors.New("invalid request body")
+	errBadQuery = errors.New("invalid query parameter")
+)
+
+// queryInt parses an optional non-negative integer query parameter.
+func queryInt(c echo.Context, name string) (int32, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || n < 0 {
+		return 0, errBadQuery
+	}
+
+	return int32(n), nil
+}
+
+// fail writes the error body shared by all REST endpoints.
+func (h *Handler) fail(c echo.Context, err error) error {
+	body := errorBody{Status: restStatus(err), Message: err.Error()}
+
+	var conflict *model.VersionConflictError
+	if errors.As(err, &conflict) {
+		body.Message = "version conflict"
+		body.CurrentVersion = &conflict.Current
+	}
+
+	if body.Status >= http.StatusInternalServerError {
+		h.log.For(c.Request().Context()).Error("REST request failed", zap.String("path", c.Path()), zap.String("err", err.Error()))
+		body.Message = http.StatusText(body.Status)
+	}
+
+	return c.JSON(body.Status, errorResponse{Error: body})
+}
+
+// restStatus maps service errors to HTTP statuses.
+func restStatus(err error) int {
+	var conflict *model.VersionConflictError
+
+	switch {
+	case errors.Is(err, errBadBody), errors.Is(err, errBadQuery),
+		errors.Is(err, service.ErrIncorrectPostLen), errors.Is(err, service.ErrIncorrectCommentLen),
+		errors.Is(err, service.ErrIncorrectContentLen), errors.Is(err, service.ErrIncorrectTag),
+		errors.Is(err, service.ErrTooManyTags), errors.Is(err, service.ErrIncorrectWindow),
+		errors.Is(err, service.ErrIncorrectStatus), errors.Is(err, service.ErrIncorrectPublishAt),
+		errors.Is(err, service.ErrIncorrectOrder), errors.Is(err, service.ErrIncorrectIdempotencyKey),
+		errors.Is(err, service.ErrPostNotPublished):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrCommentsNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrPostNotFound), errors.Is(err, service.ErrCommentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrThreadLocked), errors.Is(err, service.ErrIdempotentResultDeleted), errors.As(err, &conflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// offsetPage wraps a page of an offset-paginated list. Lists are served in
+// pages of up to 10 items, an empty page marks the end of the list.
+func offsetPage[T any](items []T, offset int32) pageResponse[T] {
+	output := pageResponse[T]{Items: items}
+	if len(items) > 0 {
+		next := offset + int32(len(items))
+		output.NextOffset = &next
+	}
+
+	return output
+}
+
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+func toPosts(posts []*model.Post) []postResponse {
+	output := make([]postResponse, 0, len(posts))
+	for _, post := range posts {
+		if post != nil {
+			output = append(output, toPost(post))
+		}
+	}
+
+	return output
+}
+
+func toPost(post *model.Post) postResponse {
+	return postResponse{
+		ID:                 post.ID,
+		AuthorID:           post.AuthorID,
+		Content:            post.Content,
+		AreCommentsAllowed: post.AreCommentsAllowed,
+		CreatedAt:          post.CreatedAt.UTC(),
+		UpdatedAt:          utc(post.UpdatedAt),
+		Status:             post.Status,
+		PublishAt:          utc(post.PublishAt),
+		IsPinned:           post.IsPinned,
+		CommentCount:       post.CommentCount,
+		Version:            post.Version,
+		Tags:               nonNil(post.Tags),
+	}
+}
+
+func toComments(comments []*model.Comment) []commentResponse {
+	output := make([]commentResponse, 0, len(comments))
+	for _, comment := range comments {
+		if comment != nil {
+			output = append(output, toComment(comment))
+		}
+	}
+
+	return output
+}
+
+func toComment(comment *model.Comment) commentResponse {
+	return commentResponse{
+		ID:              comment.ID,
+		PostID:          comment.PostID,
+		ParentCommentID: comment.ParentCommentID,
+		AuthorID:        comment.AuthorID,
+		Content:         comment.Content,
+		CreatedAt:       comment.CreatedAt.UTC(),
+		UpdatedAt:       utc(comment.UpdatedAt),
+		IsPinned:        comment.IsPinned,
+		IsLocked:        comment.IsLocked,
+		ReplyCount:      comment.ReplyCount,
+		Version:         comment.Version,
+	}
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	output := t.UTC()
+	return &output
+}
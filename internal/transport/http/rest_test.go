@@ -0,0 +1,145 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"ozon/internal/service"
+	"ozon/internal/transport/graph/mocks"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/logger"
+)
+
+func TestREST(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		setup      func(s *mocks.Service)
+		wantStatus int
+		wantBody   string
+		notBody    string
+	}{
+		{
+			name:   "get post",
+			method: http.MethodGet,
+			target: "/api/v1/posts/1",
+			setup: func(s *mocks.Service) {
+				s.On("GetPostByID", mock.Anything, "1").
+					Return(&model.Post{ID: "1", Content: "hello", Status: model.PostStatusPublished}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"content":"hello"`,
+		},
+		{
+			name:   "missing post",
+			method: http.MethodGet,
+			target: "/api/v1/posts/2",
+			setup: func(s *mocks.Service) {
+				s.On("GetPostByID", mock.Anything, "2").Return(nil, service.ErrPostNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":{"status":404,"message":"post not found"}}`,
+		},
+		{
+			name:   "comments page",
+			method: http.MethodGet,
+			target: "/api/v1/posts/1/comments?offset=10&orderBy=TOP",
+			setup: func(s *mocks.Service) {
+				s.On("GetCommentByPostID", mock.Anything, "1", int32(10), model.CommentOrderTop).
+					Return([]*model.Comment{{ID: "5", PostID: "1"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"nextOffset":11`,
+		},
+		{
+			name:   "tagged posts page",
+			method: http.MethodGet,
+			target: "/api/v1/tags/go/posts?limit=2",
+			setup: func(s *mocks.Service) {
+				s.On("GetPostByTag", mock.Anything, "go", int32(3), (*string)(nil)).
+					Return([]*model.Post{{ID: "3"}, {ID: "2"}, {ID: "1"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"nextCursor":"2"`,
+		},
+		{
+			name:   "last page of tagged posts",
+			method: http.MethodGet,
+			target: "/api/v1/tags/go/posts?after=3",
+			setup: func(s *mocks.Service) {
+				s.On("GetPostByTag", mock.Anything, "go", int32(11), mock.MatchedBy(func(after *string) bool {
+					return after != nil && *after == "3"
+				})).Return([]*model.Post{{ID: "2"}, {ID: "1"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"id":"1"`,
+			notBody:    `nextCursor`,
+		},
+		{
+			name:       "bad offset",
+			method:     http.MethodGet,
+			target:     "/api/v1/posts?offset=-1",
+			setup:      func(s *mocks.Service) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"message":"invalid query parameter"`,
+		},
+		{
+			name:   "stale comment version",
+			method: http.MethodPatch,
+			target: "/api/v1/comments/5",
+			body:   `{"content":"edited","expectedVersion":1}`,
+			setup: func(s *mocks.Service) {
+				s.On("PutComment", mock.Anything, mock.MatchedBy(func(input model.PutCommentInput) bool {
+					return input.ID == "5" && *input.ExpectedVersion == 1
+				})).Return(nil, &model.VersionConflictError{Current: 2})
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `"currentVersion":2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := mocks.NewService(t)
+			tt.setup(srv)
+
+			e := echo.New()
+			handler := &Handler{service: srv, log: logger.Logger{Logger: zap.NewNop()}}
+			handler.registerREST(e)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+			if tt.notBody != "" {
+				assert.NotContains(t, rec.Body.String(), tt.notBody)
+			}
+		})
+	}
+}
+
+func TestREST_OpenAPI(t *testing.T) {
+	e := echo.New()
+	(&Handler{}).registerREST(e)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+
+	var doc map[string]any
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+}
@@ -0,0 +1,15 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"ozon/internal/settings"
+)
+
+// NewSettingsHandler serves the current runtime settings on the admin port.
+func NewSettingsHandler(e *echo.Echo, store *settings.Store) {
+	e.GET("/settings", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, store.Load())
+	})
+}
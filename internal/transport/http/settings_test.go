@@ -0,0 +1,108 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"ozon/internal/auth"
+	"ozon/internal/ratelimit"
+	"ozon/internal/settings"
+	"ozon/internal/transport/graph/mocks"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/logger"
+)
+
+func TestRateLimit(t *testing.T) {
+	srv := mocks.NewService(t)
+	srv.On("GetPost", mock.Anything, int32(0)).Return([]*model.Post{}, nil)
+
+	limiter := ratelimit.New(ratelimit.Config{RPS: 0.001, Burst: 1, TrustedProxies: []string{"10.0.0.1"}})
+
+	e := echo.New()
+	NewHandler(e, srv, logger.Logger{Logger: zap.NewNop()}, mocks.NewSubscription(t), WithRateLimiter(limiter))
+
+	get := func(peer, user, forwardedFor string) int {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/posts", nil)
+		request.RemoteAddr = peer + ":40000"
+		if user != "" {
+			request.Header.Set(auth.UserIDHeader, user)
+		}
+		if forwardedFor != "" {
+			request.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		}
+
+		response := httptest.NewRecorder()
+		e.ServeHTTP(response, request)
+		return response.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("192.0.2.1", "1", ""))
+	assert.Equal(t, http.StatusTooManyRequests, get("192.0.2.1", "2", ""), "X-User-ID does not pick the bucket")
+	assert.Equal(t, http.StatusTooManyRequests, get("192.0.2.1", "", "198.51.100.9"), "X-Forwarded-For of a client is ignored")
+	assert.Equal(t, http.StatusOK, get("192.0.2.2", "", ""), "addresses are limited separately")
+
+	assert.Equal(t, http.StatusOK, get("10.0.0.1", "", "198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, get("10.0.0.1", "", "198.51.100.1"), "clients behind a trusted proxy are limited by X-Forwarded-For")
+	assert.Equal(t, http.StatusOK, get("10.0.0.1", "", "198.51.100.2"))
+
+	limiter.Update(ratelimit.Config{})
+	assert.Equal(t, http.StatusOK, get("192.0.2.1", "", ""), "the limit is changed at runtime")
+}
+
+func TestFeatureToggles(t *testing.T) {
+	store := settings.NewStore(settings.Settings{Features: settings.Features{Introspection: true, Playground: true}})
+
+	e := echo.New()
+	NewHandler(e, mocks.NewService(t), logger.Logger{Logger: zap.NewNop()}, mocks.NewSubscription(t),
+		WithFeatures(func() settings.Features { return store.Load().Features }),
+	)
+
+	introspect := func() string {
+		request := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ __schema { queryType { name } } }"}`))
+		request.Header.Set("Content-Type", "application/json")
+
+		response := httptest.NewRecorder()
+		e.ServeHTTP(response, request)
+		return response.Body.String()
+	}
+
+	playground := func() int {
+		response := httptest.NewRecorder()
+		e.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+		return response.Code
+	}
+
+	assert.Contains(t, introspect(), `"queryType":{"name":"Query"}`)
+	assert.Equal(t, http.StatusOK, playground())
+
+	store.Update(settings.Settings{})
+
+	assert.Contains(t, introspect(), "introspection disabled")
+	assert.Equal(t, http.StatusNotFound, playground())
+}
+
+func TestSettingsHandler(t *testing.T) {
+	store := settings.NewStore(settings.Settings{LogLevel: "info", RateLimit: ratelimit.Config{RPS: 5, Burst: 10}})
+
+	admin := echo.New()
+	NewSettingsHandler(admin, store)
+
+	store.Update(settings.Settings{LogLevel: "debug", RateLimit: ratelimit.Config{RPS: 5, Burst: 10}})
+
+	response := httptest.NewRecorder()
+	admin.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/settings", nil))
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{
+		"logLevel": "debug",
+		"limits": {"postLen": 0, "commentLen": 0},
+		"rateLimit": {"rps": 5, "burst": 10},
+		"features": {"introspection": false, "playground": false}
+	}`, response.Body.String())
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/labstack/echo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+	"ozon/internal/tracing"
+)
+
+var tracer = tracing.Tracer("ozon/internal/transport/http")
+
+// tracingMiddleware continues the trace of the caller, taken from the W3C
+// traceparent header, and wraps the request in a server span. A handler
+// error is written out here to learn the status code, it is still returned
+// so outer middleware sees it; echo skips committed responses.
+func tracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		request := c.Request()
+		ctx := otel.GetTextMapPropagator().Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+
+		ctx, span := tracer.Start(ctx, request.Method+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(request.Method), semconv.HTTPRoute(c.Path())),
+		)
+		defer span.End()
+
+		c.SetRequest(request.WithContext(ctx))
+
+		err := next(c)
+		if err != nil {
+			span.RecordError(err)
+			c.Error(err)
+		}
+
+		status := c.Response().Status
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+
+		return err
+	}
+}
+
+// graphQLTracer is a gqlgen extension that starts a span per operation and
+// per field with a resolver, trivial fields of loaded objects are skipped.
+type graphQLTracer struct{}
+
+var (
+	_ graphql.HandlerExtension    = graphQLTracer{}
+	_ graphql.ResponseInterceptor = graphQLTracer{}
+	_ graphql.FieldInterceptor    = graphQLTracer{}
+)
+
+func (graphQLTracer) ExtensionName() string {
+	return "OpenTelemetry"
+}
+
+func (graphQLTracer) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (graphQLTracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+
+	var operationType, name string
+	if oc.Operation != nil {
+		operationType, name = string(oc.Operation.Operation), oc.Operation.Name
+	}
+
+	if name == "" {
+		name = "anonymous"
+	}
+
+	ctx, span := tracer.Start(ctx, "graphql."+operationType+" "+name,
+		trace.WithAttributes(
+			attribute.String("graphql.operation.name", name),
+			attribute.String("graphql.operation.type", operationType),
+		),
+	)
+	defer span.End()
+
+	response := next(ctx)
+	if response != nil && len(response.Errors) > 0 {
+		span.SetAttributes(attribute.Int("graphql.errors", len(response.Errors)))
+		span.SetStatus(codes.Error, response.Errors.Error())
+	}
+
+	return response
+}
+
+func (graphQLTracer) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	ctx, span := tracer.Start(ctx, fc.Object+"."+fc.Field.Name,
+		trace.WithAttributes(attribute.String("graphql.field.path", fc.Path().String())),
+	)
+
+	res, err := next(ctx)
+	tracing.End(span, err)
+
+	return res, err
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"ozon/internal/transport/graph/mocks"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/logger"
+)
+
+// The global tracer provider can be replaced only once, so the recorder is
+// shared between runs of the test.
+var recordSpans = sync.OnceValue(func() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return recorder
+})
+
+func TestTracing(t *testing.T) {
+	recorder := recordSpans()
+	recorded := len(recorder.Ended())
+
+	srv := mocks.NewService(t)
+	srv.On("GetPostByID", mock.Anything, "1").Return(&model.Post{ID: "1", Status: model.PostStatusPublished}, nil)
+
+	e := echo.New()
+	NewHandler(e, srv, logger.Logger{Logger: zap.NewNop()}, mocks.NewSubscription(t))
+
+	request := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query GetPost { getPostById(id: \"1\") { id } }"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	response := httptest.NewRecorder()
+	e.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	names := map[string]bool{}
+	for _, span := range recorder.Ended()[recorded:] {
+		names[span.Name()] = true
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String(), span.Name())
+	}
+
+	assert.True(t, names["POST /query"])
+	assert.True(t, names["graphql.query GetPost"])
+	assert.True(t, names["Query.getPostById"])
+}
+
+func TestTracingMiddleware_Error(t *testing.T) {
+	recorder := recordSpans()
+	recorded := len(recorder.Ended())
+
+	failure := errors.New("database is down")
+
+	var returned error
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			returned = next(c)
+			return returned
+		}
+	})
+	e.Use(tracingMiddleware)
+	e.GET("/fail", func(c echo.Context) error { return failure })
+
+	response := httptest.NewRecorder()
+	e.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.ErrorIs(t, returned, failure, "the error reaches the outer middleware")
+
+	spans := recorder.Ended()[recorded:]
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "exception", spans[0].Events()[0].Name)
+}
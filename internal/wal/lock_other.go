@@ -0,0 +1,20 @@
+//go:build !unix
+
+package wal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockDir creates the LOCK file in dir. Other systems do not lock it, the
+// directory must not be shared there.
+func lockDir(dir string) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, lockName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("wal: %w", err)
+	}
+
+	return f, nil
+}
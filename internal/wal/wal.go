@@ -0,0 +1,571 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"ozon/pkg/logger"
+)
+
+// Policies of Config.Fsync.
+const (
+	FsyncAlways   = "always"
+	FsyncInterval = "interval"
+	FsyncNever    = "never"
+)
+
+type Config struct {
+	// Dir holds the log and its snapshot, empty disables persistence.
+	Dir string `mapstructure:"dir"`
+	// Fsync is when appended entries are flushed to disk: always, before
+	// Append returns; interval, every FsyncInterval; never, when the OS
+	// decides. Entries not flushed are lost if the machine crashes.
+	Fsync         string        `mapstructure:"fsync"`
+	FsyncInterval time.Duration `mapstructure:"fsyncInterval"`
+	// SnapshotInterval is how often the state is written to a snapshot,
+	// replacing the entries before it.
+	SnapshotInterval time.Duration `mapstructure:"snapshotInterval"`
+}
+
+// ErrCorrupted is returned by Open when a checksum does not match or
+// entries are missing. Only an entry cut short at the end of the log is
+// taken for an interrupted write and dropped.
+var ErrCorrupted = errors.New("wal: corrupted")
+
+// ErrLocked is returned by Open when the directory is held by another open
+// Log, in this process or another one.
+var ErrLocked = errors.New("wal: directory is in use")
+
+const (
+	// headerSize is the size of the frame header: the sequence number, the
+	// payload length, the CRC-32C of both and the CRC-32C of the payload.
+	// The header has its own checksum so that a damaged length is not
+	// mistaken for an entry cut short.
+	headerSize = 20
+	maxPayload = 1 << 30
+
+	segmentSuffix = ".wal"
+	snapshotName  = "snapshot"
+	lockName      = "LOCK"
+)
+
+var table = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	errTorn     = errors.New("frame is cut short")
+	errChecksum = errors.New("checksum mismatch")
+)
+
+// Log is a write-ahead log. Entries are numbered from 1 and appended to
+// segment files named after their first entry; a snapshot of the state at
+// some entry replaces the segments before it.
+type Log struct {
+	cfg Config
+	log logger.Logger
+	// lock holds the lock on the directory until Close.
+	lock *os.File
+
+	mu      sync.Mutex
+	file    *os.File
+	entries int
+	// seq is the last entry Append returned nil for.
+	seq   uint64
+	dirty bool
+	// err is the first write error, the log refuses to append after it so
+	// that no entry is written after a missing one.
+	err error
+
+	// snapshotMu serializes compactions, snapshotSeq is guarded by mu.
+	snapshotMu  sync.Mutex
+	snapshotSeq uint64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open opens the log in cfg.Dir and recovers the state it holds: restore
+// receives the latest snapshot, if there is one, then apply receives every
+// later entry in order. An entry cut short by a crash at the end of the log
+// is dropped. The directory is locked until Close, opening it again fails
+// with ErrLocked.
+func Open(cfg Config, log logger.Logger, restore func(snapshot []byte) error, apply func(entry []byte) error) (*Log, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("wal: %w", err)
+	}
+
+	lock, err := lockDir(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Log{cfg: cfg, log: log, lock: lock}
+	if err := l.open(restore, apply); err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	l.stop, l.done = make(chan struct{}), make(chan struct{})
+	if cfg.Fsync == FsyncInterval && cfg.FsyncInterval > 0 {
+		go l.syncLoop(cfg.FsyncInterval)
+	} else {
+		close(l.done)
+	}
+
+	return l, nil
+}
+
+// open recovers the state and opens the last segment for appending.
+func (l *Log) open(restore func(snapshot []byte) error, apply func(entry []byte) error) error {
+	segments, err := l.load(math.MaxUint64, restore, apply)
+	if err != nil {
+		return err
+	}
+
+	if len(segments) > 0 {
+		last := segments[len(segments)-1]
+		if last > l.seq+1 {
+			return fmt.Errorf("%w: entries %d to %d are missing", ErrCorrupted, l.seq+1, last-1)
+		}
+		l.file, err = os.OpenFile(l.segmentPath(last), os.O_WRONLY|os.O_APPEND, 0o644)
+		l.entries = int(l.seq + 1 - last)
+	} else {
+		l.file, err = l.create(l.seq + 1)
+	}
+	if err != nil {
+		return fmt.Errorf("wal: %w", err)
+	}
+
+	return nil
+}
+
+// load passes the snapshot to restore and the entries after it up to entry
+// through to apply, and returns the segments.
+func (l *Log) load(through uint64, restore func(snapshot []byte) error, apply func(entry []byte) error) ([]uint64, error) {
+	seq, snapshot, err := readSnapshot(filepath.Join(l.cfg.Dir, snapshotName))
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		if err := restore(snapshot); err != nil {
+			return nil, fmt.Errorf("wal: restore snapshot: %w", err)
+		}
+	}
+	l.seq, l.snapshotSeq = seq, seq
+
+	segments, err := l.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	for n, first := range segments {
+		last := n == len(segments)-1
+		if err := l.replay(first, last, through, apply); err != nil {
+			return nil, err
+		}
+	}
+
+	return segments, nil
+}
+
+// Reload recovers the state again, as Open does, from the entries Append
+// returned nil for. It drops the changes of entries that failed to append
+// from a state they were already applied to.
+func (l *Log) Reload(restore func(snapshot []byte) error, apply func(entry []byte) error) error {
+	l.snapshotMu.Lock()
+	defer l.snapshotMu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seq := l.seq
+	if _, err := l.load(seq, restore, apply); err != nil {
+		l.seq = seq
+		return err
+	}
+	if l.seq != seq {
+		err := fmt.Errorf("%w: entries %d to %d are missing", ErrCorrupted, l.seq+1, seq)
+		l.seq = seq
+		return err
+	}
+
+	return nil
+}
+
+// Err returns the error that stopped the log, nil while it accepts entries.
+func (l *Log) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.err
+}
+
+// replay applies the entries of a segment that are not in the snapshot, up
+// to entry through.
+func (l *Log) replay(first uint64, last bool, through uint64, apply func(entry []byte) error) error {
+	path := l.segmentPath(first)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("wal: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+
+	for expected := first; expected <= through; expected++ {
+		seq, data, size, err := decode(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		// Only the last entry written can be cut short by a crash.
+		if last && errors.Is(err, errTorn) {
+			l.log.Warn("dropping the incomplete entry at the end of the log",
+				zap.String("segment", path), zap.Int64("offset", offset), zap.String("err", err.Error()))
+			return truncate(path, offset)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s at offset %d: %v", ErrCorrupted, path, offset, err)
+		}
+		if seq != expected {
+			return fmt.Errorf("%w: %s at offset %d: entry %d, want %d", ErrCorrupted, path, offset, seq, expected)
+		}
+		offset += size
+
+		if seq <= l.seq {
+			continue
+		}
+		if seq != l.seq+1 {
+			return fmt.Errorf("%w: entries %d to %d are missing", ErrCorrupted, l.seq+1, seq-1)
+		}
+
+		if err := apply(data); err != nil {
+			return fmt.Errorf("wal: apply entry %d: %w", seq, err)
+		}
+		l.seq = seq
+	}
+
+	return nil
+}
+
+// Append writes an entry to the log, flushing it to disk first if the
+// policy is always. After an error the log is stopped: the entry is cut
+// from the segment if possible, and it is never replayed by Reload.
+func (l *Log) Append(entry []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return l.err
+	}
+
+	n, err := l.file.Write(encode(l.seq+1, entry))
+	if err != nil {
+		l.err = fmt.Errorf("wal: append: %w", err)
+		l.unwrite(n)
+		return l.err
+	}
+
+	if l.cfg.Fsync == FsyncAlways {
+		if err := l.file.Sync(); err != nil {
+			l.err = fmt.Errorf("wal: sync: %w", err)
+			l.unwrite(n)
+			return l.err
+		}
+	} else {
+		l.dirty = true
+	}
+
+	l.seq++
+	l.entries++
+
+	return nil
+}
+
+// unwrite cuts the last n bytes written from the segment, so that an entry
+// that failed to append is not replayed after a restart.
+func (l *Log) unwrite(n int) {
+	info, err := l.file.Stat()
+	if err == nil {
+		err = l.file.Truncate(info.Size() - int64(n))
+	}
+	if err != nil {
+		l.log.Error("failed to cut the entry that failed to append from the log", zap.String("err", err.Error()))
+	}
+}
+
+// Rotate starts a new segment and returns the last entry before it. The
+// caller snapshots its state as of that entry and passes it to Compact. ok
+// is false when nothing was appended since the last snapshot.
+func (l *Log) Rotate() (seq uint64, ok bool, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return 0, false, l.err
+	}
+	if l.seq == l.snapshotSeq {
+		return l.seq, false, nil
+	}
+	if l.entries == 0 {
+		return l.seq, true, nil
+	}
+
+	if err := l.file.Sync(); err != nil {
+		l.err = fmt.Errorf("wal: sync: %w", err)
+		return 0, false, l.err
+	}
+	if err := l.file.Close(); err != nil {
+		l.err = fmt.Errorf("wal: close: %w", err)
+		return 0, false, l.err
+	}
+
+	file, err := l.create(l.seq + 1)
+	if err != nil {
+		l.err = fmt.Errorf("wal: %w", err)
+		return 0, false, l.err
+	}
+	l.file, l.entries, l.dirty = file, 0, false
+
+	return l.seq, true, nil
+}
+
+// Compact replaces the snapshot with the state as of entry seq and removes
+// the segments it covers.
+func (l *Log) Compact(seq uint64, snapshot []byte) error {
+	l.snapshotMu.Lock()
+	defer l.snapshotMu.Unlock()
+
+	path := filepath.Join(l.cfg.Dir, snapshotName)
+	if err := writeFile(path, encode(seq, snapshot)); err != nil {
+		return fmt.Errorf("wal: write snapshot: %w", err)
+	}
+
+	l.mu.Lock()
+	l.snapshotSeq = max(l.snapshotSeq, seq)
+	l.mu.Unlock()
+
+	segments, err := l.segments()
+	if err != nil {
+		return err
+	}
+
+	// A segment is covered when the next one starts right after the
+	// snapshot or earlier.
+	for n := 0; n+1 < len(segments) && segments[n+1] <= seq+1; n++ {
+		if err := os.Remove(l.segmentPath(segments[n])); err != nil {
+			return fmt.Errorf("wal: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Close flushes the log to disk, closes it and releases the directory.
+func (l *Log) Close() error {
+	close(l.stop)
+	<-l.done
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return errors.Join(l.file.Sync(), l.file.Close(), l.lock.Close())
+}
+
+func (l *Log) syncLoop(interval time.Duration) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		l.mu.Lock()
+		if l.dirty && l.err == nil {
+			if err := l.file.Sync(); err != nil {
+				l.err = fmt.Errorf("wal: sync: %w", err)
+			}
+			l.dirty = false
+		}
+		l.mu.Unlock()
+	}
+}
+
+// segments returns the first entries of the segments in the directory in
+// ascending order.
+func (l *Log) segments() ([]uint64, error) {
+	files, err := os.ReadDir(l.cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("wal: %w", err)
+	}
+
+	var segments []uint64
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), segmentSuffix)
+		if !ok {
+			continue
+		}
+
+		first, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, first)
+	}
+	slices.Sort(segments)
+
+	return segments, nil
+}
+
+func (l *Log) segmentPath(first uint64) string {
+	return filepath.Join(l.cfg.Dir, fmt.Sprintf("%020d%s", first, segmentSuffix))
+}
+
+func (l *Log) create(first uint64) (*os.File, error) {
+	file, err := os.OpenFile(l.segmentPath(first), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, syncDir(l.cfg.Dir)
+}
+
+func encode(seq uint64, data []byte) []byte {
+	frame := make([]byte, headerSize+len(data))
+	binary.LittleEndian.PutUint64(frame[0:8], seq)
+	binary.LittleEndian.PutUint32(frame[8:12], uint32(len(data)))
+	binary.LittleEndian.PutUint32(frame[12:16], crc32.Checksum(frame[:12], table))
+	binary.LittleEndian.PutUint32(frame[16:20], crc32.Checksum(data, table))
+	copy(frame[headerSize:], data)
+
+	return frame
+}
+
+// decode reads a frame and returns its size. It returns io.EOF when there
+// is no frame left and errTorn when the file ends within the frame.
+func decode(r io.Reader) (seq uint64, data []byte, size int64, err error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, 0, errTorn
+		}
+		return 0, nil, 0, err
+	}
+
+	if crc32.Checksum(header[:12], table) != binary.LittleEndian.Uint32(header[12:16]) {
+		return 0, nil, 0, fmt.Errorf("header: %w", errChecksum)
+	}
+
+	seq = binary.LittleEndian.Uint64(header[0:8])
+	length := binary.LittleEndian.Uint32(header[8:12])
+	if length > maxPayload {
+		return 0, nil, 0, fmt.Errorf("entry of %d bytes is too large", length)
+	}
+
+	data = make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, 0, errTorn
+		}
+		return 0, nil, 0, err
+	}
+
+	size = headerSize + int64(length)
+	if crc32.Checksum(data, table) != binary.LittleEndian.Uint32(header[16:20]) {
+		return 0, nil, size, fmt.Errorf("payload: %w", errChecksum)
+	}
+
+	return seq, data, size, nil
+}
+
+func readSnapshot(path string) (uint64, []byte, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("wal: %w", err)
+	}
+	defer f.Close()
+
+	seq, data, _, err := decode(bufio.NewReader(f))
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %s: %v", ErrCorrupted, path, err)
+	}
+
+	return seq, data, nil
+}
+
+// writeFile replaces a file atomically: the content is written to a
+// temporary file that is renamed once it is on disk.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+func truncate(path string, size int64) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("wal: %w", err)
+	}
+	defer f.Close()
+
+	if err := f.Truncate(size); err != nil {
+		return fmt.Errorf("wal: %w", err)
+	}
+
+	return f.Sync()
+}
+
+// syncDir flushes the directory entries, so that created and renamed
+// files survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,279 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"ozon/pkg/logger"
+)
+
+// recovered is what Open passed to the callbacks.
+type recovered struct {
+	snapshot string
+	entries  []string
+}
+
+func open(t *testing.T, cfg Config) (*Log, recovered, error) {
+	t.Helper()
+
+	var r recovered
+	l, err := Open(cfg, logger.Logger{Logger: zap.NewNop()},
+		func(snapshot []byte) error {
+			r.snapshot = string(snapshot)
+			return nil
+		},
+		func(entry []byte) error {
+			r.entries = append(r.entries, string(entry))
+			return nil
+		},
+	)
+
+	return l, r, err
+}
+
+func appendAll(t *testing.T, l *Log, entries ...string) {
+	t.Helper()
+
+	for _, entry := range entries {
+		require.NoError(t, l.Append([]byte(entry)))
+	}
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+
+	return files
+}
+
+func TestLog_Replay(t *testing.T) {
+	cfg := Config{Dir: t.TempDir(), Fsync: FsyncAlways}
+
+	l, r, err := open(t, cfg)
+	require.NoError(t, err)
+	assert.Empty(t, r.entries)
+
+	appendAll(t, l, "a", "b", "c")
+	require.NoError(t, l.Close())
+
+	l, r, err = open(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, recovered{entries: []string{"a", "b", "c"}}, r)
+
+	appendAll(t, l, "d")
+	require.NoError(t, l.Close())
+
+	_, r, err = open(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, r.entries, "appends continue the log after a restart")
+}
+
+func TestLog_Lock(t *testing.T) {
+	cfg := Config{Dir: t.TempDir(), Fsync: FsyncAlways}
+
+	l, _, err := open(t, cfg)
+	require.NoError(t, err)
+
+	_, _, err = open(t, cfg)
+	require.ErrorIs(t, err, ErrLocked)
+
+	require.NoError(t, l.Close())
+
+	l, _, err = open(t, cfg)
+	require.NoError(t, err, "Close releases the directory")
+	require.NoError(t, l.Close())
+}
+
+func TestLog_FailedAppend(t *testing.T) {
+	cfg := Config{Dir: t.TempDir(), Fsync: FsyncAlways}
+
+	l, _, err := open(t, cfg)
+	require.NoError(t, err)
+	appendAll(t, l, "a", "b")
+
+	// A read-only handle makes the next write fail.
+	file := l.file
+	l.file, err = os.Open(segmentFiles(t, cfg.Dir)[0])
+	require.NoError(t, err)
+
+	err = l.Append([]byte("c"))
+	require.Error(t, err)
+	assert.Equal(t, err, l.Err())
+	assert.Equal(t, err, l.Append([]byte("d")), "the log is stopped")
+
+	var r recovered
+	require.NoError(t, l.Reload(
+		func(snapshot []byte) error {
+			r.snapshot = string(snapshot)
+			return nil
+		},
+		func(entry []byte) error {
+			r.entries = append(r.entries, string(entry))
+			return nil
+		},
+	))
+	assert.Equal(t, recovered{entries: []string{"a", "b"}}, r)
+
+	l.file.Close()
+	l.file = file
+	require.NoError(t, l.Close())
+
+	_, r, err = open(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, r.entries)
+}
+
+func TestLog_Compact(t *testing.T) {
+	cfg := Config{Dir: t.TempDir(), Fsync: FsyncNever}
+
+	l, _, err := open(t, cfg)
+	require.NoError(t, err)
+
+	appendAll(t, l, "a", "b")
+
+	seq, ok, err := l.Rotate()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.EqualValues(t, 2, seq)
+
+	appendAll(t, l, "c")
+	require.NoError(t, l.Compact(seq, []byte("ab")))
+	assert.Len(t, segmentFiles(t, cfg.Dir), 1, "the segment covered by the snapshot is removed")
+
+	_, ok, err = l.Rotate()
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, l.Close())
+
+	l, r, err := open(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, recovered{snapshot: "ab", entries: []string{"c"}}, r)
+
+	seq, _, err = l.Rotate()
+	require.NoError(t, err)
+	require.NoError(t, l.Compact(seq, []byte("abc")))
+
+	_, ok, err = l.Rotate()
+	require.NoError(t, err)
+	assert.False(t, ok, "nothing was appended since the snapshot")
+	require.NoError(t, l.Close())
+
+	_, r, err = open(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, recovered{snapshot: "abc"}, r)
+}
+
+func TestLog_TornTail(t *testing.T) {
+	tests := []struct {
+		name string
+		// cut is the number of bytes of the second entry left.
+		cut int64
+	}{
+		{name: "within the header", cut: headerSize / 2},
+		{name: "within the payload", cut: headerSize + 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Dir: t.TempDir(), Fsync: FsyncAlways}
+
+			l, _, err := open(t, cfg)
+			require.NoError(t, err)
+			appendAll(t, l, "first", "second")
+			require.NoError(t, l.Close())
+
+			segment := segmentFiles(t, cfg.Dir)[0]
+			require.NoError(t, os.Truncate(segment, headerSize+int64(len("first"))+tt.cut))
+
+			l, r, err := open(t, cfg)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"first"}, r.entries, "the incomplete entry is dropped")
+
+			appendAll(t, l, "third")
+			require.NoError(t, l.Close())
+
+			_, r, err = open(t, cfg)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"first", "third"}, r.entries)
+		})
+	}
+}
+
+func TestLog_Corrupted(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, dir string)
+	}{
+		{
+			name: "entry",
+			corrupt: func(t *testing.T, dir string) {
+				segment := segmentFiles(t, dir)[0]
+
+				data, err := os.ReadFile(segment)
+				require.NoError(t, err)
+				data[headerSize] ^= 0xff
+				require.NoError(t, os.WriteFile(segment, data, 0o644))
+			},
+		},
+		{
+			name: "length of the last entry",
+			corrupt: func(t *testing.T, dir string) {
+				segment := segmentFiles(t, dir)[1]
+
+				data, err := os.ReadFile(segment)
+				require.NoError(t, err)
+				data[8] ^= 0x10
+				require.NoError(t, os.WriteFile(segment, data, 0o644))
+			},
+		},
+		{
+			name: "payload of the last entry",
+			corrupt: func(t *testing.T, dir string) {
+				segment := segmentFiles(t, dir)[1]
+
+				data, err := os.ReadFile(segment)
+				require.NoError(t, err)
+				data[len(data)-1] ^= 0xff
+				require.NoError(t, os.WriteFile(segment, data, 0o644))
+			},
+		},
+		{
+			name: "snapshot",
+			corrupt: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, snapshotName), []byte("garbage that is long enough"), 0o644))
+			},
+		},
+		{
+			name: "missing segment",
+			corrupt: func(t *testing.T, dir string) {
+				require.NoError(t, os.Remove(segmentFiles(t, dir)[0]))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Dir: t.TempDir(), Fsync: FsyncAlways}
+
+			l, _, err := open(t, cfg)
+			require.NoError(t, err)
+			appendAll(t, l, "a", "b")
+			_, _, err = l.Rotate()
+			require.NoError(t, err)
+			appendAll(t, l, "c")
+			require.NoError(t, l.Close())
+
+			tt.corrupt(t, cfg.Dir)
+
+			_, _, err = open(t, cfg)
+			assert.ErrorIs(t, err, ErrCorrupted)
+		})
+	}
+}
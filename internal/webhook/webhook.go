@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"ozon/internal/outbox"
+	"ozon/internal/transport/graph/model"
+)
+
+// Headers of a webhook request.
+const (
+	EventHeader     = "X-Webhook-Event"
+	EventIDHeader   = "X-Webhook-Event-Id"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+var events = map[outbox.EventType]model.WebhookEvent{
+	outbox.PostCreated:    model.WebhookEventPostCreated,
+	outbox.PostPublished:  model.WebhookEventPostPublished,
+	outbox.PostUpdated:    model.WebhookEventPostUpdated,
+	outbox.PostDeleted:    model.WebhookEventPostDeleted,
+	outbox.CommentCreated: model.WebhookEventCommentCreated,
+	outbox.CommentUpdated: model.WebhookEventCommentUpdated,
+	outbox.CommentDeleted: model.WebhookEventCommentDeleted,
+}
+
+// Delivery is a pending webhook request together with its receiver. EventID
+// is the ID of the outbox event, it is the same for every delivery of the
+// event.
+type Delivery struct {
+	ID        string
+	EventID   int64
+	WebhookID string
+	URL       string
+	Secret    string
+	Event     model.WebhookEvent
+	Payload   json.RawMessage
+	Attempts  int32
+	CreatedAt time.Time
+}
+
+// eventKey returns the ID receivers deduplicate the event on. Deliveries
+// enqueued before the event ID was stored fall back to their own ID.
+func (d Delivery) eventKey() string {
+	if d.EventID == 0 {
+		return d.ID
+	}
+
+	return strconv.FormatInt(d.EventID, 10)
+}
+
+// Attempt is the outcome of sending a Delivery.
+type Attempt struct {
+	DeliveryID    string
+	Status        model.DeliveryStatus
+	Attempts      int32
+	ResponseCode  *int32
+	Error         *string
+	NextAttemptAt *time.Time
+}
+
+type Store interface {
+	EnqueueWebhookDeliveries(ctx context.Context, eventID int64, event model.WebhookEvent, payload json.RawMessage) (int64, error)
+	FetchWebhookDeliveries(ctx context.Context, now time.Time, limit int32) ([]Delivery, error)
+	RecordWebhookAttempt(ctx context.Context, attempt Attempt) error
+}
+
+// Sink turns outbox events into deliveries for the webhooks subscribed to them.
+type Sink struct {
+	store Store
+}
+
+func NewSink(store Store) *Sink {
+	return &Sink{store: store}
+}
+
+func (s *Sink) Deliver(ctx context.Context, event outbox.Event) error {
+	webhookEvent, ok := events[event.Type]
+	if !ok {
+		return nil
+	}
+
+	_, err := s.store.EnqueueWebhookDeliveries(ctx, event.ID, webhookEvent, event.Payload)
+
+	return err
+}
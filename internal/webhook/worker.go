@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/logger"
+)
+
+const (
+	pollInterval   = time.Second
+	batchSize      = 20
+	requestTimeout = 10 * time.Second
+
+	maxAttempts = 8
+	retryBase   = 10 * time.Second
+	retryMax    = time.Hour
+)
+
+// body is the JSON sent to the receivers. ID is the ID of the outbox event,
+// so it stays the same across retries and redeliveries of the event.
+type body struct {
+	ID        string             `json:"id"`
+	Event     model.WebhookEvent `json:"event"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Data      json.RawMessage    `json:"data"`
+}
+
+// Worker sends the pending deliveries and reschedules the failed ones with
+// exponential backoff until maxAttempts is reached.
+type Worker struct {
+	store  Store
+	client *http.Client
+	log    logger.Logger
+}
+
+func NewWorker(store Store, log logger.Logger) *Worker {
+	return &Worker{
+		store:  store,
+		client: &http.Client{Timeout: requestTimeout},
+		log:    log,
+	}
+}
+
+// Run blocks until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.process(ctx)
+		}
+	}
+}
+
+func (w *Worker) process(ctx context.Context) {
+	deliveries, err := w.store.FetchWebhookDeliveries(ctx, time.Now().UTC(), batchSize)
+	if err != nil {
+		w.log.Error("failed to fetch webhook deliveries", zap.String("err", err.Error()))
+		return
+	}
+
+	for _, delivery := range deliveries {
+		attempt := w.send(ctx, delivery)
+
+		if err = w.store.RecordWebhookAttempt(ctx, attempt); err != nil {
+			w.log.Error("failed to record webhook attempt", zap.String("id", delivery.ID), zap.String("err", err.Error()))
+		}
+	}
+}
+
+func (w *Worker) send(ctx context.Context, delivery Delivery) Attempt {
+	attempt := Attempt{
+		DeliveryID: delivery.ID,
+		Attempts:   delivery.Attempts + 1,
+	}
+
+	code, err := w.post(ctx, delivery)
+	if code != 0 {
+		attempt.ResponseCode = &code
+	}
+
+	if err == nil {
+		attempt.Status = model.DeliveryStatusSucceeded
+		return attempt
+	}
+
+	w.log.Debug("Webhook delivery failed", zap.String("id", delivery.ID), zap.String("err", err.Error()))
+
+	message := err.Error()
+	attempt.Error = &message
+
+	if attempt.Attempts >= maxAttempts {
+		attempt.Status = model.DeliveryStatusFailed
+		return attempt
+	}
+
+	next := time.Now().UTC().Add(backoff(attempt.Attempts))
+	attempt.Status = model.DeliveryStatusPending
+	attempt.NextAttemptAt = &next
+
+	return attempt
+}
+
+func (w *Worker) post(ctx context.Context, delivery Delivery) (int32, error) {
+	payload, err := json.Marshal(body{
+		ID:        delivery.eventKey(),
+		Event:     delivery.Event,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(EventIDHeader, delivery.eventKey())
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return int32(resp.StatusCode), fmt.Errorf("receiver responded with %s", resp.Status)
+	}
+
+	return int32(resp.StatusCode), nil
+}
+
+// Sign returns the value of the X-Webhook-Signature header for the body.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff returns the delay before the next attempt: retryBase doubled after
+// every failed attempt, capped at retryMax.
+func backoff(attempts int32) time.Duration {
+	delay := retryBase
+	for n := int32(1); n < attempts && delay < retryMax; n++ {
+		delay *= 2
+	}
+
+	return min(delay, retryMax)
+}
@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"ozon/internal/transport/graph/model"
+	"ozon/pkg/logger"
+)
+
+type memoryStore struct {
+	deliveries []Delivery
+	attempts   []Attempt
+}
+
+func (m *memoryStore) EnqueueWebhookDeliveries(ctx context.Context, eventID int64, event model.WebhookEvent, payload json.RawMessage) (int64, error) {
+	return 0, nil
+}
+
+func (m *memoryStore) FetchWebhookDeliveries(ctx context.Context, now time.Time, limit int32) ([]Delivery, error) {
+	return m.deliveries, nil
+}
+
+func (m *memoryStore) RecordWebhookAttempt(ctx context.Context, attempt Attempt) error {
+	m.attempts = append(m.attempts, attempt)
+	return nil
+}
+
+func TestWorker_process(t *testing.T) {
+	const secret = "s3cret"
+
+	tests := []struct {
+		name         string
+		responseCode int
+		attempts     int32
+		wantStatus   model.DeliveryStatus
+		wantRetry    bool
+	}{
+		{
+			name:         "receiver accepts the delivery",
+			responseCode: http.StatusNoContent,
+			wantStatus:   model.DeliveryStatusSucceeded,
+		},
+		{
+			name:         "receiver fails, retry is scheduled",
+			responseCode: http.StatusServiceUnavailable,
+			wantStatus:   model.DeliveryStatusPending,
+			wantRetry:    true,
+		},
+		{
+			name:         "receiver fails on the last attempt",
+			responseCode: http.StatusInternalServerError,
+			attempts:     maxAttempts - 1,
+			wantStatus:   model.DeliveryStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received body
+
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				payload, _ := io.ReadAll(r.Body)
+
+				assert.Equal(t, Sign(secret, payload), r.Header.Get(SignatureHeader))
+				assert.Equal(t, string(model.WebhookEventPostCreated), r.Header.Get(EventHeader))
+				assert.Equal(t, "42", r.Header.Get(EventIDHeader))
+				assert.Equal(t, "7", r.Header.Get(DeliveryHeader))
+				assert.NoError(t, json.Unmarshal(payload, &received))
+
+				w.WriteHeader(tt.responseCode)
+			}))
+			defer receiver.Close()
+
+			store := &memoryStore{deliveries: []Delivery{{
+				ID:       "7",
+				EventID:  42,
+				URL:      receiver.URL,
+				Secret:   secret,
+				Event:    model.WebhookEventPostCreated,
+				Payload:  json.RawMessage(`{"id":"1"}`),
+				Attempts: tt.attempts,
+			}}}
+
+			NewWorker(store, logger.Logger{Logger: zap.NewNop()}).process(context.Background())
+
+			assert.Equal(t, "42", received.ID, "the body carries the ID of the outbox event")
+			assert.JSONEq(t, `{"id":"1"}`, string(received.Data))
+
+			if assert.Len(t, store.attempts, 1) {
+				attempt := store.attempts[0]
+				assert.Equal(t, tt.wantStatus, attempt.Status)
+				assert.Equal(t, tt.attempts+1, attempt.Attempts)
+				assert.Equal(t, int32(tt.responseCode), *attempt.ResponseCode)
+				assert.Equal(t, tt.wantRetry, attempt.NextAttemptAt != nil)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, retryBase, backoff(1))
+	assert.Equal(t, 4*retryBase, backoff(3))
+	assert.Equal(t, retryMax, backoff(maxAttempts+10))
+}
@@ -0,0 +1,1514 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: posts.proto
+
+package posts
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PostStatus int32
+
+const (
+	PostStatus_POST_STATUS_UNSPECIFIED PostStatus = 0
+	PostStatus_POST_STATUS_DRAFT       PostStatus = 1
+	PostStatus_POST_STATUS_SCHEDULED   PostStatus = 2
+	PostStatus_POST_STATUS_PUBLISHED   PostStatus = 3
+)
+
+// Enum value maps for PostStatus.
+var (
+	PostStatus_name = map[int32]string{
+		0: "POST_STATUS_UNSPECIFIED",
+		1: "POST_STATUS_DRAFT",
+		2: "POST_STATUS_SCHEDULED",
+		3: "POST_STATUS_PUBLISHED",
+	}
+	PostStatus_value = map[string]int32{
+		"POST_STATUS_UNSPECIFIED": 0,
+		"POST_STATUS_DRAFT":       1,
+		"POST_STATUS_SCHEDULED":   2,
+		"POST_STATUS_PUBLISHED":   3,
+	}
+)
+
+func (x PostStatus) Enum() *PostStatus {
+	p := new(PostStatus)
+	*p = x
+	return p
+}
+
+func (x PostStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PostStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_posts_proto_enumTypes[0].Descriptor()
+}
+
+func (PostStatus) Type() protoreflect.EnumType {
+	return &file_posts_proto_enumTypes[0]
+}
+
+func (x PostStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PostStatus.Descriptor instead.
+func (PostStatus) EnumDescriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{0}
+}
+
+type CommentOrder int32
+
+const (
+	// Defaults to the service order, newest first.
+	CommentOrder_COMMENT_ORDER_UNSPECIFIED  CommentOrder = 0
+	CommentOrder_COMMENT_ORDER_NEWEST       CommentOrder = 1
+	CommentOrder_COMMENT_ORDER_OLDEST       CommentOrder = 2
+	CommentOrder_COMMENT_ORDER_TOP          CommentOrder = 3
+	CommentOrder_COMMENT_ORDER_MOST_REPLIES CommentOrder = 4
+)
+
+// Enum value maps for CommentOrder.
+var (
+	CommentOrder_name = map[int32]string{
+		0: "COMMENT_ORDER_UNSPECIFIED",
+		1: "COMMENT_ORDER_NEWEST",
+		2: "COMMENT_ORDER_OLDEST",
+		3: "COMMENT_ORDER_TOP",
+		4: "COMMENT_ORDER_MOST_REPLIES",
+	}
+	CommentOrder_value = map[string]int32{
+		"COMMENT_ORDER_UNSPECIFIED":  0,
+		"COMMENT_ORDER_NEWEST":       1,
+		"COMMENT_ORDER_OLDEST":       2,
+		"COMMENT_ORDER_TOP":          3,
+		"COMMENT_ORDER_MOST_REPLIES": 4,
+	}
+)
+
+func (x CommentOrder) Enum() *CommentOrder {
+	p := new(CommentOrder)
+	*p = x
+	return p
+}
+
+func (x CommentOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CommentOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_posts_proto_enumTypes[1].Descriptor()
+}
+
+func (CommentOrder) Type() protoreflect.EnumType {
+	return &file_posts_proto_enumTypes[1]
+}
+
+func (x CommentOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CommentOrder.Descriptor instead.
+func (CommentOrder) EnumDescriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{1}
+}
+
+type Post struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AuthorId           string                 `protobuf:"bytes,2,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Content            string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	AreCommentsAllowed bool                   `protobuf:"varint,4,opt,name=are_comments_allowed,json=areCommentsAllowed,proto3" json:"are_comments_allowed,omitempty"`
+	CreatedAt          *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Unset until the first edit.
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Status        PostStatus             `protobuf:"varint,7,opt,name=status,proto3,enum=ozon.posts.v1.PostStatus" json:"status,omitempty"`
+	PublishAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=publish_at,json=publishAt,proto3" json:"publish_at,omitempty"`
+	IsPinned      bool                   `protobuf:"varint,9,opt,name=is_pinned,json=isPinned,proto3" json:"is_pinned,omitempty"`
+	CommentCount  int32                  `protobuf:"varint,10,opt,name=comment_count,json=commentCount,proto3" json:"comment_count,omitempty"`
+	Version       int32                  `protobuf:"varint,11,opt,name=version,proto3" json:"version,omitempty"`
+	Tags          []string               `protobuf:"bytes,12,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Post) Reset() {
+	*x = Post{}
+	mi := &file_posts_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Post) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Post) ProtoMessage() {}
+
+func (x *Post) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Post.ProtoReflect.Descriptor instead.
+func (*Post) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Post) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Post) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *Post) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Post) GetAreCommentsAllowed() bool {
+	if x != nil {
+		return x.AreCommentsAllowed
+	}
+	return false
+}
+
+func (x *Post) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Post) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Post) GetStatus() PostStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PostStatus_POST_STATUS_UNSPECIFIED
+}
+
+func (x *Post) GetPublishAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PublishAt
+	}
+	return nil
+}
+
+func (x *Post) GetIsPinned() bool {
+	if x != nil {
+		return x.IsPinned
+	}
+	return false
+}
+
+func (x *Post) GetCommentCount() int32 {
+	if x != nil {
+		return x.CommentCount
+	}
+	return 0
+}
+
+func (x *Post) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Post) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type Comment struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	PostId          string                 `protobuf:"bytes,2,opt,name=post_id,json=postId,proto3" json:"post_id,omitempty"`
+	ParentCommentId *string                `protobuf:"bytes,3,opt,name=parent_comment_id,json=parentCommentId,proto3,oneof" json:"parent_comment_id,omitempty"`
+	AuthorId        string                 `protobuf:"bytes,4,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Content         string                 `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Unset until the first edit.
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	IsPinned      bool                   `protobuf:"varint,8,opt,name=is_pinned,json=isPinned,proto3" json:"is_pinned,omitempty"`
+	IsLocked      bool                   `protobuf:"varint,9,opt,name=is_locked,json=isLocked,proto3" json:"is_locked,omitempty"`
+	ReplyCount    int32                  `protobuf:"varint,10,opt,name=reply_count,json=replyCount,proto3" json:"reply_count,omitempty"`
+	Version       int32                  `protobuf:"varint,11,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Comment) Reset() {
+	*x = Comment{}
+	mi := &file_posts_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Comment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Comment) ProtoMessage() {}
+
+func (x *Comment) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Comment.ProtoReflect.Descriptor instead.
+func (*Comment) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Comment) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Comment) GetPostId() string {
+	if x != nil {
+		return x.PostId
+	}
+	return ""
+}
+
+func (x *Comment) GetParentCommentId() string {
+	if x != nil && x.ParentCommentId != nil {
+		return *x.ParentCommentId
+	}
+	return ""
+}
+
+func (x *Comment) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *Comment) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Comment) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Comment) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Comment) GetIsPinned() bool {
+	if x != nil {
+		return x.IsPinned
+	}
+	return false
+}
+
+func (x *Comment) GetIsLocked() bool {
+	if x != nil {
+		return x.IsLocked
+	}
+	return false
+}
+
+func (x *Comment) GetReplyCount() int32 {
+	if x != nil {
+		return x.ReplyCount
+	}
+	return 0
+}
+
+func (x *Comment) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ListPostsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        int32                  `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPostsRequest) Reset() {
+	*x = ListPostsRequest{}
+	mi := &file_posts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPostsRequest) ProtoMessage() {}
+
+func (x *ListPostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPostsRequest.ProtoReflect.Descriptor instead.
+func (*ListPostsRequest) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListPostsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListPostsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Posts         []*Post                `protobuf:"bytes,1,rep,name=posts,proto3" json:"posts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPostsResponse) Reset() {
+	*x = ListPostsResponse{}
+	mi := &file_posts_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPostsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPostsResponse) ProtoMessage() {}
+
+func (x *ListPostsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPostsResponse.ProtoReflect.Descriptor instead.
+func (*ListPostsResponse) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListPostsResponse) GetPosts() []*Post {
+	if x != nil {
+		return x.Posts
+	}
+	return nil
+}
+
+type GetPostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPostRequest) Reset() {
+	*x = GetPostRequest{}
+	mi := &file_posts_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPostRequest) ProtoMessage() {}
+
+func (x *GetPostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPostRequest.ProtoReflect.Descriptor instead.
+func (*GetPostRequest) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetPostRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CreatePostRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	AuthorId           string                 `protobuf:"bytes,1,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Content            string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	AreCommentsAllowed bool                   `protobuf:"varint,3,opt,name=are_comments_allowed,json=areCommentsAllowed,proto3" json:"are_comments_allowed,omitempty"`
+	// Taken from the hashtags of content when empty.
+	Tags []string `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Defaults to PUBLISHED.
+	Status    PostStatus             `protobuf:"varint,5,opt,name=status,proto3,enum=ozon.posts.v1.PostStatus" json:"status,omitempty"`
+	PublishAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=publish_at,json=publishAt,proto3" json:"publish_at,omitempty"`
+	// Retries with the same key return the originally created post. Takes
+	// precedence over the idempotency-key metadata.
+	ClientMutationId *string `protobuf:"bytes,7,opt,name=client_mutation_id,json=clientMutationId,proto3,oneof" json:"client_mutation_id,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CreatePostRequest) Reset() {
+	*x = CreatePostRequest{}
+	mi := &file_posts_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePostRequest) ProtoMessage() {}
+
+func (x *CreatePostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePostRequest.ProtoReflect.Descriptor instead.
+func (*CreatePostRequest) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreatePostRequest) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *CreatePostRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *CreatePostRequest) GetAreCommentsAllowed() bool {
+	if x != nil {
+		return x.AreCommentsAllowed
+	}
+	return false
+}
+
+func (x *CreatePostRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreatePostRequest) GetStatus() PostStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PostStatus_POST_STATUS_UNSPECIFIED
+}
+
+func (x *CreatePostRequest) GetPublishAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PublishAt
+	}
+	return nil
+}
+
+func (x *CreatePostRequest) GetClientMutationId() string {
+	if x != nil && x.ClientMutationId != nil {
+		return *x.ClientMutationId
+	}
+	return ""
+}
+
+type UpdatePostRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Content            *string                `protobuf:"bytes,2,opt,name=content,proto3,oneof" json:"content,omitempty"`
+	AreCommentsAllowed *bool                  `protobuf:"varint,3,opt,name=are_comments_allowed,json=areCommentsAllowed,proto3,oneof" json:"are_comments_allowed,omitempty"`
+	// Replaces the tags of the post when update_tags is set.
+	Tags       []string               `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	UpdateTags bool                   `protobuf:"varint,5,opt,name=update_tags,json=updateTags,proto3" json:"update_tags,omitempty"`
+	Status     PostStatus             `protobuf:"varint,6,opt,name=status,proto3,enum=ozon.posts.v1.PostStatus" json:"status,omitempty"`
+	PublishAt  *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=publish_at,json=publishAt,proto3" json:"publish_at,omitempty"`
+	// When set, the update fails with ABORTED if the post has been edited since.
+	ExpectedVersion *int32 `protobuf:"varint,8,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdatePostRequest) Reset() {
+	*x = UpdatePostRequest{}
+	mi := &file_posts_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdatePostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdatePostRequest) ProtoMessage() {}
+
+func (x *UpdatePostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdatePostRequest.ProtoReflect.Descriptor instead.
+func (*UpdatePostRequest) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdatePostRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdatePostRequest) GetContent() string {
+	if x != nil && x.Content != nil {
+		return *x.Content
+	}
+	return ""
+}
+
+func (x *UpdatePostRequest) GetAreCommentsAllowed() bool {
+	if x != nil && x.AreCommentsAllowed != nil {
+		return *x.AreCommentsAllowed
+	}
+	return false
+}
+
+func (x *UpdatePostRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *UpdatePostRequest) GetUpdateTags() bool {
+	if x != nil {
+		return x.UpdateTags
+	}
+	return false
+}
+
+func (x *UpdatePostRequest) GetStatus() PostStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PostStatus_POST_STATUS_UNSPECIFIED
+}
+
+func (x *UpdatePostRequest) GetPublishAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PublishAt
+	}
+	return nil
+}
+
+func (x *UpdatePostRequest) GetExpectedVersion() int32 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type DeletePostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePostRequest) Reset() {
+	*x = DeletePostRequest{}
+	mi := &file_posts_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePostRequest) ProtoMessage() {}
+
+func (x *DeletePostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePostRequest.ProtoReflect.Descriptor instead.
+func (*DeletePostRequest) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeletePostRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeletePostResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePostResponse) Reset() {
+	*x = DeletePostResponse{}
+	mi := &file_posts_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePostResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePostResponse) ProtoMessage() {}
+
+func (x *DeletePostResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePostResponse.ProtoReflect.Descriptor instead.
+func (*DeletePostResponse) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{8}
+}
+
+type ListCommentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PostId        string                 `protobuf:"bytes,1,opt,name=post_id,json=postId,proto3" json:"post_id,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	OrderBy       CommentOrder           `protobuf:"varint,3,opt,name=order_by,json=orderBy,proto3,enum=ozon.posts.v1.CommentOrder" json:"order_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommentsRequest) Reset() {
+	*x = ListCommentsRequest{}
+	mi := &file_posts_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommentsRequest) ProtoMessage() {}
+
+func (x *ListCommentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommentsRequest.ProtoReflect.Descriptor instead.
+func (*ListCommentsRequest) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListCommentsRequest) GetPostId() string {
+	if x != nil {
+		return x.PostId
+	}
+	return ""
+}
+
+func (x *ListCommentsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListCommentsRequest) GetOrderBy() CommentOrder {
+	if x != nil {
+		return x.OrderBy
+	}
+	return CommentOrder_COMMENT_ORDER_UNSPECIFIED
+}
+
+type ListRepliesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CommentId     string                 `protobuf:"bytes,1,opt,name=comment_id,json=commentId,proto3" json:"comment_id,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	OrderBy       CommentOrder           `protobuf:"varint,3,opt,name=order_by,json=orderBy,proto3,enum=ozon.posts.v1.CommentOrder" json:"order_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRepliesRequest) Reset() {
+	*x = ListRepliesRequest{}
+	mi := &file_posts_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRepliesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRepliesRequest) ProtoMessage() {}
+
+func (x *ListRepliesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRepliesRequest.ProtoReflect.Descriptor instead.
+func (*ListRepliesRequest) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListRepliesRequest) GetCommentId() string {
+	if x != nil {
+		return x.CommentId
+	}
+	return ""
+}
+
+func (x *ListRepliesRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListRepliesRequest) GetOrderBy() CommentOrder {
+	if x != nil {
+		return x.OrderBy
+	}
+	return CommentOrder_COMMENT_ORDER_UNSPECIFIED
+}
+
+type ListCommentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Comments      []*Comment             `protobuf:"bytes,1,rep,name=comments,proto3" json:"comments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommentsResponse) Reset() {
+	*x = ListCommentsResponse{}
+	mi := &file_posts_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommentsResponse) ProtoMessage() {}
+
+func (x *ListCommentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommentsResponse.ProtoReflect.Descriptor instead.
+func (*ListCommentsResponse) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListCommentsResponse) GetComments() []*Comment {
+	if x != nil {
+		return x.Comments
+	}
+	return nil
+}
+
+type PostCommentRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PostId          string                 `protobuf:"bytes,1,opt,name=post_id,json=postId,proto3" json:"post_id,omitempty"`
+	ParentCommentId *string                `protobuf:"bytes,2,opt,name=parent_comment_id,json=parentCommentId,proto3,oneof" json:"parent_comment_id,omitempty"`
+	AuthorId        string                 `protobuf:"bytes,3,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Content         string                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	// Retries with the same key return the originally created comment. Takes
+	// precedence over the idempotency-key metadata.
+	ClientMutationId *string `protobuf:"bytes,5,opt,name=client_mutation_id,json=clientMutationId,proto3,oneof" json:"client_mutation_id,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *PostCommentRequest) Reset() {
+	*x = PostCommentRequest{}
+	mi := &file_posts_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostCommentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostCommentRequest) ProtoMessage() {}
+
+func (x *PostCommentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostCommentRequest.ProtoReflect.Descriptor instead.
+func (*PostCommentRequest) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PostCommentRequest) GetPostId() string {
+	if x != nil {
+		return x.PostId
+	}
+	return ""
+}
+
+func (x *PostCommentRequest) GetParentCommentId() string {
+	if x != nil && x.ParentCommentId != nil {
+		return *x.ParentCommentId
+	}
+	return ""
+}
+
+func (x *PostCommentRequest) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *PostCommentRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *PostCommentRequest) GetClientMutationId() string {
+	if x != nil && x.ClientMutationId != nil {
+		return *x.ClientMutationId
+	}
+	return ""
+}
+
+type UpdateCommentRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Content string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	// When set, the update fails with ABORTED if the comment has been edited since.
+	ExpectedVersion *int32 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateCommentRequest) Reset() {
+	*x = UpdateCommentRequest{}
+	mi := &file_posts_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCommentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCommentRequest) ProtoMessage() {}
+
+func (x *UpdateCommentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCommentRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCommentRequest) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UpdateCommentRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateCommentRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *UpdateCommentRequest) GetExpectedVersion() int32 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type DeleteCommentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCommentRequest) Reset() {
+	*x = DeleteCommentRequest{}
+	mi := &file_posts_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCommentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCommentRequest) ProtoMessage() {}
+
+func (x *DeleteCommentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCommentRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCommentRequest) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DeleteCommentRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteCommentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCommentResponse) Reset() {
+	*x = DeleteCommentResponse{}
+	mi := &file_posts_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCommentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCommentResponse) ProtoMessage() {}
+
+func (x *DeleteCommentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCommentResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCommentResponse) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{15}
+}
+
+type WatchCommentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PostId        string                 `protobuf:"bytes,1,opt,name=post_id,json=postId,proto3" json:"post_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchCommentsRequest) Reset() {
+	*x = WatchCommentsRequest{}
+	mi := &file_posts_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchCommentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchCommentsRequest) ProtoMessage() {}
+
+func (x *WatchCommentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchCommentsRequest.ProtoReflect.Descriptor instead.
+func (*WatchCommentsRequest) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *WatchCommentsRequest) GetPostId() string {
+	if x != nil {
+		return x.PostId
+	}
+	return ""
+}
+
+type CommentEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Comment       *Comment               `protobuf:"bytes,1,opt,name=comment,proto3" json:"comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommentEvent) Reset() {
+	*x = CommentEvent{}
+	mi := &file_posts_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommentEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommentEvent) ProtoMessage() {}
+
+func (x *CommentEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_posts_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommentEvent.ProtoReflect.Descriptor instead.
+func (*CommentEvent) Descriptor() ([]byte, []int) {
+	return file_posts_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CommentEvent) GetComment() *Comment {
+	if x != nil {
+		return x.Comment
+	}
+	return nil
+}
+
+var File_posts_proto protoreflect.FileDescriptor
+
+const file_posts_proto_rawDesc = "" +
+	"\n" +
+	"\vposts.proto\x12\rozon.posts.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd3\x03\n" +
+	"\x04Post\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tauthor_id\x18\x02 \x01(\tR\bauthorId\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x120\n" +
+	"\x14are_comments_allowed\x18\x04 \x01(\bR\x12areCommentsAllowed\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x121\n" +
+	"\x06status\x18\a \x01(\x0e2\x19.ozon.posts.v1.PostStatusR\x06status\x129\n" +
+	"\n" +
+	"publish_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tpublishAt\x12\x1b\n" +
+	"\tis_pinned\x18\t \x01(\bR\bisPinned\x12#\n" +
+	"\rcomment_count\x18\n" +
+	" \x01(\x05R\fcommentCount\x12\x18\n" +
+	"\aversion\x18\v \x01(\x05R\aversion\x12\x12\n" +
+	"\x04tags\x18\f \x03(\tR\x04tags\"\x9b\x03\n" +
+	"\aComment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\apost_id\x18\x02 \x01(\tR\x06postId\x12/\n" +
+	"\x11parent_comment_id\x18\x03 \x01(\tH\x00R\x0fparentCommentId\x88\x01\x01\x12\x1b\n" +
+	"\tauthor_id\x18\x04 \x01(\tR\bauthorId\x12\x18\n" +
+	"\acontent\x18\x05 \x01(\tR\acontent\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1b\n" +
+	"\tis_pinned\x18\b \x01(\bR\bisPinned\x12\x1b\n" +
+	"\tis_locked\x18\t \x01(\bR\bisLocked\x12\x1f\n" +
+	"\vreply_count\x18\n" +
+	" \x01(\x05R\n" +
+	"replyCount\x12\x18\n" +
+	"\aversion\x18\v \x01(\x05R\aversionB\x14\n" +
+	"\x12_parent_comment_id\"*\n" +
+	"\x10ListPostsRequest\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x05R\x06offset\">\n" +
+	"\x11ListPostsResponse\x12)\n" +
+	"\x05posts\x18\x01 \x03(\v2\x13.ozon.posts.v1.PostR\x05posts\" \n" +
+	"\x0eGetPostRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xc8\x02\n" +
+	"\x11CreatePostRequest\x12\x1b\n" +
+	"\tauthor_id\x18\x01 \x01(\tR\bauthorId\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x120\n" +
+	"\x14are_comments_allowed\x18\x03 \x01(\bR\x12areCommentsAllowed\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\x121\n" +
+	"\x06status\x18\x05 \x01(\x0e2\x19.ozon.posts.v1.PostStatusR\x06status\x129\n" +
+	"\n" +
+	"publish_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tpublishAt\x121\n" +
+	"\x12client_mutation_id\x18\a \x01(\tH\x00R\x10clientMutationId\x88\x01\x01B\x15\n" +
+	"\x13_client_mutation_id\"\x86\x03\n" +
+	"\x11UpdatePostRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\acontent\x18\x02 \x01(\tH\x00R\acontent\x88\x01\x01\x125\n" +
+	"\x14are_comments_allowed\x18\x03 \x01(\bH\x01R\x12areCommentsAllowed\x88\x01\x01\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\x12\x1f\n" +
+	"\vupdate_tags\x18\x05 \x01(\bR\n" +
+	"updateTags\x121\n" +
+	"\x06status\x18\x06 \x01(\x0e2\x19.ozon.posts.v1.PostStatusR\x06status\x129\n" +
+	"\n" +
+	"publish_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tpublishAt\x12.\n" +
+	"\x10expected_version\x18\b \x01(\x05H\x02R\x0fexpectedVersion\x88\x01\x01B\n" +
+	"\n" +
+	"\b_contentB\x17\n" +
+	"\x15_are_comments_allowedB\x13\n" +
+	"\x11_expected_version\"#\n" +
+	"\x11DeletePostRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x14\n" +
+	"\x12DeletePostResponse\"~\n" +
+	"\x13ListCommentsRequest\x12\x17\n" +
+	"\apost_id\x18\x01 \x01(\tR\x06postId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x126\n" +
+	"\border_by\x18\x03 \x01(\x0e2\x1b.ozon.posts.v1.CommentOrderR\aorderBy\"\x83\x01\n" +
+	"\x12ListRepliesRequest\x12\x1d\n" +
+	"\n" +
+	"comment_id\x18\x01 \x01(\tR\tcommentId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x126\n" +
+	"\border_by\x18\x03 \x01(\x0e2\x1b.ozon.posts.v1.CommentOrderR\aorderBy\"J\n" +
+	"\x14ListCommentsResponse\x122\n" +
+	"\bcomments\x18\x01 \x03(\v2\x16.ozon.posts.v1.CommentR\bcomments\"\xf5\x01\n" +
+	"\x12PostCommentRequest\x12\x17\n" +
+	"\apost_id\x18\x01 \x01(\tR\x06postId\x12/\n" +
+	"\x11parent_comment_id\x18\x02 \x01(\tH\x00R\x0fparentCommentId\x88\x01\x01\x12\x1b\n" +
+	"\tauthor_id\x18\x03 \x01(\tR\bauthorId\x12\x18\n" +
+	"\acontent\x18\x04 \x01(\tR\acontent\x121\n" +
+	"\x12client_mutation_id\x18\x05 \x01(\tH\x01R\x10clientMutationId\x88\x01\x01B\x14\n" +
+	"\x12_parent_comment_idB\x15\n" +
+	"\x13_client_mutation_id\"\x85\x01\n" +
+	"\x14UpdateCommentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12.\n" +
+	"\x10expected_version\x18\x03 \x01(\x05H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version\"&\n" +
+	"\x14DeleteCommentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x17\n" +
+	"\x15DeleteCommentResponse\"/\n" +
+	"\x14WatchCommentsRequest\x12\x17\n" +
+	"\apost_id\x18\x01 \x01(\tR\x06postId\"@\n" +
+	"\fCommentEvent\x120\n" +
+	"\acomment\x18\x01 \x01(\v2\x16.ozon.posts.v1.CommentR\acomment*v\n" +
+	"\n" +
+	"PostStatus\x12\x1b\n" +
+	"\x17POST_STATUS_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11POST_STATUS_DRAFT\x10\x01\x12\x19\n" +
+	"\x15POST_STATUS_SCHEDULED\x10\x02\x12\x19\n" +
+	"\x15POST_STATUS_PUBLISHED\x10\x03*\x98\x01\n" +
+	"\fCommentOrder\x12\x1d\n" +
+	"\x19COMMENT_ORDER_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14COMMENT_ORDER_NEWEST\x10\x01\x12\x18\n" +
+	"\x14COMMENT_ORDER_OLDEST\x10\x02\x12\x15\n" +
+	"\x11COMMENT_ORDER_TOP\x10\x03\x12\x1e\n" +
+	"\x1aCOMMENT_ORDER_MOST_REPLIES\x10\x042\xec\x06\n" +
+	"\x05Posts\x12N\n" +
+	"\tListPosts\x12\x1f.ozon.posts.v1.ListPostsRequest\x1a .ozon.posts.v1.ListPostsResponse\x12=\n" +
+	"\aGetPost\x12\x1d.ozon.posts.v1.GetPostRequest\x1a\x13.ozon.posts.v1.Post\x12C\n" +
+	"\n" +
+	"CreatePost\x12 .ozon.posts.v1.CreatePostRequest\x1a\x13.ozon.posts.v1.Post\x12C\n" +
+	"\n" +
+	"UpdatePost\x12 .ozon.posts.v1.UpdatePostRequest\x1a\x13.ozon.posts.v1.Post\x12Q\n" +
+	"\n" +
+	"DeletePost\x12 .ozon.posts.v1.DeletePostRequest\x1a!.ozon.posts.v1.DeletePostResponse\x12W\n" +
+	"\fListComments\x12\".ozon.posts.v1.ListCommentsRequest\x1a#.ozon.posts.v1.ListCommentsResponse\x12U\n" +
+	"\vListReplies\x12!.ozon.posts.v1.ListRepliesRequest\x1a#.ozon.posts.v1.ListCommentsResponse\x12H\n" +
+	"\vPostComment\x12!.ozon.posts.v1.PostCommentRequest\x1a\x16.ozon.posts.v1.Comment\x12L\n" +
+	"\rUpdateComment\x12#.ozon.posts.v1.UpdateCommentRequest\x1a\x16.ozon.posts.v1.Comment\x12Z\n" +
+	"\rDeleteComment\x12#.ozon.posts.v1.DeleteCommentRequest\x1a$.ozon.posts.v1.DeleteCommentResponse\x12S\n" +
+	"\rWatchComments\x12#.ozon.posts.v1.WatchCommentsRequest\x1a\x1b.ozon.posts.v1.CommentEvent0\x01B\x1aZ\x18ozon/pkg/api/posts;postsb\x06proto3"
+
+var (
+	file_posts_proto_rawDescOnce sync.Once
+	file_posts_proto_rawDescData []byte
+)
+
+func file_posts_proto_rawDescGZIP() []byte {
+	file_posts_proto_rawDescOnce.Do(func() {
+		file_posts_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_posts_proto_rawDesc), len(file_posts_proto_rawDesc)))
+	})
+	return file_posts_proto_rawDescData
+}
+
+var file_posts_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_posts_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_posts_proto_goTypes = []any{
+	(PostStatus)(0),               // 0: ozon.posts.v1.PostStatus
+	(CommentOrder)(0),             // 1: ozon.posts.v1.CommentOrder
+	(*Post)(nil),                  // 2: ozon.posts.v1.Post
+	(*Comment)(nil),               // 3: ozon.posts.v1.Comment
+	(*ListPostsRequest)(nil),      // 4: ozon.posts.v1.ListPostsRequest
+	(*ListPostsResponse)(nil),     // 5: ozon.posts.v1.ListPostsResponse
+	(*GetPostRequest)(nil),        // 6: ozon.posts.v1.GetPostRequest
+	(*CreatePostRequest)(nil),     // 7: ozon.posts.v1.CreatePostRequest
+	(*UpdatePostRequest)(nil),     // 8: ozon.posts.v1.UpdatePostRequest
+	(*DeletePostRequest)(nil),     // 9: ozon.posts.v1.DeletePostRequest
+	(*DeletePostResponse)(nil),    // 10: ozon.posts.v1.DeletePostResponse
+	(*ListCommentsRequest)(nil),   // 11: ozon.posts.v1.ListCommentsRequest
+	(*ListRepliesRequest)(nil),    // 12: ozon.posts.v1.ListRepliesRequest
+	(*ListCommentsResponse)(nil),  // 13: ozon.posts.v1.ListCommentsResponse
+	(*PostCommentRequest)(nil),    // 14: ozon.posts.v1.PostCommentRequest
+	(*UpdateCommentRequest)(nil),  // 15: ozon.posts.v1.UpdateCommentRequest
+	(*DeleteCommentRequest)(nil),  // 16: ozon.posts.v1.DeleteCommentRequest
+	(*DeleteCommentResponse)(nil), // 17: ozon.posts.v1.DeleteCommentResponse
+	(*WatchCommentsRequest)(nil),  // 18: ozon.posts.v1.WatchCommentsRequest
+	(*CommentEvent)(nil),          // 19: ozon.posts.v1.CommentEvent
+	(*timestamppb.Timestamp)(nil), // 20: google.protobuf.Timestamp
+}
+var file_posts_proto_depIdxs = []int32{
+	20, // 0: ozon.posts.v1.Post.created_at:type_name -> google.protobuf.Timestamp
+	20, // 1: ozon.posts.v1.Post.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: ozon.posts.v1.Post.status:type_name -> ozon.posts.v1.PostStatus
+	20, // 3: ozon.posts.v1.Post.publish_at:type_name -> google.protobuf.Timestamp
+	20, // 4: ozon.posts.v1.Comment.created_at:type_name -> google.protobuf.Timestamp
+	20, // 5: ozon.posts.v1.Comment.updated_at:type_name -> google.protobuf.Timestamp
+	2,  // 6: ozon.posts.v1.ListPostsResponse.posts:type_name -> ozon.posts.v1.Post
+	0,  // 7: ozon.posts.v1.CreatePostRequest.status:type_name -> ozon.posts.v1.PostStatus
+	20, // 8: ozon.posts.v1.CreatePostRequest.publish_at:type_name -> google.protobuf.Timestamp
+	0,  // 9: ozon.posts.v1.UpdatePostRequest.status:type_name -> ozon.posts.v1.PostStatus
+	20, // 10: ozon.posts.v1.UpdatePostRequest.publish_at:type_name -> google.protobuf.Timestamp
+	1,  // 11: ozon.posts.v1.ListCommentsRequest.order_by:type_name -> ozon.posts.v1.CommentOrder
+	1,  // 12: ozon.posts.v1.ListRepliesRequest.order_by:type_name -> ozon.posts.v1.CommentOrder
+	3,  // 13: ozon.posts.v1.ListCommentsResponse.comments:type_name -> ozon.posts.v1.Comment
+	3,  // 14: ozon.posts.v1.CommentEvent.comment:type_name -> ozon.posts.v1.Comment
+	4,  // 15: ozon.posts.v1.Posts.ListPosts:input_type -> ozon.posts.v1.ListPostsRequest
+	6,  // 16: ozon.posts.v1.Posts.GetPost:input_type -> ozon.posts.v1.GetPostRequest
+	7,  // 17: ozon.posts.v1.Posts.CreatePost:input_type -> ozon.posts.v1.CreatePostRequest
+	8,  // 18: ozon.posts.v1.Posts.UpdatePost:input_type -> ozon.posts.v1.UpdatePostRequest
+	9,  // 19: ozon.posts.v1.Posts.DeletePost:input_type -> ozon.posts.v1.DeletePostRequest
+	11, // 20: ozon.posts.v1.Posts.ListComments:input_type -> ozon.posts.v1.ListCommentsRequest
+	12, // 21: ozon.posts.v1.Posts.ListReplies:input_type -> ozon.posts.v1.ListRepliesRequest
+	14, // 22: ozon.posts.v1.Posts.PostComment:input_type -> ozon.posts.v1.PostCommentRequest
+	15, // 23: ozon.posts.v1.Posts.UpdateComment:input_type -> ozon.posts.v1.UpdateCommentRequest
+	16, // 24: ozon.posts.v1.Posts.DeleteComment:input_type -> ozon.posts.v1.DeleteCommentRequest
+	18, // 25: ozon.posts.v1.Posts.WatchComments:input_type -> ozon.posts.v1.WatchCommentsRequest
+	5,  // 26: ozon.posts.v1.Posts.ListPosts:output_type -> ozon.posts.v1.ListPostsResponse
+	2,  // 27: ozon.posts.v1.Posts.GetPost:output_type -> ozon.posts.v1.Post
+	2,  // 28: ozon.posts.v1.Posts.CreatePost:output_type -> ozon.posts.v1.Post
+	2,  // 29: ozon.posts.v1.Posts.UpdatePost:output_type -> ozon.posts.v1.Post
+	10, // 30: ozon.posts.v1.Posts.DeletePost:output_type -> ozon.posts.v1.DeletePostResponse
+	13, // 31: ozon.posts.v1.Posts.ListComments:output_type -> ozon.posts.v1.ListCommentsResponse
+	13, // 32: ozon.posts.v1.Posts.ListReplies:output_type -> ozon.posts.v1.ListCommentsResponse
+	3,  // 33: ozon.posts.v1.Posts.PostComment:output_type -> ozon.posts.v1.Comment
+	3,  // 34: ozon.posts.v1.Posts.UpdateComment:output_type -> ozon.posts.v1.Comment
+	17, // 35: ozon.posts.v1.Posts.DeleteComment:output_type -> ozon.posts.v1.DeleteCommentResponse
+	19, // 36: ozon.posts.v1.Posts.WatchComments:output_type -> ozon.posts.v1.CommentEvent
+	26, // [26:37] is the sub-list for method output_type
+	15, // [15:26] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_posts_proto_init() }
+func file_posts_proto_init() {
+	if File_posts_proto != nil {
+		return
+	}
+	file_posts_proto_msgTypes[1].OneofWrappers = []any{}
+	file_posts_proto_msgTypes[5].OneofWrappers = []any{}
+	file_posts_proto_msgTypes[6].OneofWrappers = []any{}
+	file_posts_proto_msgTypes[12].OneofWrappers = []any{}
+	file_posts_proto_msgTypes[13].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_posts_proto_rawDesc), len(file_posts_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_posts_proto_goTypes,
+		DependencyIndexes: file_posts_proto_depIdxs,
+		EnumInfos:         file_posts_proto_enumTypes,
+		MessageInfos:      file_posts_proto_msgTypes,
+	}.Build()
+	File_posts_proto = out.File
+	file_posts_proto_goTypes = nil
+	file_posts_proto_depIdxs = nil
+}
@@ -5,15 +5,37 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-func NewZapConfig() zap.Config {
-	var logConfig zap.Config
-	Level := ""
+// Encodings supported in Config.
+const (
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
+)
+
+type Config struct {
+	// Level is debug, info, warn or error, it falls back to info.
+	Level string `mapstructure:"level"`
+	// Encoding is json or console.
+	Encoding string `mapstructure:"encoding"`
+	// Outputs are stdout, stderr or file paths, stderr by default.
+	Outputs []string `mapstructure:"outputs"`
+}
+
+// NewZapConfig returns the production config with the level, encoding and
+// outputs of cfg. The level is the one changed by SetLevel.
+func NewZapConfig(cfg Config) zap.Config {
+	logConfig := zap.NewProductionConfig()
 
-	level, err := zapcore.ParseLevel(Level)
-	if err != nil {
-		level = zapcore.InfoLevel
+	if parsed, err := zapcore.ParseLevel(cfg.Level); err == nil {
+		level.SetLevel(parsed)
+	}
+	logConfig.Level = level
+
+	if cfg.Encoding != "" {
+		logConfig.Encoding = cfg.Encoding
+	}
+	if len(cfg.Outputs) > 0 {
+		logConfig.OutputPaths = cfg.Outputs
 	}
-	logConfig.Level = zap.NewAtomicLevelAt(level)
 
 	// Настройка формата вывода времени
 	logConfig.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
@@ -1,25 +1,33 @@
 package logger
 
-import "go.uber.org/zap"
+import (
+	"context"
 
-var log *zap.Logger
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	log   *zap.Logger
+	level = zap.NewAtomicLevel()
+)
 
 type Logger struct {
 	*zap.Logger
 }
 
-func InitLogger() {
+// InitLogger builds the global logger.
+func InitLogger(cfg Config) error {
 
-	logConfig := NewZapConfig()
-	logConfig = zap.NewProductionConfig()
+	logConfig := NewZapConfig(cfg)
 
 	var err error
 
 	if log, err = logConfig.Build(zap.AddCallerSkip(1)); err != nil {
-		panic("failed to initialize logger: " + err.Error())
+		return err
 	}
 
-	defer log.Sync()
+	return nil
 }
 
 func GetLogger() *zap.Logger {
@@ -28,3 +36,41 @@ func GetLogger() *zap.Logger {
 	}
 	return log
 }
+
+// SetLevel changes the level of the global logger while it is in use.
+func SetLevel(lvl string) error {
+	parsed, err := zapcore.ParseLevel(lvl)
+	if err != nil {
+		return err
+	}
+
+	level.SetLevel(parsed)
+	return nil
+}
+
+type loggerKey struct{}
+
+// WithContext returns a context carrying the logger of a request.
+func WithContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger of the request, if any.
+func FromContext(ctx context.Context) (Logger, bool) {
+	l, ok := ctx.Value(loggerKey{}).(Logger)
+	return l, ok
+}
+
+// For returns the logger of the request in ctx, which carries the request
+// ID, operation and caller, or l outside of a request.
+func (l Logger) For(ctx context.Context) Logger {
+	if ctxLogger, ok := FromContext(ctx); ok {
+		return ctxLogger
+	}
+	return l
+}
+
+// With returns a logger with the fields added to every entry.
+func (l Logger) With(fields ...zap.Field) Logger {
+	return Logger{Logger: l.Logger.With(fields...)}
+}
@@ -13,7 +13,17 @@ func ConnectionDB(ctx context.Context, DBConn string) *pgxpool.Pool {
 
 	log := logger.GetLogger()
 
-	pool, err := pgxpool.New(ctx, DBConn)
+	cfg, err := pgxpool.ParseConfig(DBConn)
+	if err != nil {
+		log.Fatal("invalid database connection string", zap.Error(err))
+	}
+	cfg.ConnConfig.Tracer = newQueryTracer()
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		log.Fatal("database connection error!", zap.Error(err))
+	}
+
 	if err = pool.Ping(ctx); err != nil {
 		log.Fatal("database connection error!", zap.Error(err))
 	}